@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// cachedFile is a file in the data directory whose name is a checksum
+type cachedFile struct {
+	checksum string
+	path     string
+	size     int64
+}
+
+// findCachedFiles returns all files below dir named after a checksum, optionally with an extension
+func findCachedFiles(dir string) ([]cachedFile, error) {
+	var res []cachedFile
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		name := strings.TrimSuffix(d.Name(), filepath.Ext(d.Name()))
+		if bz, err := hex.DecodeString(name); err != nil || len(bz) != 32 {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		res = append(res, cachedFile{checksum: strings.ToLower(name), path: path, size: info.Size()})
+		return nil
+	})
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].path < res[j].path })
+	return res, err
+}
+
+func totalSize(files []cachedFile) int64 {
+	var sum int64
+	for _, f := range files {
+		sum += f.size
+	}
+	return sum
+}
+
+func runInspectCache(args []string) error {
+	flags := newFlagSet("inspect-cache", "[data-dir]")
+	_ = flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	dataDir := defaultDataDir
+	if flags.NArg() == 1 {
+		dataDir = flags.Arg(0)
+	}
+	if _, err := os.Stat(dataDir); err != nil {
+		return err
+	}
+
+	// This is the layout of the cosmwasm-vm file system cache
+	codes, err := findCachedFiles(filepath.Join(dataDir, "state", "wasm"))
+	if err != nil {
+		return err
+	}
+	modules, err := findCachedFiles(filepath.Join(dataDir, "cache", "modules"))
+	if err != nil {
+		return err
+	}
+	compiled := map[string][]string{}
+	for _, m := range modules {
+		rel, err := filepath.Rel(filepath.Join(dataDir, "cache", "modules"), filepath.Dir(m.path))
+		if err != nil {
+			return err
+		}
+		compiled[m.checksum] = append(compiled[m.checksum], rel)
+	}
+
+	fmt.Printf("Data directory: %s\n", dataDir)
+	fmt.Printf("Codes: %d (%s)\n", len(codes), formatSize(totalSize(codes)))
+	for _, c := range codes {
+		status := "not compiled"
+		if dirs := compiled[c.checksum]; len(dirs) > 0 {
+			status = "compiled for " + strings.Join(dirs, ", ")
+		}
+		fmt.Printf("  %s  %10s  %s\n", c.checksum, formatSize(c.size), status)
+	}
+	fmt.Printf("Compiled modules: %d (%s)\n", len(modules), formatSize(totalSize(modules)))
+
+	// modules without code can be left over from removed codes
+	known := map[string]bool{}
+	for _, c := range codes {
+		known[c.checksum] = true
+	}
+	for _, m := range modules {
+		if !known[m.checksum] {
+			fmt.Printf("  orphaned module %s  %10s\n", m.path, formatSize(m.size))
+		}
+	}
+	return nil
+}
+
+func formatSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+func runMetrics(args []string) error {
+	flags := newFlagSet("metrics", "[checksum...]")
+	vmc := addVMFlags(flags)
+	_ = flags.Parse(args)
+
+	vm, err := vmc.open()
+	if err != nil {
+		return err
+	}
+	defer vm.Cleanup()
+
+	// The metrics are kept in memory by the VM, so they only cover what this process did.
+	// Pinning the given codes loads them into the memory caches.
+	for _, arg := range flags.Args() {
+		checksum, err := parseChecksum(arg)
+		if err != nil {
+			return err
+		}
+		if err := vm.Pin(checksum); err != nil {
+			return err
+		}
+	}
+	metrics, err := vm.GetMetrics()
+	if err != nil {
+		return err
+	}
+	pinned, err := vm.GetPinnedMetrics()
+	if err != nil {
+		return err
+	}
+	return printJSON(struct {
+		Metrics any `json:"metrics"`
+		Pinned  any `json:"pinned"`
+	}{metrics, pinned})
+}
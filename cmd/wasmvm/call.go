@@ -0,0 +1,384 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	wasmvm "github.com/CosmWasm/wasmvm/v2"
+	wasmvmtesting "github.com/CosmWasm/wasmvm/v2/testing"
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+const defaultPrefix = "wasm"
+
+var deserCost = types.UFraction{Numerator: 1, Denominator: 1}
+
+// callConfig holds the flags shared by all contract calls
+type callConfig struct {
+	vm       *vmConfig
+	stateDir string
+	env      string
+	gasLimit uint64
+	prefix   string
+}
+
+func addCallFlags(fs *flag.FlagSet) *callConfig {
+	c := callConfig{vm: addVMFlags(fs)}
+	fs.StringVar(&c.stateDir, "state-dir", defaultStateDir, "directory of the local state the contracts are called against")
+	fs.StringVar(&c.env, "env", "", "JSON merged into the env passed to the contract, e.g. '{\"block\":{\"height\":10}}', or @file to read it from a file")
+	fs.Uint64Var(&c.gasLimit, "gas-limit", defaultGasLimit, "CosmWasm gas limit of the call")
+	fs.StringVar(&c.prefix, "prefix", "", "bech32 prefix of addresses; defaults to the prefix of the local state or \""+defaultPrefix+"\" for a new state")
+	return &c
+}
+
+// localChain calls contracts against the local state. Messages returned by contracts are not executed.
+type localChain struct {
+	cfg   *callConfig
+	vm    *wasmvm.VM
+	state *localState
+	dbs   map[string]*wasmvmtesting.MemDB
+	api   types.GoAPI
+}
+
+// open loads the local state and creates the VM. The caller must call close.
+func (c *callConfig) open() (*localChain, error) {
+	state, err := loadState(c.stateDir)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case state.Prefix == "":
+		state.Prefix = c.prefix
+		if state.Prefix == "" {
+			state.Prefix = defaultPrefix
+		}
+	case c.prefix != "" && c.prefix != state.Prefix:
+		return nil, fmt.Errorf("the local state uses the prefix %q, not %q", state.Prefix, c.prefix)
+	}
+	vm, err := c.vm.open()
+	if err != nil {
+		return nil, err
+	}
+	return &localChain{
+		cfg:   c,
+		vm:    vm,
+		state: state,
+		dbs:   map[string]*wasmvmtesting.MemDB{},
+		api:   types.NewBech32GoAPI(state.Prefix, types.DefaultAddressCosts()),
+	}, nil
+}
+
+func (c *localChain) close() {
+	c.vm.Cleanup()
+}
+
+// db returns the storage of a contract of the state as used by queries
+func (c *localChain) db(addr string) (*wasmvmtesting.MemDB, error) {
+	if db, ok := c.dbs[addr]; ok {
+		return db, nil
+	}
+	contract, err := c.state.contract(addr)
+	if err != nil {
+		return nil, err
+	}
+	db, err := contract.open()
+	if err != nil {
+		return nil, err
+	}
+	c.dbs[addr] = db
+	return db, nil
+}
+
+// validate checks that all given addresses are valid for the bech32 prefix of the state
+func (c *localChain) validate(addrs ...string) error {
+	for _, addr := range addrs {
+		if _, err := c.api.ValidateAddress(addr); err != nil {
+			return fmt.Errorf("invalid address %q: %w", addr, err)
+		}
+	}
+	return nil
+}
+
+// contractAddress returns the address of a new contract. Like the classic contract addresses
+// of wasmd it is the module address of the wasm module derived from the code ID and the
+// instance ID, which is the number of contracts instantiated before plus one.
+func (c *localChain) contractAddress(codeID, instanceID uint64) (string, error) {
+	key := []byte("wasm\x00")
+	key = binary.BigEndian.AppendUint64(key, codeID)
+	key = binary.BigEndian.AppendUint64(key, instanceID)
+	typ := sha256.Sum256([]byte("module"))
+	canon := sha256.Sum256(append(typ[:], key...))
+	addr, _, err := c.api.HumanizeAddress(canon[:])
+	return addr, err
+}
+
+// env returns the env of a call to the contract with the overrides of the -env flag applied
+func (c *localChain) env(addr string) (types.Env, error) {
+	env := types.Env{
+		Block:       c.state.Block,
+		Transaction: &types.TransactionInfo{Index: 0},
+		Contract:    types.ContractInfo{Address: addr},
+	}
+	if c.cfg.env == "" {
+		return env, nil
+	}
+	override, err := readArg(c.cfg.env)
+	if err != nil {
+		return env, err
+	}
+	// unmarshaling into the existing env only overrides the given fields
+	if err := json.Unmarshal(override, &env); err != nil {
+		return env, fmt.Errorf("invalid env override: %w", err)
+	}
+	return env, nil
+}
+
+// callFunc calls an entrypoint of a contract
+type callFunc func(checksum wasmvm.Checksum, env types.Env, store types.KVStore, querier types.Querier, meter types.GasMeter) (*types.ContractResult, uint64, error)
+
+// call calls a contract in a new block and saves the state if the call succeeded
+func (c *localChain) call(addr string, fn callFunc) error {
+	contract, err := c.state.contract(addr)
+	if err != nil {
+		return err
+	}
+	c.state.nextBlock()
+	env, err := c.env(addr)
+	if err != nil {
+		return err
+	}
+	meter := &gasMeter{}
+	// run on a copy of the contract storage, so nothing is written if the call fails
+	db, err := contract.open()
+	if err != nil {
+		return err
+	}
+	res, gasUsed, err := fn(c.state.checksum(contract.CodeID), env, gasStore(db, meter), c.querier(meter), meter)
+	if err != nil {
+		return err
+	}
+	if err := printJSON(callOutput{Contract: addr, GasUsed: gasUsed, SDKGasUsed: meter.GasConsumed(), Result: res}); err != nil {
+		return err
+	}
+	if res.Err != "" {
+		return fmt.Errorf("contract returned an error: %s", res.Err)
+	}
+	if err := contract.store(db); err != nil {
+		return err
+	}
+	return c.state.save(c.cfg.stateDir)
+}
+
+type callOutput struct {
+	Contract string `json:"contract"`
+	// GasUsed is the CosmWasm gas used by the call
+	GasUsed uint64 `json:"gas_used"`
+	// SDKGasUsed is the SDK gas charged for storage access
+	SDKGasUsed uint64 `json:"sdk_gas_used"`
+	Result     any    `json:"result"`
+}
+
+// readArg returns the argument or the content of the file if it starts with @
+func readArg(arg string) ([]byte, error) {
+	if path, ok := strings.CutPrefix(arg, "@"); ok {
+		return os.ReadFile(path)
+	}
+	return []byte(arg), nil
+}
+
+func runInstantiate(args []string) error {
+	fs := newFlagSet("instantiate", "<checksum> <msg>")
+	cfg := addCallFlags(fs)
+	sender := fs.String("sender", "", "bech32 address of the sender (required)")
+	admin := fs.String("admin", "", "address of the admin that can migrate the contract")
+	label := fs.String("label", "", "label of the contract")
+	funds := fs.String("funds", "", "comma separated coins sent to the contract, e.g. 100uatom; they are not taken from the sender")
+	parseArgs(fs, args, 2)
+
+	checksum, err := parseChecksum(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	msg, err := readArg(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	coins, err := parseCoins(*funds)
+	if err != nil {
+		return err
+	}
+	c, err := cfg.open()
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	if err := c.validate(*sender); err != nil {
+		return err
+	}
+	if *admin != "" {
+		if err := c.validate(*admin); err != nil {
+			return err
+		}
+	}
+	c.state.ContractCount++
+	codeID := c.state.codeID(checksum)
+	addr, err := c.contractAddress(codeID, c.state.ContractCount)
+	if err != nil {
+		return err
+	}
+	c.state.Contracts[addr] = &localContract{CodeID: codeID, Creator: *sender, Admin: *admin, Label: *label}
+	if err := c.state.addCoins(addr, coins); err != nil {
+		return err
+	}
+
+	info := types.MessageInfo{Sender: *sender, Funds: coins}
+	return c.call(addr, func(checksum wasmvm.Checksum, env types.Env, store types.KVStore, querier types.Querier, meter types.GasMeter) (*types.ContractResult, uint64, error) {
+		return c.vm.Instantiate(checksum, env, info, msg, store, c.api, querier, meter, cfg.gasLimit, deserCost)
+	})
+}
+
+func runExecute(args []string) error {
+	fs := newFlagSet("execute", "<contract> <msg>")
+	cfg := addCallFlags(fs)
+	sender := fs.String("sender", "", "bech32 address of the sender (required)")
+	funds := fs.String("funds", "", "comma separated coins sent to the contract, e.g. 100uatom; they are not taken from the sender")
+	parseArgs(fs, args, 2)
+
+	msg, err := readArg(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	coins, err := parseCoins(*funds)
+	if err != nil {
+		return err
+	}
+	c, err := cfg.open()
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	if err := c.validate(*sender); err != nil {
+		return err
+	}
+	addr := fs.Arg(0)
+	if _, err := c.state.contract(addr); err != nil {
+		return err
+	}
+	if err := c.state.addCoins(addr, coins); err != nil {
+		return err
+	}
+
+	info := types.MessageInfo{Sender: *sender, Funds: coins}
+	return c.call(addr, func(checksum wasmvm.Checksum, env types.Env, store types.KVStore, querier types.Querier, meter types.GasMeter) (*types.ContractResult, uint64, error) {
+		return c.vm.Execute(checksum, env, info, msg, store, c.api, querier, meter, cfg.gasLimit, deserCost)
+	})
+}
+
+func runQuery(args []string) error {
+	fs := newFlagSet("query", "<contract> <msg>")
+	cfg := addCallFlags(fs)
+	parseArgs(fs, args, 2)
+
+	msg, err := readArg(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	c, err := cfg.open()
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	addr := fs.Arg(0)
+	contract, err := c.state.contract(addr)
+	if err != nil {
+		return err
+	}
+	env, err := c.env(addr)
+	if err != nil {
+		return err
+	}
+	db, err := c.db(addr)
+	if err != nil {
+		return err
+	}
+	meter := &gasMeter{}
+	res, gasUsed, err := c.vm.Query(c.state.checksum(contract.CodeID), env, msg, gasStore(db, meter), c.api, c.querier(meter), meter, cfg.gasLimit, deserCost)
+	if err != nil {
+		return err
+	}
+	output := callOutput{Contract: addr, GasUsed: gasUsed, SDKGasUsed: meter.GasConsumed(), Result: res}
+	if res.Ok != nil && json.Valid(res.Ok) {
+		// show JSON responses as JSON instead of base64
+		output.Result = map[string]json.RawMessage{"ok": res.Ok}
+	}
+	if err := printJSON(output); err != nil {
+		return err
+	}
+	if res.Err != "" {
+		return fmt.Errorf("contract returned an error: %s", res.Err)
+	}
+	return nil
+}
+
+func runMigrate(args []string) error {
+	fs := newFlagSet("migrate", "<contract> <checksum> <msg>")
+	cfg := addCallFlags(fs)
+	sender := fs.String("sender", "", "address of the sender, which must be the admin of the contract")
+	parseArgs(fs, args, 3)
+
+	checksum, err := parseChecksum(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	msg, err := readArg(fs.Arg(2))
+	if err != nil {
+		return err
+	}
+	c, err := cfg.open()
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	addr := fs.Arg(0)
+	contract, err := c.state.contract(addr)
+	if err != nil {
+		return err
+	}
+	if contract.Admin == "" || contract.Admin != *sender {
+		return fmt.Errorf("%q is not the admin of %s", *sender, addr)
+	}
+	// the new code ID is only saved if the migration succeeds
+	contract.CodeID = c.state.codeID(checksum)
+	return c.call(addr, func(checksum wasmvm.Checksum, env types.Env, store types.KVStore, querier types.Querier, meter types.GasMeter) (*types.ContractResult, uint64, error) {
+		return c.vm.Migrate(checksum, env, msg, store, c.api, querier, meter, cfg.gasLimit, deserCost)
+	})
+}
+
+func runSudo(args []string) error {
+	fs := newFlagSet("sudo", "<contract> <msg>")
+	cfg := addCallFlags(fs)
+	parseArgs(fs, args, 2)
+
+	msg, err := readArg(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+	c, err := cfg.open()
+	if err != nil {
+		return err
+	}
+	defer c.close()
+
+	return c.call(fs.Arg(0), func(checksum wasmvm.Checksum, env types.Env, store types.KVStore, querier types.Querier, meter types.GasMeter) (*types.ContractResult, uint64, error) {
+		return c.vm.Sudo(checksum, env, msg, store, c.api, querier, meter, cfg.gasLimit, deserCost)
+	})
+}
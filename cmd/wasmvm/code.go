@@ -0,0 +1,127 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	wasmvm "github.com/CosmWasm/wasmvm/v2"
+)
+
+func runChecksum(args []string) error {
+	fs := newFlagSet("checksum", "<file.wasm>")
+	parseArgs(fs, args, 1)
+	code, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	checksum, err := wasmvm.CreateChecksum(code)
+	if err != nil {
+		return err
+	}
+	fmt.Println(checksum)
+	return nil
+}
+
+func runStore(args []string) error {
+	fs := newFlagSet("store", "<file.wasm>")
+	vmc := addVMFlags(fs)
+	unchecked := fs.Bool("unchecked", false, "skip the static validation of the code, e.g. for codes that were stored on chain before")
+	gasLimit := fs.Uint64("gas-limit", defaultGasLimit, "gas limit for compiling the code")
+	parseArgs(fs, args, 1)
+
+	code, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	vm, err := vmc.open()
+	if err != nil {
+		return err
+	}
+	defer vm.Cleanup()
+
+	var checksum wasmvm.Checksum
+	var gas uint64
+	if *unchecked {
+		checksum, err = vm.StoreCodeUnchecked(code)
+	} else {
+		checksum, gas, err = vm.StoreCode(code, *gasLimit)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Stored code with checksum %s (%d gas)\n", checksum, gas)
+	return nil
+}
+
+func runAnalyze(args []string) error {
+	fs := newFlagSet("analyze", "<checksum>")
+	vmc := addVMFlags(fs)
+	parseArgs(fs, args, 1)
+
+	checksum, err := parseChecksum(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	vm, err := vmc.open()
+	if err != nil {
+		return err
+	}
+	defer vm.Cleanup()
+
+	report, err := vm.AnalyzeCode(checksum)
+	if err != nil {
+		return err
+	}
+	return printJSON(report)
+}
+
+func runPin(args []string) error {
+	fs := newFlagSet("pin", "<checksum>")
+	vmc := addVMFlags(fs)
+	parseArgs(fs, args, 1)
+
+	checksum, err := parseChecksum(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	vm, err := vmc.open()
+	if err != nil {
+		return err
+	}
+	defer vm.Cleanup()
+
+	// The pinned cache lives in memory, so this mostly checks that the code can be loaded
+	// and shows the size of the pinned module.
+	if err := vm.Pin(checksum); err != nil {
+		return err
+	}
+	metrics, err := vm.GetPinnedMetrics()
+	if err != nil {
+		return err
+	}
+	return printJSON(metrics)
+}
+
+// parseChecksum parses a hex encoded checksum
+func parseChecksum(s string) (wasmvm.Checksum, error) {
+	bz, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid checksum %q: %w", s, err)
+	}
+	if len(bz) != 32 {
+		return nil, fmt.Errorf("invalid checksum %q: must be 32 bytes", s)
+	}
+	return bz, nil
+}
+
+// printJSON prints v as indented JSON to stdout
+func printJSON(v any) error {
+	bz, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(bz))
+	return nil
+}
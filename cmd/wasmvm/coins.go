@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+var coinRegexp = regexp.MustCompile(`^([0-9]+)([a-zA-Z][a-zA-Z0-9/:._-]{2,127})$`)
+
+// parseCoins parses a comma separated list of coins like "100uatom,5stake"
+func parseCoins(s string) ([]types.Coin, error) {
+	var coins []types.Coin
+	if s == "" {
+		return coins, nil
+	}
+	for _, part := range strings.Split(s, ",") {
+		m := coinRegexp.FindStringSubmatch(strings.TrimSpace(part))
+		if m == nil {
+			return nil, fmt.Errorf("invalid coin %q", part)
+		}
+		coins = append(coins, types.Coin{Amount: m[1], Denom: m[2]})
+	}
+	return coins, nil
+}
+
+// addAmounts adds two decimal coin amounts
+func addAmounts(a, b string) (string, error) {
+	x, ok := new(big.Int).SetString(a, 10)
+	if !ok {
+		return "", fmt.Errorf("invalid amount %q", a)
+	}
+	y, ok := new(big.Int).SetString(b, 10)
+	if !ok {
+		return "", fmt.Errorf("invalid amount %q", b)
+	}
+	return x.Add(x, y).String(), nil
+}
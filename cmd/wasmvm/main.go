@@ -0,0 +1,103 @@
+// Command wasmvm is a command line interface to the wasmvm library. It stores, analyzes and
+// pins contracts in a VM data directory, calls contracts against a local file-backed state
+// and inspects the caches of existing data directories, e.g. of a validator node.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	wasmvm "github.com/CosmWasm/wasmvm/v2"
+)
+
+// command is a subcommand of the CLI
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"version", "print the version of libwasmvm", runVersion},
+		{"checksum", "print the checksum of a Wasm file", runChecksum},
+		{"store", "compile a Wasm file and store it in the data directory", runStore},
+		{"analyze", "print the analysis report of a stored code", runAnalyze},
+		{"pin", "pin a stored code in the in-memory cache and print the pinned metrics", runPin},
+		{"instantiate", "instantiate a contract in the local state", runInstantiate},
+		{"execute", "execute a contract in the local state", runExecute},
+		{"query", "query a contract in the local state", runQuery},
+		{"migrate", "migrate a contract in the local state to a new code", runMigrate},
+		{"sudo", "call the sudo entrypoint of a contract in the local state", runSudo},
+		{"inspect-cache", "list the codes and compiled modules in a data directory", runInspectCache},
+		{"metrics", "print the cache metrics of a VM on a data directory", runMetrics},
+		{"replay", "replay a recorded contract call and compare its writes and gas", runReplay},
+	}
+}
+
+func usage() string {
+	var b strings.Builder
+	b.WriteString("Usage: wasmvm <command> [flags] [args]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(&b, "  %-14s %s\n", c.name, c.summary)
+	}
+	b.WriteString("\nRun \"wasmvm <command> -h\" for the flags of a command.\n")
+	return b.String()
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprint(os.Stderr, usage())
+		os.Exit(2)
+	}
+	name, args := os.Args[1], os.Args[2:]
+	if name == "help" || name == "-h" || name == "--help" {
+		fmt.Print(usage())
+		return
+	}
+	for _, c := range commands {
+		if c.name == name {
+			if err := c.run(args); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n%s", name, usage())
+	os.Exit(2)
+}
+
+// newFlagSet creates the flag set of a command with the given usage of its arguments
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: wasmvm %s [flags] %s\n", name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses the flags and checks the number of positional arguments
+func parseArgs(fs *flag.FlagSet, args []string, n int) {
+	_ = fs.Parse(args)
+	if fs.NArg() != n {
+		fs.Usage()
+		os.Exit(2)
+	}
+}
+
+func runVersion(args []string) error {
+	fs := newFlagSet("version", "")
+	parseArgs(fs, args, 0)
+	version, err := wasmvm.LibwasmvmVersion()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("libwasmvm: %s\n", version)
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// localQuerier answers the bank balance and wasm queries of a call using the local state.
+// Storage reads of queried contracts are charged to the SDK gas meter of the call and the
+// CosmWasm gas of smart queries is reported by GasConsumed. Other queries are not supported.
+type localQuerier struct {
+	chain   *localChain
+	meter   *gasMeter
+	usedGas uint64
+}
+
+var _ types.Querier = (*localQuerier)(nil)
+
+// querier returns a querier for a call charging storage access to meter
+func (c *localChain) querier(meter *gasMeter) *localQuerier {
+	return &localQuerier{chain: c, meter: meter}
+}
+
+func (q *localQuerier) Query(request types.QueryRequest, gasLimit uint64) ([]byte, error) {
+	switch {
+	case request.Bank != nil:
+		return q.bank(request.Bank)
+	case request.Wasm != nil:
+		return q.wasm(request.Wasm, gasLimit)
+	default:
+		return nil, types.UnsupportedRequest{Kind: "only bank and wasm queries are supported"}
+	}
+}
+
+func (q *localQuerier) GasConsumed() uint64 {
+	return q.usedGas
+}
+
+func (q *localQuerier) bank(request *types.BankQuery) ([]byte, error) {
+	balances := q.chain.state.Balances
+	switch {
+	case request.Balance != nil:
+		coin := types.NewCoin(0, request.Balance.Denom)
+		for _, c := range balances[request.Balance.Address] {
+			if c.Denom == coin.Denom {
+				coin = c
+			}
+		}
+		return json.Marshal(types.BalanceResponse{Amount: coin})
+	case request.AllBalances != nil:
+		return json.Marshal(types.AllBalancesResponse{Amount: balances[request.AllBalances.Address]})
+	default:
+		return nil, types.UnsupportedRequest{Kind: "only bank balance queries are supported"}
+	}
+}
+
+func (q *localQuerier) wasm(request *types.WasmQuery, gasLimit uint64) ([]byte, error) {
+	state := q.chain.state
+	switch {
+	case request.Smart != nil:
+		return q.smart(request.Smart.ContractAddr, request.Smart.Msg, gasLimit)
+	case request.Raw != nil:
+		db, err := q.chain.db(request.Raw.ContractAddr)
+		if err != nil {
+			return nil, err
+		}
+		return gasStore(db, q.meter).Get(request.Raw.Key), nil
+	case request.ContractInfo != nil:
+		contract, err := state.contract(request.ContractInfo.ContractAddr)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(types.ContractInfoResponse{
+			CodeID:  contract.CodeID,
+			Creator: contract.Creator,
+			Admin:   contract.Admin,
+		})
+	case request.CodeInfo != nil:
+		codeID := request.CodeInfo.CodeID
+		if codeID == 0 || codeID > uint64(len(state.Codes)) {
+			return nil, types.NoSuchCode{CodeID: codeID}
+		}
+		return json.Marshal(types.CodeInfoResponse{CodeID: codeID, Checksum: state.checksum(codeID)})
+	default:
+		return nil, types.UnsupportedRequest{Kind: "Empty WasmQuery"}
+	}
+}
+
+// smart queries a contract of the local state with the current storage of the contract
+func (q *localQuerier) smart(addr string, msg []byte, gasLimit uint64) ([]byte, error) {
+	c := q.chain
+	contract, err := c.state.contract(addr)
+	if err != nil {
+		return nil, err
+	}
+	db, err := c.db(addr)
+	if err != nil {
+		return nil, err
+	}
+	env, err := c.env(addr)
+	if err != nil {
+		return nil, err
+	}
+	// the queried contract gets its own querier, so nested query gas is only counted once
+	res, gasUsed, err := c.vm.Query(c.state.checksum(contract.CodeID), env, msg, gasStore(db, q.meter), c.api, c.querier(q.meter), q.meter, gasLimit, deserCost)
+	q.usedGas += gasUsed
+	if err != nil {
+		return nil, err
+	}
+	if res.Err != "" {
+		return nil, errors.New(res.Err)
+	}
+	return res.Ok, nil
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"github.com/CosmWasm/wasmvm/v2/replay"
+)
+
+func runReplay(args []string) error {
+	fs := newFlagSet("replay", "<recording.json>")
+	vmc := addVMFlags(fs)
+	codePath := fs.String("code", "", "Wasm file of the recorded contract; required unless it is stored in the data directory")
+	parseArgs(fs, args, 1)
+
+	rec, err := replay.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	vm, err := vmc.open()
+	if err != nil {
+		return err
+	}
+	defer vm.Cleanup()
+
+	if *codePath != "" {
+		code, err := os.ReadFile(*codePath)
+		if err != nil {
+			return err
+		}
+		checksum, err := vm.StoreCodeUnchecked(code)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(checksum, rec.Checksum) {
+			return fmt.Errorf("checksum of %s is %s but the recording is of %s", *codePath, checksum, rec.Checksum)
+		}
+	}
+
+	replayed, err := vm.Replay(rec)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Replayed %s of %s: %d callbacks, %d writes, %d gas used\n",
+		rec.Entrypoint, rec.Checksum, len(replayed.Ops), len(replayed.Writes()), replayed.GasReport.UsedInternally)
+	if replayed.Error != "" {
+		fmt.Printf("Call failed: %s\n", replayed.Error)
+	}
+	diff := replay.Diff(rec, replayed)
+	if len(diff) == 0 {
+		fmt.Println("No differences")
+		return nil
+	}
+	for _, d := range diff {
+		fmt.Println(d)
+	}
+	return fmt.Errorf("%d differences", len(diff))
+}
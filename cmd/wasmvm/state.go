@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	wasmvmtesting "github.com/CosmWasm/wasmvm/v2/testing"
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+const (
+	defaultStateDir = "wasmvm-state"
+	stateFile       = "state.json"
+)
+
+// localState is the chain state contracts are called against. It is stored as JSON in a state directory.
+type localState struct {
+	// Block is the block info of the last call. The height and time are increased before every call.
+	Block types.BlockInfo `json:"block"`
+	// Prefix is the bech32 prefix of all addresses. It is set by the first call.
+	Prefix string `json:"prefix"`
+	// Codes are the checksums of all codes used by contracts. The code ID is the index + 1.
+	Codes         []types.Checksum                   `json:"codes"`
+	ContractCount uint64                             `json:"contract_count"`
+	Contracts     map[string]*localContract          `json:"contracts"`
+	Balances      map[string]types.Array[types.Coin] `json:"balances"`
+}
+
+type localContract struct {
+	CodeID  uint64 `json:"code_id"`
+	Creator string `json:"creator"`
+	Admin   string `json:"admin,omitempty"`
+	Label   string `json:"label"`
+	// Storage maps hex encoded keys to values
+	Storage map[string][]byte `json:"storage"`
+}
+
+// loadState reads the state from the given directory or returns an empty state if there is none.
+func loadState(dir string) (*localState, error) {
+	s := &localState{
+		Block: types.BlockInfo{
+			Height:  1,
+			Time:    1_700_000_000_000_000_000,
+			ChainID: "wasmvm-local",
+		},
+		Contracts: map[string]*localContract{},
+		Balances:  map[string]types.Array[types.Coin]{},
+	}
+	bz, err := os.ReadFile(filepath.Join(dir, stateFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(bz, s); err != nil {
+		return nil, fmt.Errorf("cannot parse state in %s: %w", dir, err)
+	}
+	return s, nil
+}
+
+// save writes the state to the given directory.
+func (s *localState) save(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	bz, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first to not corrupt the state when interrupted
+	tmp := filepath.Join(dir, stateFile+".tmp")
+	if err := os.WriteFile(tmp, bz, 0o644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(dir, stateFile))
+}
+
+// nextBlock advances the block by one height and 5 seconds
+func (s *localState) nextBlock() {
+	s.Block.Height++
+	s.Block.Time += 5_000_000_000
+}
+
+func (s *localState) contract(addr string) (*localContract, error) {
+	c, ok := s.Contracts[addr]
+	if !ok {
+		return nil, types.NoSuchContract{Addr: addr}
+	}
+	return c, nil
+}
+
+func (s *localState) checksum(codeID uint64) types.Checksum {
+	return s.Codes[codeID-1]
+}
+
+// codeID returns the code ID of the checksum, adding it to the codes if needed
+func (s *localState) codeID(checksum types.Checksum) uint64 {
+	for i, c := range s.Codes {
+		if c.String() == checksum.String() {
+			return uint64(i + 1)
+		}
+	}
+	s.Codes = append(s.Codes, checksum)
+	return uint64(len(s.Codes))
+}
+
+// addCoins adds coins to the balance of an address
+func (s *localState) addCoins(addr string, coins []types.Coin) error {
+	balance := s.Balances[addr]
+	for _, coin := range coins {
+		found := false
+		for i, b := range balance {
+			if b.Denom == coin.Denom {
+				sum, err := addAmounts(b.Amount, coin.Amount)
+				if err != nil {
+					return err
+				}
+				balance[i].Amount = sum
+				found = true
+			}
+		}
+		if !found {
+			balance = append(balance, coin)
+		}
+	}
+	sort.Slice(balance, func(i, j int) bool { return balance[i].Denom < balance[j].Denom })
+	s.Balances[addr] = balance
+	return nil
+}
+
+// open returns the storage of a contract as a MemDB
+func (c *localContract) open() (*wasmvmtesting.MemDB, error) {
+	db := wasmvmtesting.NewMemDB()
+	for k, v := range c.Storage {
+		key, err := hex.DecodeString(k)
+		if err != nil {
+			return nil, fmt.Errorf("invalid storage key %q: %w", k, err)
+		}
+		if err := db.Set(key, v); err != nil {
+			return nil, err
+		}
+	}
+	return db, nil
+}
+
+// store replaces the storage of a contract with the content of the MemDB
+func (c *localContract) store(db *wasmvmtesting.MemDB) error {
+	it, err := db.Iterator(nil, nil)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+	c.Storage = map[string][]byte{}
+	for ; it.Valid(); it.Next() {
+		c.Storage[hex.EncodeToString(it.Key())] = it.Value()
+	}
+	return nil
+}
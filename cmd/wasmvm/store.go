@@ -0,0 +1,78 @@
+package main
+
+import (
+	"math"
+
+	"github.com/CosmWasm/wasmvm/v2/store"
+	wasmvmtesting "github.com/CosmWasm/wasmvm/v2/testing"
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// dbStore is a types.KVStore backed by a MemDB without gas metering
+type dbStore struct {
+	db *wasmvmtesting.MemDB
+}
+
+var _ types.KVStore = dbStore{}
+
+func (s dbStore) Get(key []byte) []byte {
+	v, err := s.db.Get(key)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (s dbStore) Set(key, value []byte) {
+	if err := s.db.Set(key, value); err != nil {
+		panic(err)
+	}
+}
+
+func (s dbStore) Delete(key []byte) {
+	if err := s.db.Delete(key); err != nil {
+		panic(err)
+	}
+}
+
+func (s dbStore) Iterator(start, end []byte) types.Iterator {
+	it, err := s.db.Iterator(start, end)
+	if err != nil {
+		panic(err)
+	}
+	return it
+}
+
+func (s dbStore) ReverseIterator(start, end []byte) types.Iterator {
+	it, err := s.db.ReverseIterator(start, end)
+	if err != nil {
+		panic(err)
+	}
+	return it
+}
+
+// gasMeter counts the SDK gas charged for storage access. Like the infinite gas meter of
+// the Cosmos SDK it has no limit; calls are limited by their CosmWasm gas limit.
+type gasMeter struct {
+	consumed types.Gas
+}
+
+var _ store.GasMeter = (*gasMeter)(nil)
+
+func (m *gasMeter) GasConsumed() types.Gas {
+	return m.consumed
+}
+
+// ConsumeGas adds amount to the consumed gas, saturating at math.MaxUint64
+func (m *gasMeter) ConsumeGas(amount types.Gas, _ string) {
+	if math.MaxUint64-m.consumed < amount {
+		m.consumed = math.MaxUint64
+		return
+	}
+	m.consumed += amount
+}
+
+// gasStore returns the storage in db as a store that charges the SDK gas of the Cosmos SDK to meter
+func gasStore(db *wasmvmtesting.MemDB, meter *gasMeter) types.KVStore {
+	return store.NewGasStore(dbStore{db: db}, meter, store.DefaultGasConfig())
+}
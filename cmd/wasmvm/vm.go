@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"strings"
+
+	wasmvm "github.com/CosmWasm/wasmvm/v2"
+)
+
+const (
+	defaultDataDir  = "wasmvm-data"
+	defaultGasLimit = 500_000_000_000
+)
+
+var defaultCapabilities = []string{
+	"iterator", "staking", "stargate",
+	"cosmwasm_1_1", "cosmwasm_1_2", "cosmwasm_1_3", "cosmwasm_1_4", "cosmwasm_2_0", "cosmwasm_2_1",
+}
+
+// vmConfig holds the flags used to create a VM
+type vmConfig struct {
+	dataDir       string
+	capabilities  string
+	memoryLimit   uint
+	cacheSize     uint
+	iteratorLimit int
+	iteratorBatch int
+	debug         bool
+}
+
+func addVMFlags(fs *flag.FlagSet) *vmConfig {
+	var c vmConfig
+	fs.StringVar(&c.dataDir, "data-dir", defaultDataDir, "data directory of the VM")
+	fs.StringVar(&c.capabilities, "capabilities", strings.Join(defaultCapabilities, ","), "comma separated list of capabilities supported by the VM")
+	fs.UintVar(&c.memoryLimit, "memory-limit", 32, "memory limit of a contract instance in MiB")
+	fs.UintVar(&c.cacheSize, "cache-size", 100, "size of the in-memory cache in MiB")
+	fs.IntVar(&c.iteratorLimit, "iterator-limit", wasmvm.DefaultIteratorLimit, "maximum number of iterators per contract call")
+	fs.IntVar(&c.iteratorBatch, "iterator-batch-size", 0, "number of records an iterator fetches per callback (0 disables batching)")
+	fs.BoolVar(&c.debug, "debug", false, "print debug output of contracts")
+	return &c
+}
+
+// open creates the VM. The caller must call Cleanup on it.
+func (c *vmConfig) open() (*wasmvm.VM, error) {
+	if err := os.MkdirAll(c.dataDir, 0o755); err != nil {
+		return nil, err
+	}
+	vm, err := wasmvm.NewVM(c.dataDir, strings.Split(c.capabilities, ","), uint32(c.memoryLimit), c.debug, uint32(c.cacheSize))
+	if err != nil {
+		return nil, err
+	}
+	vm.SetIteratorLimit(c.iteratorLimit)
+	vm.SetIteratorBatchSize(c.iteratorBatch)
+	return vm, nil
+}
@@ -0,0 +1,192 @@
+// Package dispatch implements the execution of sub-messages returned by contracts.
+//
+// The rules are the same as in wasmd: every sub-message runs on a branch of the state that is
+// only written back if the sub-message succeeds. Depending on SubMsg.ReplyOn the result is
+// passed to the reply entry point of the calling contract, or an error aborts the processing
+// of the remaining messages. SubMsg.GasLimit (in Cosmos SDK gas) limits the gas available to
+// the sub-message and SubMsg.Payload is forwarded to the reply.
+//
+// As in wasmd, the error passed to a reply is redacted to its ABCI codespace and code, since
+// error messages are not guaranteed to be the same on all nodes. Running out of gas can only
+// be handled in a reply if the sub-message exceeded its own SubMsg.GasLimit. Running out of
+// the gas of the caller always aborts.
+//
+// Executing individual messages and calling contracts is left to a MessageHandler.
+package dispatch
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// Store is the state that messages are executed on.
+type Store interface {
+	// Branch returns an isolated copy of the store. Changes to the branch are applied to
+	// this store only when Write is called on the branch.
+	Branch() BranchedStore
+}
+
+// BranchedStore is a Store created by Store.Branch.
+type BranchedStore interface {
+	Store
+	// Write applies all changes to the store this was branched from.
+	Write()
+}
+
+// Result is the result of executing a message or calling the reply entry point.
+type Result struct {
+	// Events are all events emitted by the execution
+	Events []types.Event
+	// Data is the data returned by the execution. For a reply this should only be set if the
+	// reply returned data, since it overrides the data of the calling contract.
+	Data []byte
+	// MsgResponses are the responses of the executed messages, e.g. the protobuf encoded
+	// MsgExecuteContractResponse. They are passed to the reply in SubMsgResponse.MsgResponses.
+	MsgResponses []types.MsgResponse
+	// GasUsed is the CosmWasm gas used by the execution
+	GasUsed uint64
+}
+
+// MessageHandler executes individual messages and calls the reply entry point of contracts.
+//
+// Both methods should return the gas used even in case of an error, i.e. a Result with only
+// GasUsed set. A nil Result is treated as no gas used.
+//
+// Implementations executing contracts are responsible for dispatching the sub-messages of the
+// contract's response, typically by calling Dispatcher.DispatchSubMessages.
+type MessageHandler interface {
+	// Handle executes msg on behalf of sender. The store is discarded if an error is returned.
+	Handle(store Store, sender string, msg types.CosmosMsg, gasLimit uint64) (*Result, error)
+	// Reply calls the reply entry point of contract.
+	Reply(store Store, contract string, reply types.Reply, gasLimit uint64) (*Result, error)
+}
+
+// Dispatcher executes the sub-messages of a contract response.
+type Dispatcher struct {
+	Handler MessageHandler
+	// GasMultiplier converts SubMsg.GasLimit and Reply.GasUsed between Cosmos SDK gas and
+	// CosmWasm gas. It should be the GasMultiplier of the VM's GasConfig, so that sub-messages
+	// are converted like the gas meters of the calls. As there, zero converts 1:1.
+	GasMultiplier types.GasMultiplier
+}
+
+// NewDispatcher creates a Dispatcher, typically with the GasMultiplier of VM.GasConfig.
+func NewDispatcher(handler MessageHandler, multiplier types.GasMultiplier) *Dispatcher {
+	return &Dispatcher{Handler: handler, GasMultiplier: multiplier}
+}
+
+// DispatchSubMessages executes the sub-messages sent by contract in order.
+//
+// The returned result contains the events of all successful sub-messages and replies.
+// Its data is set to the data returned by the last reply that returned data, if any.
+// On error the state changes of the failed sub-message are discarded, but changes of
+// previous sub-messages are still written to store. Callers should thus run the contract
+// call and its sub-messages on a branch themselves.
+func (d *Dispatcher) DispatchSubMessages(store Store, contract string, msgs []types.SubMsg, gasLimit uint64) (*Result, error) {
+	res := &Result{}
+	for _, msg := range msgs {
+		if res.GasUsed > gasLimit {
+			return res, types.OutOfGasError{}
+		}
+		subRes, err := d.dispatchSubMsg(store, contract, msg, gasLimit-res.GasUsed)
+		res.GasUsed += subRes.GasUsed
+		if err != nil {
+			return res, err
+		}
+		res.Events = append(res.Events, subRes.Events...)
+		if subRes.Data != nil {
+			res.Data = subRes.Data
+		}
+	}
+	return res, nil
+}
+
+func (d *Dispatcher) dispatchSubMsg(store Store, contract string, msg types.SubMsg, gasLimit uint64) (Result, error) {
+	multiplier := d.GasMultiplier
+	subGasLimit := gasLimit
+	// ownLimit is true if the gas available to the sub-message is limited by its own GasLimit
+	ownLimit := false
+	if msg.GasLimit != nil && *msg.GasLimit < multiplier.FromWasmVMGas(subGasLimit) {
+		// cannot overflow since the result is smaller than gasLimit
+		subGasLimit = multiplier.ToWasmVMGasSaturating(*msg.GasLimit)
+		ownLimit = true
+	}
+
+	branch := store.Branch()
+	res, err := d.Handler.Handle(branch, contract, msg.Msg, subGasLimit)
+	if res == nil {
+		res = &Result{}
+	}
+	if err == nil {
+		branch.Write()
+	} else if errors.As(err, &types.OutOfGasError{}) && !ownLimit {
+		return Result{GasUsed: res.GasUsed}, err
+	}
+
+	var result types.SubMsgResult
+	switch {
+	case err == nil && (msg.ReplyOn == types.ReplyAlways || msg.ReplyOn == types.ReplySuccess):
+		result.Ok = &types.SubMsgResponse{
+			Events:       res.Events,
+			Data:         res.Data,
+			MsgResponses: res.MsgResponses,
+		}
+	case err != nil && (msg.ReplyOn == types.ReplyAlways || msg.ReplyOn == types.ReplyError):
+		result.Err = redactError(err)
+	case err != nil:
+		return Result{GasUsed: res.GasUsed}, err
+	default:
+		// success without reply: the data of the sub-message is dropped
+		return Result{Events: res.Events, GasUsed: res.GasUsed}, nil
+	}
+
+	if res.GasUsed > gasLimit {
+		return Result{GasUsed: res.GasUsed}, types.OutOfGasError{}
+	}
+	reply := types.Reply{
+		GasUsed: multiplier.FromWasmVMGas(res.GasUsed),
+		ID:      msg.ID,
+		Result:  result,
+		Payload: msg.Payload,
+	}
+	replyRes, err := d.Handler.Reply(store, contract, reply, gasLimit-res.GasUsed)
+	if replyRes == nil {
+		replyRes = &Result{}
+	}
+	gasUsed := res.GasUsed + replyRes.GasUsed
+	if err != nil {
+		return Result{GasUsed: gasUsed}, err
+	}
+	var events []types.Event
+	if result.Ok != nil {
+		events = append(events, res.Events...)
+	}
+	events = append(events, replyRes.Events...)
+	return Result{Events: events, Data: replyRes.Data, GasUsed: gasUsed}, nil
+}
+
+// abciError is implemented by errors with an ABCI code, like the registered errors of the Cosmos SDK.
+type abciError interface {
+	Codespace() string
+	ABCICode() uint32
+}
+
+// redactError returns the error message passed to a reply, which is the same as in wasmd.
+// System errors are deterministic and passed on unchanged. For all other errors only the
+// ABCI codespace and code are passed on. Errors without an ABCI code are reported like
+// unregistered errors in the Cosmos SDK and out of gas like sdkerrors.ErrOutOfGas.
+func redactError(err error) string {
+	if types.ToSystemError(err) != nil {
+		return err.Error()
+	}
+	codespace, code := "undefined", uint32(1)
+	var abciErr abciError
+	if errors.As(err, &abciErr) {
+		codespace, code = abciErr.Codespace(), abciErr.ABCICode()
+	} else if errors.As(err, &types.OutOfGasError{}) {
+		codespace, code = "sdk", 11
+	}
+	return fmt.Sprintf("codespace: %s, code: %d", codespace, code)
+}
@@ -0,0 +1,262 @@
+package dispatch
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// memStore is a map based Store
+type memStore struct {
+	data   map[string]string
+	parent *memStore
+}
+
+func newMemStore() *memStore {
+	return &memStore{data: map[string]string{}}
+}
+
+func (s *memStore) Branch() BranchedStore {
+	data := make(map[string]string, len(s.data))
+	for k, v := range s.data {
+		data[k] = v
+	}
+	return &memStore{data: data, parent: s}
+}
+
+func (s *memStore) Write() {
+	s.parent.data = s.data
+}
+
+// mockHandler writes the custom message to the store and fails on custom messages starting with "fail".
+// Each message uses 1000 gas.
+type mockHandler struct {
+	replies []types.Reply
+	// replyData is returned by Reply
+	replyData []byte
+	// replyErr is returned by Reply
+	replyErr error
+}
+
+const msgGas = 1000
+
+func (h *mockHandler) Handle(store Store, sender string, msg types.CosmosMsg, gasLimit uint64) (*Result, error) {
+	if gasLimit < msgGas {
+		return &Result{GasUsed: gasLimit}, types.OutOfGasError{}
+	}
+	s := store.(*memStore)
+	value := string(msg.Custom)
+	s.data[value] = sender
+	if len(value) >= 5 && value[:5] == `"fail` {
+		return &Result{GasUsed: msgGas}, errors.New("failed " + value)
+	}
+	return &Result{
+		Events:       []types.Event{{Type: "handled", Attributes: types.Array[types.EventAttribute]{{Key: "msg", Value: value}}}},
+		Data:         []byte(value),
+		MsgResponses: []types.MsgResponse{{TypeURL: "/mock", Value: []byte(value)}},
+		GasUsed:      msgGas,
+	}, nil
+}
+
+func (h *mockHandler) Reply(store Store, contract string, reply types.Reply, gasLimit uint64) (*Result, error) {
+	h.replies = append(h.replies, reply)
+	store.(*memStore).data["reply"] = contract
+	if h.replyErr != nil {
+		return &Result{GasUsed: 500}, h.replyErr
+	}
+	return &Result{
+		Events:  []types.Event{{Type: "reply", Attributes: types.Array[types.EventAttribute]{}}},
+		Data:    h.replyData,
+		GasUsed: 500,
+	}, nil
+}
+
+// subMsg creates a sub-message with a custom message containing value.
+// replyOn is the JSON representation of SubMsg.ReplyOn, e.g. "always".
+func subMsg(id uint64, value, replyOn string) types.SubMsg {
+	msg := types.SubMsg{
+		ID:  id,
+		Msg: types.CosmosMsg{Custom: []byte(`"` + value + `"`)},
+	}
+	if err := json.Unmarshal([]byte(`"`+replyOn+`"`), &msg.ReplyOn); err != nil {
+		panic(err)
+	}
+	return msg
+}
+
+func TestReplyOn(t *testing.T) {
+	specs := map[string]struct {
+		msg        types.SubMsg
+		expErr     bool
+		expReply   bool
+		expWritten bool
+	}{
+		"success, reply never":   {msg: subMsg(1, "ok", "never"), expWritten: true},
+		"success, reply error":   {msg: subMsg(1, "ok", "error"), expWritten: true},
+		"success, reply success": {msg: subMsg(1, "ok", "success"), expReply: true, expWritten: true},
+		"success, reply always":  {msg: subMsg(1, "ok", "always"), expReply: true, expWritten: true},
+		"failure, reply never":   {msg: subMsg(1, "fail", "never"), expErr: true},
+		"failure, reply success": {msg: subMsg(1, "fail", "success"), expErr: true},
+		"failure, reply error":   {msg: subMsg(1, "fail", "error"), expReply: true},
+		"failure, reply always":  {msg: subMsg(1, "fail", "always"), expReply: true},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			store := newMemStore()
+			handler := &mockHandler{}
+			d := NewDispatcher(handler, types.DefaultGasMultiplier)
+
+			res, err := d.DispatchSubMessages(store, "contract", []types.SubMsg{spec.msg}, 1_000_000)
+			if spec.expErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			_, written := store.data[string(spec.msg.Msg.Custom)]
+			assert.Equal(t, spec.expWritten, written)
+
+			if !spec.expReply {
+				assert.Empty(t, handler.replies)
+				_, replied := store.data["reply"]
+				assert.False(t, replied)
+				return
+			}
+			require.Len(t, handler.replies, 1)
+			reply := handler.replies[0]
+			assert.Equal(t, spec.msg.ID, reply.ID)
+			if spec.expWritten {
+				require.NotNil(t, reply.Result.Ok)
+				assert.Equal(t, "", reply.Result.Err)
+				assert.Equal(t, []byte(`"ok"`), reply.Result.Ok.Data)
+				assert.Equal(t, types.Array[types.MsgResponse]{{TypeURL: "/mock", Value: []byte(`"ok"`)}}, reply.Result.Ok.MsgResponses)
+				assert.Len(t, reply.Result.Ok.Events, 1)
+				assert.Len(t, res.Events, 2)
+			} else {
+				assert.Nil(t, reply.Result.Ok)
+				assert.Equal(t, "codespace: undefined, code: 1", reply.Result.Err)
+				assert.Len(t, res.Events, 1)
+			}
+			assert.Equal(t, "contract", store.data["reply"])
+			assert.Equal(t, uint64(msgGas+500), res.GasUsed)
+		})
+	}
+}
+
+func TestFailureRevertsOnlyFailedSubMessage(t *testing.T) {
+	store := newMemStore()
+	d := NewDispatcher(&mockHandler{}, types.DefaultGasMultiplier)
+
+	_, err := d.DispatchSubMessages(store, "contract", []types.SubMsg{
+		subMsg(1, "first", "never"),
+		subMsg(2, "fail", "error"),
+		subMsg(3, "third", "never"),
+	}, 1_000_000)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{`"first"`: "contract", `"third"`: "contract", "reply": "contract"}, store.data)
+
+	// an error without reply aborts processing
+	store = newMemStore()
+	res, err := d.DispatchSubMessages(store, "contract", []types.SubMsg{
+		subMsg(1, "first", "never"),
+		subMsg(2, "fail", "success"),
+		subMsg(3, "third", "never"),
+	}, 1_000_000)
+	require.EqualError(t, err, `failed "fail"`)
+	assert.Equal(t, map[string]string{`"first"`: "contract"}, store.data)
+	assert.Equal(t, uint64(2*msgGas), res.GasUsed)
+}
+
+func TestReplyError(t *testing.T) {
+	store := newMemStore()
+	d := NewDispatcher(&mockHandler{replyErr: errors.New("reply failed")}, types.DefaultGasMultiplier)
+
+	res, err := d.DispatchSubMessages(store, "contract", []types.SubMsg{subMsg(1, "ok", "always")}, 1_000_000)
+	require.EqualError(t, err, "reply failed")
+	assert.Equal(t, uint64(msgGas+500), res.GasUsed)
+}
+
+func TestGasLimitAndPayload(t *testing.T) {
+	handler := &mockHandler{}
+	d := NewDispatcher(handler, 100)
+
+	// 10 SDK gas are 1000 CosmWasm gas which is enough
+	msg := subMsg(7, "ok", "always")
+	limit := uint64(10)
+	msg.GasLimit = &limit
+	msg.Payload = []byte("payload")
+	_, err := d.DispatchSubMessages(newMemStore(), "contract", []types.SubMsg{msg}, 1_000_000)
+	require.NoError(t, err)
+	require.Len(t, handler.replies, 1)
+	assert.Equal(t, []byte("payload"), handler.replies[0].Payload)
+	assert.Equal(t, uint64(msgGas/100), handler.replies[0].GasUsed)
+
+	// 9 SDK gas are not enough, which is reported to the reply
+	limit = 9
+	handler.replies = nil
+	_, err = d.DispatchSubMessages(newMemStore(), "contract", []types.SubMsg{msg}, 1_000_000)
+	require.NoError(t, err)
+	require.Len(t, handler.replies, 1)
+	assert.Equal(t, "codespace: sdk, code: 11", handler.replies[0].Result.Err)
+	assert.Equal(t, uint64(9), handler.replies[0].GasUsed)
+
+	// the gas limit of the caller applies if it is lower and running out of it aborts
+	limit = 1_000_000
+	handler.replies = nil
+	res, err := d.DispatchSubMessages(newMemStore(), "contract", []types.SubMsg{msg}, 999)
+	require.ErrorIs(t, err, types.OutOfGasError{})
+	assert.Empty(t, handler.replies)
+	assert.Equal(t, uint64(999), res.GasUsed)
+
+	// the same applies without a gas limit
+	msg.GasLimit = nil
+	_, err = d.DispatchSubMessages(newMemStore(), "contract", []types.SubMsg{msg}, 999)
+	require.ErrorIs(t, err, types.OutOfGasError{})
+	assert.Empty(t, handler.replies)
+}
+
+// abciErr is an error with an ABCI code like the registered errors of the Cosmos SDK
+type abciErr struct{}
+
+func (abciErr) Error() string     { return "insufficient funds: 3atom is smaller than 5atom" }
+func (abciErr) Codespace() string { return "sdk" }
+func (abciErr) ABCICode() uint32  { return 5 }
+
+func TestRedactError(t *testing.T) {
+	specs := map[string]struct {
+		err error
+		exp string
+	}{
+		"plain error":  {err: errors.New("node specific"), exp: "codespace: undefined, code: 1"},
+		"abci error":   {err: abciErr{}, exp: "codespace: sdk, code: 5"},
+		"wrapped abci": {err: fmt.Errorf("wrapped: %w", abciErr{}), exp: "codespace: sdk, code: 5"},
+		"out of gas":   {err: types.OutOfGasError{}, exp: "codespace: sdk, code: 11"},
+		"system error": {err: types.NoSuchContract{Addr: "foo"}, exp: types.NoSuchContract{Addr: "foo"}.Error()},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, spec.exp, redactError(spec.err))
+		})
+	}
+}
+
+func TestReplyDataOverridesData(t *testing.T) {
+	d := NewDispatcher(&mockHandler{replyData: []byte("reply data")}, types.DefaultGasMultiplier)
+
+	// without reply the data is dropped
+	res, err := d.DispatchSubMessages(newMemStore(), "contract", []types.SubMsg{subMsg(1, "ok", "never")}, 1_000_000)
+	require.NoError(t, err)
+	assert.Nil(t, res.Data)
+
+	res, err = d.DispatchSubMessages(newMemStore(), "contract", []types.SubMsg{
+		subMsg(1, "ok", "success"),
+		subMsg(2, "ok2", "never"),
+	}, 1_000_000)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("reply data"), res.Data)
+}
@@ -22,6 +22,8 @@ GoError cScan_cgo(db_t *ptr, gas_meter_t *gas_meter, uint64_t *used_gas, U8Slice
 GoError cNext_cgo(IteratorReference *ref, gas_meter_t *gas_meter, uint64_t *used_gas, UnmanagedVector *key, UnmanagedVector *val, UnmanagedVector *errOut);
 GoError cNextKey_cgo(IteratorReference *ref, gas_meter_t *gas_meter, uint64_t *used_gas, UnmanagedVector *key, UnmanagedVector *errOut);
 GoError cNextValue_cgo(IteratorReference *ref, gas_meter_t *gas_meter, uint64_t *used_gas, UnmanagedVector *val, UnmanagedVector *errOut);
+GoError cNextBatch_cgo(IteratorReference *ref, gas_meter_t *gas_meter, uint64_t *used_gas, uint64_t max_records, UnmanagedVector *records, UnmanagedVector *errOut);
+GoError cChargeNext_cgo(IteratorReference *ref, gas_meter_t *gas_meter, uint64_t *used_gas, UnmanagedVector *errOut);
 // api
 GoError cHumanizeAddress_cgo(api_t *ptr, U8SliceView src, UnmanagedVector *dest, UnmanagedVector *errOut, uint64_t *used_gas);
 GoError cCanonicalizeAddress_cgo(api_t *ptr, U8SliceView src, UnmanagedVector *dest, UnmanagedVector *errOut, uint64_t *used_gas);
@@ -34,6 +36,7 @@ GoError cQueryExternal_cgo(querier_t *ptr, uint64_t gas_limit, uint64_t *used_ga
 import "C"
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -99,22 +102,59 @@ var db_vtable = C.DbVtable{
 
 type DBState struct {
 	Store types.KVStore
-	// CallID is used to lookup the proper frame for iterators associated with this contract call (iterator.go)
+	// CallID identifies this contract call
 	CallID uint64
+	// frame holds the iterators of this contract call. Rust passes the DBState back in every
+	// IteratorReference, so iterators are found without a global lookup (see retrieveIterator).
+	frame *frame
+	// IteratorLimit is the maximum number of iterators of this contract call
+	IteratorLimit int
+	// IteratorBatchSize is the maximum number of records an iterator fetches per callback.
+	// It only applies if Store implements types.MeteredKVStore.
+	IteratorBatchSize int
+	// iteratorLimitErr is set when the contract call reached the iterator limit
+	iteratorLimitErr error
 }
 
 // use this to create C.Db in two steps, so the pointer lives as long as the calling stack
 //
-//	state := buildDBState(kv, callID)
+//	callFrame := startCall()
+//	defer endCall(callFrame)
+//	state := buildDBState(kv, callFrame, iteratorOptions{})
 //	db := buildDB(&state, &gasMeter)
 //	// then pass db into some FFI function
-func buildDBState(kv types.KVStore, callID uint64) DBState {
+func buildDBState(kv types.KVStore, callFrame *frame, opts iteratorOptions) DBState {
+	limit := opts.limit
+	if limit <= 0 {
+		limit = DefaultIteratorLimit
+	}
 	return DBState{
-		Store:  kv,
-		CallID: callID,
+		Store:             kv,
+		CallID:            callFrame.callID,
+		frame:             callFrame,
+		IteratorLimit:     limit,
+		IteratorBatchSize: max(opts.batchSize, 0),
 	}
 }
 
+// iteratorOptions configures the iterators of a contract call
+type iteratorOptions struct {
+	// limit is the maximum number of iterators per contract call; 0 means DefaultIteratorLimit
+	limit int
+	// batchSize is the maximum number of records fetched per callback; 0 or 1 disables batching
+	batchSize int
+}
+
+// callError converts the error of a failed contract call. If the call failed because it reached
+// the iterator limit, a types.IteratorLimitError is returned instead of the error message.
+func callError(err error, errmsg C.UnmanagedVector, state *DBState) error {
+	err = errorWithMessage(err, errmsg)
+	if _, ok := err.(types.OutOfGasError); !ok && state.iteratorLimitErr != nil {
+		return state.iteratorLimitErr
+	}
+	return err
+}
+
 // contract: original pointer/struct referenced must live longer than C.Db struct
 // since this is only used internally, we can verify the code that this is the case
 func buildDB(state *DBState, gm *types.GasMeter) C.Db {
@@ -126,29 +166,49 @@ func buildDB(state *DBState, gm *types.GasMeter) C.Db {
 }
 
 var iterator_vtable = C.IteratorVtable{
-	next:       C.any_function_t(C.cNext_cgo),
-	next_key:   C.any_function_t(C.cNextKey_cgo),
-	next_value: C.any_function_t(C.cNextValue_cgo),
+	next:        C.any_function_t(C.cNext_cgo),
+	next_key:    C.any_function_t(C.cNextKey_cgo),
+	next_value:  C.any_function_t(C.cNextValue_cgo),
+	next_batch:  C.any_function_t(C.cNextBatch_cgo),
+	charge_next: C.any_function_t(C.cChargeNext_cgo),
 }
 
+// DefaultIteratorLimit is the default maximum number of iterators per contract call.
+//
 // An iterator including referenced objects is 117 bytes large (calculated using https://github.com/DmitriyVTitov/size).
 // We limit the number of iterators per contract call ID here in order limit memory usage to 32768*117 = ~3.8 MB as a safety measure.
 // In any reasonable contract, gas limits should hit sooner than that though.
-const frameLenLimit = 32768
+const DefaultIteratorLimit = 32768
 
 // contract: original pointer/struct referenced must live longer than C.Db struct
 // since this is only used internally, we can verify the code that this is the case
-func buildIterator(callID uint64, it types.Iterator) (C.IteratorReference, error) {
-	iteratorID, err := storeIterator(callID, it, frameLenLimit)
+func buildIterator(state *DBState, it types.Iterator) (C.IteratorReference, error) {
+	iteratorID, err := state.frame.storeIterator(it, state.IteratorLimit)
 	if err != nil {
 		return C.IteratorReference{}, err
 	}
 	return C.IteratorReference{
-		call_id:     cu64(callID),
+		state:       (*C.db_t)(unsafe.Pointer(state)),
 		iterator_id: cu64(iteratorID),
 	}, nil
 }
 
+// retrieveIterator returns the iterator referenced by ref or nil if it does not exist.
+func retrieveIterator(ref C.IteratorReference) types.Iterator {
+	state := (*DBState)(unsafe.Pointer(ref.state))
+	return state.frame.retrieveIterator(uint64(ref.iterator_id))
+}
+
+// retrieveBatchIterator returns the iterator referenced by ref and panics if it does not
+// exist or does not support batches.
+func retrieveBatchIterator(ref C.IteratorReference) *batchIterator {
+	iter, ok := retrieveIterator(ref).(*batchIterator)
+	if !ok {
+		panic("Unable to retrieve batch iterator.")
+	}
+	return iter
+}
+
 //export cGet
 func cGet(ptr *C.db_t, gasMeter *C.gas_meter_t, usedGas *cu64, key C.U8SliceView, val *C.UnmanagedVector, errOut *C.UnmanagedVector) (ret C.GoError) {
 	defer recoverPanic(&ret)
@@ -242,6 +302,7 @@ func cScan(ptr *C.db_t, gasMeter *C.gas_meter_t, usedGas *cu64, start C.U8SliceV
 	e := copyU8Slice(end)
 
 	var iter types.Iterator
+	batchSize := 0
 	gasBefore := gm.GasConsumed()
 	switch order {
 	case 1: // Ascending
@@ -251,20 +312,36 @@ func cScan(ptr *C.db_t, gasMeter *C.gas_meter_t, usedGas *cu64, start C.U8SliceV
 	default:
 		return C.GoError_BadArgument
 	}
+	if metered, ok := kv.(types.MeteredKVStore); ok && state.IteratorBatchSize > 1 {
+		// batches are read ahead without charging gas, see batchIterator
+		var ahead types.Iterator
+		if order == 1 {
+			ahead = metered.Unmetered().Iterator(s, e)
+		} else {
+			ahead = metered.Unmetered().ReverseIterator(s, e)
+		}
+		iter = &batchIterator{Iterator: iter, ahead: ahead}
+		batchSize = state.IteratorBatchSize
+	}
 	gasAfter := gm.GasConsumed()
 	*usedGas = (cu64)(gasAfter - gasBefore)
 
-	iteratorRef, err := buildIterator(state.CallID, iter)
+	iteratorRef, err := buildIterator(state, iter)
 	if err != nil {
+		iter.Close()
+		if _, ok := err.(types.IteratorLimitError); ok {
+			state.iteratorLimitErr = err
+		}
 		// store the actual error message in the return buffer
 		*errOut = newUnmanagedVector([]byte(err.Error()))
 		return C.GoError_User
 	}
 
 	*out = C.GoIter{
-		gas_meter: gasMeter,
-		reference: iteratorRef,
-		vtable:    iterator_vtable,
+		gas_meter:  gasMeter,
+		reference:  iteratorRef,
+		vtable:     iterator_vtable,
+		batch_size: cu64(batchSize),
 	}
 
 	return C.GoError_None
@@ -279,7 +356,7 @@ func cNext(ref C.IteratorReference, gasMeter *C.gas_meter_t, usedGas *cu64, key
 	// 	}
 
 	defer recoverPanic(&ret)
-	if ref.call_id == 0 || gasMeter == nil || usedGas == nil || key == nil || val == nil || errOut == nil {
+	if ref.state == nil || gasMeter == nil || usedGas == nil || key == nil || val == nil || errOut == nil {
 		// we received an invalid pointer
 		return C.GoError_BadArgument
 	}
@@ -289,7 +366,7 @@ func cNext(ref C.IteratorReference, gasMeter *C.gas_meter_t, usedGas *cu64, key
 	}
 
 	gm := *(*types.GasMeter)(unsafe.Pointer(gasMeter))
-	iter := retrieveIterator(uint64(ref.call_id), uint64(ref.iterator_id))
+	iter := retrieveIterator(ref)
 	if iter == nil {
 		panic("Unable to retrieve iterator.")
 	}
@@ -331,7 +408,7 @@ func nextPart(ref C.IteratorReference, gasMeter *C.gas_meter_t, usedGas *cu64, o
 	// 	}
 
 	defer recoverPanic(&ret)
-	if ref.call_id == 0 || gasMeter == nil || usedGas == nil || output == nil || errOut == nil {
+	if ref.state == nil || gasMeter == nil || usedGas == nil || output == nil || errOut == nil {
 		// we received an invalid pointer
 		return C.GoError_BadArgument
 	}
@@ -341,7 +418,7 @@ func nextPart(ref C.IteratorReference, gasMeter *C.gas_meter_t, usedGas *cu64, o
 	}
 
 	gm := *(*types.GasMeter)(unsafe.Pointer(gasMeter))
-	iter := retrieveIterator(uint64(ref.call_id), uint64(ref.iterator_id))
+	iter := retrieveIterator(ref)
 	if iter == nil {
 		panic("Unable to retrieve iterator.")
 	}
@@ -362,6 +439,64 @@ func nextPart(ref C.IteratorReference, gasMeter *C.gas_meter_t, usedGas *cu64, o
 	return C.GoError_None
 }
 
+//export cNextBatch
+func cNextBatch(ref C.IteratorReference, gasMeter *C.gas_meter_t, usedGas *cu64, maxRecords cu64, records *C.UnmanagedVector, errOut *C.UnmanagedVector) (ret C.GoError) {
+	defer recoverPanic(&ret)
+	if ref.state == nil || gasMeter == nil || usedGas == nil || records == nil || errOut == nil {
+		// we received an invalid pointer
+		return C.GoError_BadArgument
+	}
+	// errOut is unused and we don't check `is_none` because of https://github.com/CosmWasm/wasmvm/issues/536
+	if !(*records).is_none {
+		panic("Got a non-none UnmanagedVector we're about to override. This is a bug because someone has to drop the old one.")
+	}
+
+	gm := *(*types.GasMeter)(unsafe.Pointer(gasMeter))
+	iter := retrieveBatchIterator(ref)
+
+	// The records are read from the unmetered store. Rust charges each of them with
+	// cChargeNext when it hands the record to the contract, so records the contract
+	// never reads are not charged.
+	buf := getBuffer()
+	defer putBuffer(buf)
+	gasBefore := gm.GasConsumed()
+	for n := uint64(0); n < uint64(maxRecords) && iter.ahead.Valid(); n++ {
+		*buf = appendBatchRecord(*buf, iter.ahead.Key(), iter.ahead.Value())
+		iter.ahead.Next()
+	}
+	gasAfter := gm.GasConsumed()
+	*usedGas = (cu64)(gasAfter - gasBefore)
+
+	*records = newUnmanagedVector(*buf)
+	return C.GoError_None
+}
+
+//export cChargeNext
+func cChargeNext(ref C.IteratorReference, gasMeter *C.gas_meter_t, usedGas *cu64, errOut *C.UnmanagedVector) (ret C.GoError) {
+	defer recoverPanic(&ret)
+	if ref.state == nil || gasMeter == nil || usedGas == nil || errOut == nil {
+		// we received an invalid pointer
+		return C.GoError_BadArgument
+	}
+	// errOut is unused and we don't check `is_none` because of https://github.com/CosmWasm/wasmvm/issues/536
+
+	gm := *(*types.GasMeter)(unsafe.Pointer(gasMeter))
+	iter := retrieveBatchIterator(ref)
+	if !iter.Valid() {
+		return C.GoError_None
+	}
+
+	// read the record from the metered iterator like cNext, so the same gas is charged
+	gasBefore := gm.GasConsumed()
+	_ = iter.Key()
+	_ = iter.Value()
+	iter.Next()
+	gasAfter := gm.GasConsumed()
+	*usedGas = (cu64)(gasAfter - gasBefore)
+
+	return C.GoError_None
+}
+
 var api_vtable = C.GoApiVtable{
 	humanize_address:     C.any_function_t(C.cHumanizeAddress_cgo),
 	canonicalize_address: C.any_function_t(C.cCanonicalizeAddress_cgo),
@@ -417,7 +552,8 @@ func cCanonicalizeAddress(ptr *C.api_t, src C.U8SliceView, dest *C.UnmanagedVect
 	}
 
 	api := (*types.GoAPI)(unsafe.Pointer(ptr))
-	s := string(copyU8Slice(src))
+	// the conversion to string copies the address
+	s := string(viewU8Slice(src))
 	c, cost, err := api.CanonicalizeAddress(s)
 	*used_gas = cu64(cost)
 	if err != nil {
@@ -444,7 +580,8 @@ func cValidateAddress(ptr *C.api_t, src C.U8SliceView, errOut *C.UnmanagedVector
 	}
 
 	api := (*types.GoAPI)(unsafe.Pointer(ptr))
-	s := string(copyU8Slice(src))
+	// the conversion to string copies the address
+	s := string(viewU8Slice(src))
 	cost, err := api.ValidateAddress(s)
 
 	*used_gas = cu64(cost)
@@ -485,7 +622,9 @@ func cQueryExternal(ptr *C.querier_t, gasLimit cu64, usedGas *cu64, request C.U8
 
 	// query the data
 	querier := *(*Querier)(unsafe.Pointer(ptr))
-	req := copyU8Slice(request)
+	// RustQuery only decodes the request and the response is serialized below,
+	// so the request can be read in place
+	req := viewU8Slice(request)
 
 	gasBefore := querier.GasConsumed()
 	res := types.RustQuery(querier, req, uint64(gasLimit))
@@ -493,11 +632,15 @@ func cQueryExternal(ptr *C.querier_t, gasLimit cu64, usedGas *cu64, request C.U8
 	*usedGas = (cu64)(gasAfter - gasBefore)
 
 	// serialize the response
-	bz, err := json.Marshal(res)
-	if err != nil {
+	buf := getBuffer()
+	defer putBuffer(buf)
+	w := bytes.NewBuffer(*buf)
+	if err := json.NewEncoder(w).Encode(res); err != nil {
 		*errOut = newUnmanagedVector([]byte(err.Error()))
 		return C.GoError_CannotSerialize
 	}
-	*result = newUnmanagedVector(bz)
+	*buf = w.Bytes()
+	// Encode adds a trailing newline, which json.Marshal does not
+	*result = newUnmanagedVector((*buf)[:len(*buf)-1])
 	return C.GoError_None
 }
@@ -13,6 +13,8 @@ GoError cScan(db_t *ptr, gas_meter_t *gas_meter, uint64_t *used_gas, U8SliceView
 GoError cNext(IteratorReference *ref, gas_meter_t *gas_meter, uint64_t *used_gas, UnmanagedVector *key, UnmanagedVector *val, UnmanagedVector *errOut);
 GoError cNextKey(IteratorReference *ref, gas_meter_t *gas_meter, uint64_t *used_gas, UnmanagedVector *key, UnmanagedVector *errOut);
 GoError cNextValue(IteratorReference *ref, gas_meter_t *gas_meter, uint64_t *used_gas, UnmanagedVector *value, UnmanagedVector *errOut);
+GoError cNextBatch(IteratorReference *ref, gas_meter_t *gas_meter, uint64_t *used_gas, uint64_t max_records, UnmanagedVector *records, UnmanagedVector *errOut);
+GoError cChargeNext(IteratorReference *ref, gas_meter_t *gas_meter, uint64_t *used_gas, UnmanagedVector *errOut);
 // imports (api)
 GoError cHumanizeAddress(api_t *ptr, U8SliceView src, UnmanagedVector *dest, UnmanagedVector *errOut, uint64_t *used_gas);
 GoError cCanonicalizeAddress(api_t *ptr, U8SliceView src, UnmanagedVector *dest, UnmanagedVector *errOut, uint64_t *used_gas);
@@ -44,6 +46,12 @@ GoError cNextKey_cgo(IteratorReference *ref, gas_meter_t *gas_meter, uint64_t *u
 GoError cNextValue_cgo(IteratorReference *ref, gas_meter_t *gas_meter, uint64_t *used_gas, UnmanagedVector *val, UnmanagedVector *errOut) {
 	return cNextValue(ref, gas_meter, used_gas, val, errOut);
 }
+GoError cNextBatch_cgo(IteratorReference *ref, gas_meter_t *gas_meter, uint64_t *used_gas, uint64_t max_records, UnmanagedVector *records, UnmanagedVector *errOut) {
+	return cNextBatch(ref, gas_meter, used_gas, max_records, records, errOut);
+}
+GoError cChargeNext_cgo(IteratorReference *ref, gas_meter_t *gas_meter, uint64_t *used_gas, UnmanagedVector *errOut) {
+	return cChargeNext(ref, gas_meter, used_gas, errOut);
+}
 
 // Gateway functions (api)
 GoError cCanonicalizeAddress_cgo(api_t *ptr, U8SliceView src, UnmanagedVector *dest, UnmanagedVector *errOut, uint64_t *used_gas) {
@@ -1,75 +1,69 @@
 package api
 
 import (
+	"encoding/binary"
+	"errors"
 	"fmt"
 	"math"
-	"sync"
+	"sync/atomic"
 
 	"github.com/CosmWasm/wasmvm/v2/types"
 )
 
-// frame stores all Iterators for one contract call
-type frame []types.Iterator
-
-// iteratorFrames contains one frame for each contract call, indexed by contract call ID.
-var (
-	iteratorFrames      = make(map[uint64]frame)
-	iteratorFramesMutex sync.Mutex
-)
+// frame stores all Iterators for one contract call. It is owned by the DBState of the call,
+// which Rust passes back in every IteratorReference, so no global registry is needed to find it.
+// A contract call runs on one thread, so the iterators are not guarded by a mutex.
+type frame struct {
+	callID    uint64
+	iterators []types.Iterator
+}
 
 // this is a global counter for creating call IDs
-var (
-	latestCallID      uint64
-	latestCallIDMutex sync.Mutex
-)
-
-// startCall is called at the beginning of a contract call to create a new frame in iteratorFrames.
-// It updates latestCallID for generating a new call ID.
-func startCall() uint64 {
-	latestCallIDMutex.Lock()
-	defer latestCallIDMutex.Unlock()
-	latestCallID += 1
-	return latestCallID
+var latestCallID atomic.Uint64
+
+// iteratorStats are the metrics of all frames
+var iteratorStats struct {
+	openFrames           atomic.Uint64
+	openIterators        atomic.Uint64
+	peakIteratorsPerCall atomic.Uint64
+	limitErrors          atomic.Uint64
 }
 
-// removeFrame removes the frame with for the given call ID.
-// The result can be nil when the frame is not initialized,
-// i.e. when startCall() is called but no iterator is stored.
-func removeFrame(callID uint64) frame {
-	iteratorFramesMutex.Lock()
-	defer iteratorFramesMutex.Unlock()
-
-	remove := iteratorFrames[callID]
-	delete(iteratorFrames, callID)
-	return remove
+// startCall is called at the beginning of a contract call to create a new frame with a new call ID.
+func startCall() *frame {
+	return &frame{callID: latestCallID.Add(1)}
 }
 
-// endCall is called at the end of a contract call to remove one item the iteratorFrames
-func endCall(callID uint64) {
-	// we pull removeFrame in another function so we don't hold the mutex while cleaning up the removed frame
-	remove := removeFrame(callID)
-	// free all iterators in the frame when we release it
-	for _, iter := range remove {
+// endCall is called at the end of a contract call to free all iterators in the frame.
+func endCall(f *frame) {
+	if n := uint64(len(f.iterators)); n > 0 {
+		iteratorStats.openFrames.Add(^uint64(0))
+		iteratorStats.openIterators.Add(^(n - 1))
+	}
+	for _, iter := range f.iterators {
 		iter.Close()
 	}
+	f.iterators = nil
 }
 
-// storeIterator will add this to the end of the frame for the given call ID and return
-// an iterator ID to reference it.
+// storeIterator will add this to the end of the frame and return an iterator ID to reference it.
 //
 // We assign iterator IDs starting with 1 for historic reasons. This could be changed to 0
 // I guess.
-func storeIterator(callID uint64, it types.Iterator, frameLenLimit int) (uint64, error) {
-	iteratorFramesMutex.Lock()
-	defer iteratorFramesMutex.Unlock()
-
-	new_index := len(iteratorFrames[callID])
+func (f *frame) storeIterator(it types.Iterator, frameLenLimit int) (uint64, error) {
+	new_index := len(f.iterators)
 	if new_index >= frameLenLimit {
-		return 0, fmt.Errorf("Reached iterator limit (%d)", frameLenLimit)
+		iteratorStats.limitErrors.Add(1)
+		return 0, types.IteratorLimitError{Limit: frameLenLimit}
 	}
 
 	// store at array position `new_index`
-	iteratorFrames[callID] = append(iteratorFrames[callID], it)
+	if new_index == 0 {
+		iteratorStats.openFrames.Add(1)
+	}
+	f.iterators = append(f.iterators, it)
+	iteratorStats.openIterators.Add(1)
+	updatePeak(&iteratorStats.peakIteratorsPerCall, uint64(new_index+1))
 
 	iterator_id, ok := indexToIteratorID(new_index)
 	if !ok {
@@ -80,24 +74,57 @@ func storeIterator(callID uint64, it types.Iterator, frameLenLimit int) (uint64,
 	return iterator_id, nil
 }
 
-// retrieveIterator will recover an iterator based on its ID.
-func retrieveIterator(callID uint64, iteratorID uint64) types.Iterator {
-	indexInFrame, ok := iteratorIdToIndex(iteratorID)
-	if !ok {
-		return nil
+// updatePeak raises peak to value if value is larger.
+func updatePeak(peak *atomic.Uint64, value uint64) {
+	for {
+		current := peak.Load()
+		if value <= current || peak.CompareAndSwap(current, value) {
+			return
+		}
 	}
+}
 
-	iteratorFramesMutex.Lock()
-	defer iteratorFramesMutex.Unlock()
-	myFrame := iteratorFrames[callID]
-	if myFrame == nil {
-		return nil
+// GetIteratorMetrics returns the metrics of the iterators of all contract calls.
+func GetIteratorMetrics() types.IteratorMetrics {
+	return types.IteratorMetrics{
+		OpenFrames:           iteratorStats.openFrames.Load(),
+		OpenIterators:        iteratorStats.openIterators.Load(),
+		PeakIteratorsPerCall: iteratorStats.peakIteratorsPerCall.Load(),
+		LimitErrors:          iteratorStats.limitErrors.Load(),
 	}
-	if indexInFrame >= len(myFrame) {
-		// index out of range
+}
+
+// retrieveIterator returns the iterator with the given ID or nil if it does not exist.
+func (f *frame) retrieveIterator(iteratorID uint64) types.Iterator {
+	indexInFrame, ok := iteratorIdToIndex(iteratorID)
+	if !ok || indexInFrame >= len(f.iterators) {
 		return nil
 	}
-	return myFrame[indexInFrame]
+	return f.iterators[indexInFrame]
+}
+
+// batchIterator is an iterator of a types.MeteredKVStore whose records can be fetched in batches.
+// Batches are read from an iterator of the unmetered store, which charges no gas. The metered
+// iterator is advanced by cChargeNext whenever Rust hands a record of a batch to the contract,
+// so the gas meter is charged for exactly the records the contract reads, like with cNext.
+type batchIterator struct {
+	types.Iterator
+	// ahead iterates over the same records as Iterator without charging gas
+	ahead types.Iterator
+}
+
+func (it *batchIterator) Close() error {
+	return errors.Join(it.Iterator.Close(), it.ahead.Close())
+}
+
+// appendBatchRecord appends a record fetched by cNextBatch to buf. The record is encoded as
+// key length (uint32), key, value length (uint32) and value, with all integers in big endian.
+// This must match `decode_batch` in libwasmvm/src/iterator.rs.
+func appendBatchRecord(buf []byte, key, value []byte) []byte {
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(key)))
+	buf = append(buf, key...)
+	buf = binary.BigEndian.AppendUint32(buf, uint32(len(value)))
+	return append(buf, value...)
 }
 
 // iteratorIdToIndex converts an iterator ID to an index in the frame.
@@ -3,6 +3,7 @@ package api
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"sync"
 	"testing"
 
@@ -10,6 +11,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/CosmWasm/wasmvm/v2/internal/api/testdb"
+	"github.com/CosmWasm/wasmvm/v2/store"
 	"github.com/CosmWasm/wasmvm/v2/types"
 )
 
@@ -24,7 +26,13 @@ func (q queueData) Store(meter MockGasMeter) types.KVStore {
 	return q.store.WithGasMeter(meter)
 }
 
-func setupQueueContractWithData(t *testing.T, cache Cache, values ...int) queueData {
+// MeteredStore returns a store that charges gas for every access like the Cosmos SDK.
+// It implements types.MeteredKVStore, so its iterators can fetch records in batches.
+func (q queueData) MeteredStore(meter MockGasMeter) types.KVStore {
+	return store.NewGasStore(q.store.WithGasMeter(NewMockGasMeter(math.MaxUint64)), meter, store.DefaultGasConfig())
+}
+
+func setupQueueContractWithData(t testing.TB, cache Cache, values ...int) queueData {
 	checksum := createQueueContract(t, cache)
 
 	gasMeter1 := NewMockGasMeter(TESTING_GAS_LIMIT)
@@ -37,7 +45,7 @@ func setupQueueContractWithData(t *testing.T, cache Cache, values ...int) queueD
 	msg := []byte(`{}`)
 
 	igasMeter1 := types.GasMeter(gasMeter1)
-	res, _, err := Instantiate(cache, checksum, env, info, msg, &igasMeter1, store, api, &querier, TESTING_GAS_LIMIT, TESTING_PRINT_DEBUG)
+	res, _, err := Instantiate(cache, checksum, env, info, msg, &igasMeter1, store, api, &querier, TESTING_GAS_LIMIT, TESTING_PRINT_DEBUG, nil)
 	require.NoError(t, err)
 	requireOkResponse(t, res, 0)
 
@@ -45,7 +53,7 @@ func setupQueueContractWithData(t *testing.T, cache Cache, values ...int) queueD
 		// push 17
 		var gasMeter2 types.GasMeter = NewMockGasMeter(TESTING_GAS_LIMIT)
 		push := []byte(fmt.Sprintf(`{"enqueue":{"value":%d}}`, value))
-		res, _, err = Execute(cache, checksum, env, info, push, &gasMeter2, store, api, &querier, TESTING_GAS_LIMIT, TESTING_PRINT_DEBUG)
+		res, _, err = Execute(cache, checksum, env, info, push, &gasMeter2, store, api, &querier, TESTING_GAS_LIMIT, TESTING_PRINT_DEBUG, nil)
 		require.NoError(t, err)
 		requireOkResponse(t, res, 0)
 	}
@@ -64,8 +72,9 @@ func setupQueueContract(t *testing.T, cache Cache) queueData {
 
 func TestStoreIterator(t *testing.T) {
 	const limit = 2000
-	callID1 := startCall()
-	callID2 := startCall()
+	frame1 := startCall()
+	frame2 := startCall()
+	require.NotEqual(t, frame1.callID, frame2.callID)
 
 	store := testdb.NewMemDB()
 	var iter types.Iterator
@@ -73,33 +82,33 @@ func TestStoreIterator(t *testing.T) {
 	var err error
 
 	iter, _ = store.Iterator(nil, nil)
-	index, err = storeIterator(callID1, iter, limit)
+	index, err = frame1.storeIterator(iter, limit)
 	require.NoError(t, err)
 	require.Equal(t, uint64(1), index)
 	iter, _ = store.Iterator(nil, nil)
-	index, err = storeIterator(callID1, iter, limit)
+	index, err = frame1.storeIterator(iter, limit)
 	require.NoError(t, err)
 	require.Equal(t, uint64(2), index)
 
 	iter, _ = store.Iterator(nil, nil)
-	index, err = storeIterator(callID2, iter, limit)
+	index, err = frame2.storeIterator(iter, limit)
 	require.NoError(t, err)
 	require.Equal(t, uint64(1), index)
 	iter, _ = store.Iterator(nil, nil)
-	index, err = storeIterator(callID2, iter, limit)
+	index, err = frame2.storeIterator(iter, limit)
 	require.NoError(t, err)
 	require.Equal(t, uint64(2), index)
 	iter, _ = store.Iterator(nil, nil)
-	index, err = storeIterator(callID2, iter, limit)
+	index, err = frame2.storeIterator(iter, limit)
 	require.NoError(t, err)
 	require.Equal(t, uint64(3), index)
 
-	endCall(callID1)
-	endCall(callID2)
+	endCall(frame1)
+	endCall(frame2)
 }
 
 func TestStoreIteratorHitsLimit(t *testing.T) {
-	callID := startCall()
+	callFrame := startCall()
 
 	store := testdb.NewMemDB()
 	var iter types.Iterator
@@ -107,70 +116,117 @@ func TestStoreIteratorHitsLimit(t *testing.T) {
 	const limit = 2
 
 	iter, _ = store.Iterator(nil, nil)
-	_, err = storeIterator(callID, iter, limit)
+	_, err = callFrame.storeIterator(iter, limit)
 	require.NoError(t, err)
 
 	iter, _ = store.Iterator(nil, nil)
-	_, err = storeIterator(callID, iter, limit)
+	_, err = callFrame.storeIterator(iter, limit)
 	require.NoError(t, err)
 
+	before := GetIteratorMetrics()
+
 	iter, _ = store.Iterator(nil, nil)
-	_, err = storeIterator(callID, iter, limit)
+	_, err = callFrame.storeIterator(iter, limit)
 	require.ErrorContains(t, err, "Reached iterator limit (2)")
+	var limitErr types.IteratorLimitError
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, limit, limitErr.Limit)
+
+	after := GetIteratorMetrics()
+	require.Equal(t, before.LimitErrors+1, after.LimitErrors)
+	require.Equal(t, before.OpenIterators, after.OpenIterators)
+	require.GreaterOrEqual(t, after.PeakIteratorsPerCall, uint64(limit))
 
-	endCall(callID)
+	endCall(callFrame)
+
+	final := GetIteratorMetrics()
+	require.Equal(t, after.OpenIterators-limit, final.OpenIterators)
+	require.Equal(t, after.OpenFrames-1, final.OpenFrames)
 }
 
 func TestRetrieveIterator(t *testing.T) {
 	const limit = 2000
-	callID1 := startCall()
-	callID2 := startCall()
+	frame1 := startCall()
+	frame2 := startCall()
 
 	store := testdb.NewMemDB()
 	var iter types.Iterator
 	var err error
 
 	iter, _ = store.Iterator(nil, nil)
-	iteratorID11, err := storeIterator(callID1, iter, limit)
+	iteratorID11, err := frame1.storeIterator(iter, limit)
 	require.NoError(t, err)
 	iter, _ = store.Iterator(nil, nil)
-	_, err = storeIterator(callID1, iter, limit)
+	_, err = frame1.storeIterator(iter, limit)
 	require.NoError(t, err)
 	iter, _ = store.Iterator(nil, nil)
-	_, err = storeIterator(callID2, iter, limit)
+	_, err = frame2.storeIterator(iter, limit)
 	require.NoError(t, err)
 	iter, _ = store.Iterator(nil, nil)
-	iteratorID22, err := storeIterator(callID2, iter, limit)
+	iteratorID22, err := frame2.storeIterator(iter, limit)
 	require.NoError(t, err)
 	iter, err = store.Iterator(nil, nil)
 	require.NoError(t, err)
-	iteratorID23, err := storeIterator(callID2, iter, limit)
+	iteratorID23, err := frame2.storeIterator(iter, limit)
 	require.NoError(t, err)
 
 	// Retrieve existing
-	iter = retrieveIterator(callID1, iteratorID11)
+	iter = frame1.retrieveIterator(iteratorID11)
 	require.NotNil(t, iter)
-	iter = retrieveIterator(callID2, iteratorID22)
+	iter = frame2.retrieveIterator(iteratorID22)
 	require.NotNil(t, iter)
 
 	// Retrieve with non-existent iterator ID
-	iter = retrieveIterator(callID1, iteratorID23)
+	iter = frame1.retrieveIterator(iteratorID23)
 	require.Nil(t, iter)
-	iter = retrieveIterator(callID1, uint64(0))
+	iter = frame1.retrieveIterator(uint64(0))
 	require.Nil(t, iter)
-	iter = retrieveIterator(callID1, uint64(2147483647))
+	iter = frame1.retrieveIterator(uint64(2147483647))
 	require.Nil(t, iter)
-	iter = retrieveIterator(callID1, uint64(2147483648))
+	iter = frame1.retrieveIterator(uint64(2147483648))
 	require.Nil(t, iter)
-	iter = retrieveIterator(callID1, uint64(18446744073709551615))
+	iter = frame1.retrieveIterator(uint64(18446744073709551615))
 	require.Nil(t, iter)
 
-	// Retrieve with non-existent call ID
-	iter = retrieveIterator(callID1+1_234_567, iteratorID23)
+	endCall(frame1)
+	endCall(frame2)
+
+	// Retrieve after the call ended
+	iter = frame1.retrieveIterator(iteratorID11)
 	require.Nil(t, iter)
+}
+
+func TestStartCallConcurrent(t *testing.T) {
+	const workers = 8
+	const callsPerWorker = 500
+
+	var wg sync.WaitGroup
+	ids := make(chan uint64, workers*callsPerWorker)
+	store := testdb.NewMemDB()
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			defer wg.Done()
+			for i := 0; i < callsPerWorker; i++ {
+				callFrame := startCall()
+				iter, _ := store.Iterator(nil, nil)
+				id, err := callFrame.storeIterator(iter, DefaultIteratorLimit)
+				assert.NoError(t, err)
+				assert.Equal(t, iter, callFrame.retrieveIterator(id))
+				ids <- callFrame.callID
+				endCall(callFrame)
+			}
+		}()
+	}
+	wg.Wait()
+	close(ids)
 
-	endCall(callID1)
-	endCall(callID2)
+	seen := make(map[uint64]bool)
+	for id := range ids {
+		require.False(t, seen[id], "duplicate call ID %d", id)
+		seen[id] = true
+	}
+	require.Len(t, seen, workers*callsPerWorker)
 }
 
 func TestQueueIteratorSimple(t *testing.T) {
@@ -186,7 +242,7 @@ func TestQueueIteratorSimple(t *testing.T) {
 	store := setup.Store(gasMeter)
 	query := []byte(`{"sum":{}}`)
 	env := MockEnvBin(t)
-	data, _, err := Query(cache, checksum, env, query, &igasMeter, store, api, &querier, TESTING_GAS_LIMIT, TESTING_PRINT_DEBUG)
+	data, _, err := Query(cache, checksum, env, query, &igasMeter, store, api, &querier, TESTING_GAS_LIMIT, TESTING_PRINT_DEBUG, nil)
 	require.NoError(t, err)
 	var qResult types.QueryResult
 	err = json.Unmarshal(data, &qResult)
@@ -196,7 +252,7 @@ func TestQueueIteratorSimple(t *testing.T) {
 
 	// query reduce (multiple iterators at once)
 	query = []byte(`{"reducer":{}}`)
-	data, _, err = Query(cache, checksum, env, query, &igasMeter, store, api, &querier, TESTING_GAS_LIMIT, TESTING_PRINT_DEBUG)
+	data, _, err = Query(cache, checksum, env, query, &igasMeter, store, api, &querier, TESTING_GAS_LIMIT, TESTING_PRINT_DEBUG, nil)
 	require.NoError(t, err)
 	var reduced types.QueryResult
 	err = json.Unmarshal(data, &reduced)
@@ -205,11 +261,86 @@ func TestQueueIteratorSimple(t *testing.T) {
 	require.Equal(t, `{"counters":[[17,22],[22,0]]}`, string(reduced.Ok))
 }
 
+func TestQueueIteratorBatched(t *testing.T) {
+	cache, cleanup := withCache(t)
+	defer cleanup()
+
+	setup := setupQueueContractWithData(t, cache, 1, 19, 6, 35, 8)
+	checksum, querier, api := setup.checksum, setup.querier, setup.api
+	env := MockEnvBin(t)
+
+	query := func(t *testing.T, cache Cache, msg string) ([]byte, types.GasReport, uint64) {
+		t.Helper()
+		gasMeter := NewMockGasMeter(TESTING_GAS_LIMIT)
+		igasMeter := types.GasMeter(gasMeter)
+		kv := setup.MeteredStore(gasMeter)
+		data, gasReport, err := Query(cache, checksum, env, []byte(msg), &igasMeter, kv, api, &querier, TESTING_GAS_LIMIT, TESTING_PRINT_DEBUG, nil)
+		require.NoError(t, err)
+		return data, gasReport, gasMeter.GasConsumed()
+	}
+
+	for _, batchSize := range []int{2, 3, 100} {
+		batched := cache
+		SetIteratorBatchSize(&batched, batchSize)
+		for _, msg := range []string{`{"sum":{}}`, `{"count":{}}`, `{"reducer":{}}`, `{"list":{}}`} {
+			expected, expectedGas, expectedConsumed := query(t, cache, msg)
+			data, gas, consumed := query(t, batched, msg)
+			assert.Equal(t, string(expected), string(data), "batch size %d, query %s", batchSize, msg)
+			assert.Equal(t, expectedGas, gas, "batch size %d, query %s", batchSize, msg)
+			assert.Equal(t, expectedConsumed, consumed, "batch size %d, query %s", batchSize, msg)
+		}
+	}
+}
+
+func TestQueueIteratorBatchedEarlyStop(t *testing.T) {
+	cache, cleanup := withCache(t)
+	defer cleanup()
+
+	batched := cache
+	SetIteratorBatchSize(&batched, 100)
+	env := MockEnvBin(t)
+	info := MockInfoBin(t, "creator")
+
+	// dequeue only reads the first record of an iterator over the whole queue
+	dequeue := func(t *testing.T, cache Cache, gasLimit uint64) ([]byte, types.GasReport, uint64, error) {
+		t.Helper()
+		setup := setupQueueContractWithData(t, cache, 1, 19, 6, 35, 8)
+		gasMeter := NewMockGasMeter(gasLimit)
+		igasMeter := types.GasMeter(gasMeter)
+		kv := setup.MeteredStore(gasMeter)
+		data, gasReport, err := Execute(cache, setup.checksum, env, info, []byte(`{"dequeue":{}}`), &igasMeter, kv, setup.api, &setup.querier, TESTING_GAS_LIMIT, TESTING_PRINT_DEBUG, nil)
+		return data, gasReport, gasMeter.GasConsumed(), err
+	}
+
+	_, _, needed, err := dequeue(t, cache, TESTING_GAS_LIMIT)
+	require.NoError(t, err)
+
+	// the gas meter only has enough gas for the records the contract reads
+	expected, expectedReport, expectedConsumed, err := dequeue(t, cache, needed)
+	require.NoError(t, err)
+	data, report, consumed, err := dequeue(t, batched, needed)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), string(data))
+	assert.Equal(t, expectedReport, report)
+	assert.Equal(t, expectedConsumed, consumed)
+}
+
+func TestAppendBatchRecord(t *testing.T) {
+	var buf []byte
+	buf = appendBatchRecord(buf, []byte("foo"), []byte("bar"))
+	buf = appendBatchRecord(buf, nil, nil)
+	expected := []byte{
+		0, 0, 0, 3, 'f', 'o', 'o', 0, 0, 0, 3, 'b', 'a', 'r',
+		0, 0, 0, 0, 0, 0, 0, 0,
+	}
+	require.Equal(t, expected, buf)
+}
+
 func TestQueueIteratorRaces(t *testing.T) {
 	cache, cleanup := withCache(t)
 	defer cleanup()
 
-	assert.Equal(t, 0, len(iteratorFrames))
+	assert.Equal(t, uint64(0), GetIteratorMetrics().OpenFrames)
 
 	contract1 := setupQueueContractWithData(t, cache, 17, 22)
 	contract2 := setupQueueContractWithData(t, cache, 1, 19, 6, 35, 8)
@@ -224,7 +355,7 @@ func TestQueueIteratorRaces(t *testing.T) {
 
 		// query reduce (multiple iterators at once)
 		query := []byte(`{"reducer":{}}`)
-		data, _, err := Query(cache, checksum, env, query, &igasMeter, store, api, &querier, TESTING_GAS_LIMIT, TESTING_PRINT_DEBUG)
+		data, _, err := Query(cache, checksum, env, query, &igasMeter, store, api, &querier, TESTING_GAS_LIMIT, TESTING_PRINT_DEBUG, nil)
 		require.NoError(t, err)
 		var reduced types.QueryResult
 		err = json.Unmarshal(data, &reduced)
@@ -255,8 +386,10 @@ func TestQueueIteratorRaces(t *testing.T) {
 	}
 	wg.Wait()
 
-	// when they finish, we should have removed all frames
-	assert.Equal(t, 0, len(iteratorFrames))
+	// when they finish, we should have closed all iterators
+	metrics := GetIteratorMetrics()
+	assert.Equal(t, uint64(0), metrics.OpenFrames)
+	assert.Equal(t, uint64(0), metrics.OpenIterators)
 }
 
 func TestQueueIteratorLimit(t *testing.T) {
@@ -277,7 +410,7 @@ func TestQueueIteratorLimit(t *testing.T) {
 	store := setup.Store(gasMeter)
 	query := []byte(`{"open_iterators":{"count":5000}}`)
 	env := MockEnvBin(t)
-	data, _, err := Query(cache, checksum, env, query, &igasMeter, store, api, &querier, gasLimit, TESTING_PRINT_DEBUG)
+	data, _, err := Query(cache, checksum, env, query, &igasMeter, store, api, &querier, gasLimit, TESTING_PRINT_DEBUG, nil)
 	require.NoError(t, err)
 	err = json.Unmarshal(data, &qResult)
 	require.NoError(t, err)
@@ -291,6 +424,56 @@ func TestQueueIteratorLimit(t *testing.T) {
 	store = setup.Store(gasMeter)
 	query = []byte(`{"open_iterators":{"count":35000}}`)
 	env = MockEnvBin(t)
-	_, _, err = Query(cache, checksum, env, query, &igasMeter, store, api, &querier, gasLimit, TESTING_PRINT_DEBUG)
+	_, _, err = Query(cache, checksum, env, query, &igasMeter, store, api, &querier, gasLimit, TESTING_PRINT_DEBUG, nil)
 	require.ErrorContains(t, err, "Reached iterator limit (32768)")
 }
+
+// BenchmarkStoreAndRetrieveIteratorParallel measures storing and retrieving iterators when many
+// contract calls use iterators at the same time.
+func BenchmarkStoreAndRetrieveIteratorParallel(b *testing.B) {
+	store := testdb.NewMemDB()
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			callFrame := startCall()
+			for i := 0; i < 10; i++ {
+				iter, _ := store.Iterator(nil, nil)
+				id, err := callFrame.storeIterator(iter, DefaultIteratorLimit)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for j := 0; j < 10; j++ {
+					if callFrame.retrieveIterator(id) == nil {
+						b.Fatal("iterator not found")
+					}
+				}
+			}
+			endCall(callFrame)
+		}
+	})
+}
+
+// BenchmarkQueueIteratorParallel runs iterator heavy queries in parallel.
+func BenchmarkQueueIteratorParallel(b *testing.B) {
+	cache, cleanup := withCache(b)
+	defer cleanup()
+
+	setup := setupQueueContractWithData(b, cache, 1, 19, 6, 35, 8)
+	checksum, querier, api := setup.checksum, setup.querier, setup.api
+	env := MockEnvBin(b)
+	query := []byte(`{"reducer":{}}`)
+
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			gasMeter := NewMockGasMeter(TESTING_GAS_LIMIT)
+			igasMeter := types.GasMeter(gasMeter)
+			store := setup.Store(gasMeter)
+			_, _, err := Query(cache, checksum, env, query, &igasMeter, store, api, &querier, TESTING_GAS_LIMIT, TESTING_PRINT_DEBUG, nil)
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
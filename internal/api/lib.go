@@ -34,9 +34,13 @@ type (
 	cu8_ptr = *C.uint8_t
 )
 
+// Cache is a handle to a cache in libwasmvm. It is passed by value to all calls. Copies share
+// the cache and its lockfile, so ReleaseCache must be called on only one of them.
 type Cache struct {
 	ptr      *C.cache_t
-	lockfile os.File
+	lockfile *os.File
+	// iterators configures the iterators of contract calls using this cache
+	iterators iteratorOptions
 }
 
 type Querier = types.Querier
@@ -76,7 +80,20 @@ func InitCache(dataDir string, supportedCapabilities []string, cacheSize uint32,
 	if err != nil {
 		return Cache{}, errorWithMessage(err, errmsg)
 	}
-	return Cache{ptr: ptr, lockfile: *lockfile}, nil
+	return Cache{ptr: ptr, lockfile: lockfile}, nil
+}
+
+// SetIteratorLimit sets the maximum number of iterators per contract call using this cache.
+// A limit of 0 means DefaultIteratorLimit.
+func SetIteratorLimit(cache *Cache, limit int) {
+	cache.iterators.limit = limit
+}
+
+// SetIteratorBatchSize sets the maximum number of records an iterator fetches per callback
+// for contract calls using this cache. A size of 0 or 1 disables batching, which is the default.
+// It only applies to stores implementing types.MeteredKVStore (see batchIterator).
+func SetIteratorBatchSize(cache *Cache, size int) {
+	cache.iterators.batchSize = size
 }
 
 func ReleaseCache(cache Cache) {
@@ -217,6 +234,7 @@ func Instantiate(
 	querier *Querier,
 	gasLimit uint64,
 	printDebug bool,
+	decode ResultDecoder,
 ) ([]byte, types.GasReport, error) {
 	cs := makeView(checksum)
 	defer runtime.KeepAlive(checksum)
@@ -232,10 +250,10 @@ func Instantiate(
 	checkAndPinQuerier(querier, pinner)
 	defer pinner.Unpin()
 
-	callID := startCall()
-	defer endCall(callID)
+	callFrame := startCall()
+	defer endCall(callFrame)
 
-	dbState := buildDBState(store, callID)
+	dbState := buildDBState(store, callFrame, cache.iterators)
 	db := buildDB(&dbState, gasMeter)
 	a := buildAPI(api)
 	q := buildQuerier(querier)
@@ -245,9 +263,9 @@ func Instantiate(
 	res, err := C.instantiate(cache.ptr, cs, e, i, m, db, a, q, cu64(gasLimit), cbool(printDebug), &gasReport, &errmsg)
 	if err != nil && err.(syscall.Errno) != C.ErrnoValue_Success {
 		// Depending on the nature of the error, `gasUsed` will either have a meaningful value, or just 0.
-		return nil, convertGasReport(gasReport), errorWithMessage(err, errmsg)
+		return nil, convertGasReport(gasReport), callError(err, errmsg, &dbState)
 	}
-	return copyAndDestroyUnmanagedVector(res), convertGasReport(gasReport), nil
+	return handleResult(res, convertGasReport(gasReport), decode)
 }
 
 func Execute(
@@ -262,6 +280,7 @@ func Execute(
 	querier *Querier,
 	gasLimit uint64,
 	printDebug bool,
+	decode ResultDecoder,
 ) ([]byte, types.GasReport, error) {
 	cs := makeView(checksum)
 	defer runtime.KeepAlive(checksum)
@@ -277,10 +296,10 @@ func Execute(
 	checkAndPinQuerier(querier, pinner)
 	defer pinner.Unpin()
 
-	callID := startCall()
-	defer endCall(callID)
+	callFrame := startCall()
+	defer endCall(callFrame)
 
-	dbState := buildDBState(store, callID)
+	dbState := buildDBState(store, callFrame, cache.iterators)
 	db := buildDB(&dbState, gasMeter)
 	a := buildAPI(api)
 	q := buildQuerier(querier)
@@ -290,9 +309,9 @@ func Execute(
 	res, err := C.execute(cache.ptr, cs, e, i, m, db, a, q, cu64(gasLimit), cbool(printDebug), &gasReport, &errmsg)
 	if err != nil && err.(syscall.Errno) != C.ErrnoValue_Success {
 		// Depending on the nature of the error, `gasUsed` will either have a meaningful value, or just 0.
-		return nil, convertGasReport(gasReport), errorWithMessage(err, errmsg)
+		return nil, convertGasReport(gasReport), callError(err, errmsg, &dbState)
 	}
-	return copyAndDestroyUnmanagedVector(res), convertGasReport(gasReport), nil
+	return handleResult(res, convertGasReport(gasReport), decode)
 }
 
 func Migrate(
@@ -306,6 +325,7 @@ func Migrate(
 	querier *Querier,
 	gasLimit uint64,
 	printDebug bool,
+	decode ResultDecoder,
 ) ([]byte, types.GasReport, error) {
 	cs := makeView(checksum)
 	defer runtime.KeepAlive(checksum)
@@ -319,10 +339,10 @@ func Migrate(
 	checkAndPinQuerier(querier, pinner)
 	defer pinner.Unpin()
 
-	callID := startCall()
-	defer endCall(callID)
+	callFrame := startCall()
+	defer endCall(callFrame)
 
-	dbState := buildDBState(store, callID)
+	dbState := buildDBState(store, callFrame, cache.iterators)
 	db := buildDB(&dbState, gasMeter)
 	a := buildAPI(api)
 	q := buildQuerier(querier)
@@ -332,9 +352,9 @@ func Migrate(
 	res, err := C.migrate(cache.ptr, cs, e, m, db, a, q, cu64(gasLimit), cbool(printDebug), &gasReport, &errmsg)
 	if err != nil && err.(syscall.Errno) != C.ErrnoValue_Success {
 		// Depending on the nature of the error, `gasUsed` will either have a meaningful value, or just 0.
-		return nil, convertGasReport(gasReport), errorWithMessage(err, errmsg)
+		return nil, convertGasReport(gasReport), callError(err, errmsg, &dbState)
 	}
-	return copyAndDestroyUnmanagedVector(res), convertGasReport(gasReport), nil
+	return handleResult(res, convertGasReport(gasReport), decode)
 }
 
 func Sudo(
@@ -348,6 +368,7 @@ func Sudo(
 	querier *Querier,
 	gasLimit uint64,
 	printDebug bool,
+	decode ResultDecoder,
 ) ([]byte, types.GasReport, error) {
 	cs := makeView(checksum)
 	defer runtime.KeepAlive(checksum)
@@ -361,10 +382,10 @@ func Sudo(
 	checkAndPinQuerier(querier, pinner)
 	defer pinner.Unpin()
 
-	callID := startCall()
-	defer endCall(callID)
+	callFrame := startCall()
+	defer endCall(callFrame)
 
-	dbState := buildDBState(store, callID)
+	dbState := buildDBState(store, callFrame, cache.iterators)
 	db := buildDB(&dbState, gasMeter)
 	a := buildAPI(api)
 	q := buildQuerier(querier)
@@ -374,9 +395,9 @@ func Sudo(
 	res, err := C.sudo(cache.ptr, cs, e, m, db, a, q, cu64(gasLimit), cbool(printDebug), &gasReport, &errmsg)
 	if err != nil && err.(syscall.Errno) != C.ErrnoValue_Success {
 		// Depending on the nature of the error, `gasUsed` will either have a meaningful value, or just 0.
-		return nil, convertGasReport(gasReport), errorWithMessage(err, errmsg)
+		return nil, convertGasReport(gasReport), callError(err, errmsg, &dbState)
 	}
-	return copyAndDestroyUnmanagedVector(res), convertGasReport(gasReport), nil
+	return handleResult(res, convertGasReport(gasReport), decode)
 }
 
 func Reply(
@@ -390,6 +411,7 @@ func Reply(
 	querier *Querier,
 	gasLimit uint64,
 	printDebug bool,
+	decode ResultDecoder,
 ) ([]byte, types.GasReport, error) {
 	cs := makeView(checksum)
 	defer runtime.KeepAlive(checksum)
@@ -403,10 +425,10 @@ func Reply(
 	checkAndPinQuerier(querier, pinner)
 	defer pinner.Unpin()
 
-	callID := startCall()
-	defer endCall(callID)
+	callFrame := startCall()
+	defer endCall(callFrame)
 
-	dbState := buildDBState(store, callID)
+	dbState := buildDBState(store, callFrame, cache.iterators)
 	db := buildDB(&dbState, gasMeter)
 	a := buildAPI(api)
 	q := buildQuerier(querier)
@@ -416,9 +438,9 @@ func Reply(
 	res, err := C.reply(cache.ptr, cs, e, r, db, a, q, cu64(gasLimit), cbool(printDebug), &gasReport, &errmsg)
 	if err != nil && err.(syscall.Errno) != C.ErrnoValue_Success {
 		// Depending on the nature of the error, `gasUsed` will either have a meaningful value, or just 0.
-		return nil, convertGasReport(gasReport), errorWithMessage(err, errmsg)
+		return nil, convertGasReport(gasReport), callError(err, errmsg, &dbState)
 	}
-	return copyAndDestroyUnmanagedVector(res), convertGasReport(gasReport), nil
+	return handleResult(res, convertGasReport(gasReport), decode)
 }
 
 func Query(
@@ -432,6 +454,7 @@ func Query(
 	querier *Querier,
 	gasLimit uint64,
 	printDebug bool,
+	decode ResultDecoder,
 ) ([]byte, types.GasReport, error) {
 	cs := makeView(checksum)
 	defer runtime.KeepAlive(checksum)
@@ -445,10 +468,10 @@ func Query(
 	checkAndPinQuerier(querier, pinner)
 	defer pinner.Unpin()
 
-	callID := startCall()
-	defer endCall(callID)
+	callFrame := startCall()
+	defer endCall(callFrame)
 
-	dbState := buildDBState(store, callID)
+	dbState := buildDBState(store, callFrame, cache.iterators)
 	db := buildDB(&dbState, gasMeter)
 	a := buildAPI(api)
 	q := buildQuerier(querier)
@@ -458,9 +481,9 @@ func Query(
 	res, err := C.query(cache.ptr, cs, e, m, db, a, q, cu64(gasLimit), cbool(printDebug), &gasReport, &errmsg)
 	if err != nil && err.(syscall.Errno) != C.ErrnoValue_Success {
 		// Depending on the nature of the error, `gasUsed` will either have a meaningful value, or just 0.
-		return nil, convertGasReport(gasReport), errorWithMessage(err, errmsg)
+		return nil, convertGasReport(gasReport), callError(err, errmsg, &dbState)
 	}
-	return copyAndDestroyUnmanagedVector(res), convertGasReport(gasReport), nil
+	return handleResult(res, convertGasReport(gasReport), decode)
 }
 
 func IBCChannelOpen(
@@ -474,6 +497,7 @@ func IBCChannelOpen(
 	querier *Querier,
 	gasLimit uint64,
 	printDebug bool,
+	decode ResultDecoder,
 ) ([]byte, types.GasReport, error) {
 	cs := makeView(checksum)
 	defer runtime.KeepAlive(checksum)
@@ -487,10 +511,10 @@ func IBCChannelOpen(
 	checkAndPinQuerier(querier, pinner)
 	defer pinner.Unpin()
 
-	callID := startCall()
-	defer endCall(callID)
+	callFrame := startCall()
+	defer endCall(callFrame)
 
-	dbState := buildDBState(store, callID)
+	dbState := buildDBState(store, callFrame, cache.iterators)
 	db := buildDB(&dbState, gasMeter)
 	a := buildAPI(api)
 	q := buildQuerier(querier)
@@ -500,9 +524,9 @@ func IBCChannelOpen(
 	res, err := C.ibc_channel_open(cache.ptr, cs, e, m, db, a, q, cu64(gasLimit), cbool(printDebug), &gasReport, &errmsg)
 	if err != nil && err.(syscall.Errno) != C.ErrnoValue_Success {
 		// Depending on the nature of the error, `gasUsed` will either have a meaningful value, or just 0.
-		return nil, convertGasReport(gasReport), errorWithMessage(err, errmsg)
+		return nil, convertGasReport(gasReport), callError(err, errmsg, &dbState)
 	}
-	return copyAndDestroyUnmanagedVector(res), convertGasReport(gasReport), nil
+	return handleResult(res, convertGasReport(gasReport), decode)
 }
 
 func IBCChannelConnect(
@@ -516,6 +540,7 @@ func IBCChannelConnect(
 	querier *Querier,
 	gasLimit uint64,
 	printDebug bool,
+	decode ResultDecoder,
 ) ([]byte, types.GasReport, error) {
 	cs := makeView(checksum)
 	defer runtime.KeepAlive(checksum)
@@ -529,10 +554,10 @@ func IBCChannelConnect(
 	checkAndPinQuerier(querier, pinner)
 	defer pinner.Unpin()
 
-	callID := startCall()
-	defer endCall(callID)
+	callFrame := startCall()
+	defer endCall(callFrame)
 
-	dbState := buildDBState(store, callID)
+	dbState := buildDBState(store, callFrame, cache.iterators)
 	db := buildDB(&dbState, gasMeter)
 	a := buildAPI(api)
 	q := buildQuerier(querier)
@@ -542,9 +567,9 @@ func IBCChannelConnect(
 	res, err := C.ibc_channel_connect(cache.ptr, cs, e, m, db, a, q, cu64(gasLimit), cbool(printDebug), &gasReport, &errmsg)
 	if err != nil && err.(syscall.Errno) != C.ErrnoValue_Success {
 		// Depending on the nature of the error, `gasUsed` will either have a meaningful value, or just 0.
-		return nil, convertGasReport(gasReport), errorWithMessage(err, errmsg)
+		return nil, convertGasReport(gasReport), callError(err, errmsg, &dbState)
 	}
-	return copyAndDestroyUnmanagedVector(res), convertGasReport(gasReport), nil
+	return handleResult(res, convertGasReport(gasReport), decode)
 }
 
 func IBCChannelClose(
@@ -558,6 +583,7 @@ func IBCChannelClose(
 	querier *Querier,
 	gasLimit uint64,
 	printDebug bool,
+	decode ResultDecoder,
 ) ([]byte, types.GasReport, error) {
 	cs := makeView(checksum)
 	defer runtime.KeepAlive(checksum)
@@ -571,10 +597,10 @@ func IBCChannelClose(
 	checkAndPinQuerier(querier, pinner)
 	defer pinner.Unpin()
 
-	callID := startCall()
-	defer endCall(callID)
+	callFrame := startCall()
+	defer endCall(callFrame)
 
-	dbState := buildDBState(store, callID)
+	dbState := buildDBState(store, callFrame, cache.iterators)
 	db := buildDB(&dbState, gasMeter)
 	a := buildAPI(api)
 	q := buildQuerier(querier)
@@ -584,9 +610,9 @@ func IBCChannelClose(
 	res, err := C.ibc_channel_close(cache.ptr, cs, e, m, db, a, q, cu64(gasLimit), cbool(printDebug), &gasReport, &errmsg)
 	if err != nil && err.(syscall.Errno) != C.ErrnoValue_Success {
 		// Depending on the nature of the error, `gasUsed` will either have a meaningful value, or just 0.
-		return nil, convertGasReport(gasReport), errorWithMessage(err, errmsg)
+		return nil, convertGasReport(gasReport), callError(err, errmsg, &dbState)
 	}
-	return copyAndDestroyUnmanagedVector(res), convertGasReport(gasReport), nil
+	return handleResult(res, convertGasReport(gasReport), decode)
 }
 
 func IBCPacketReceive(
@@ -600,6 +626,7 @@ func IBCPacketReceive(
 	querier *Querier,
 	gasLimit uint64,
 	printDebug bool,
+	decode ResultDecoder,
 ) ([]byte, types.GasReport, error) {
 	cs := makeView(checksum)
 	defer runtime.KeepAlive(checksum)
@@ -613,10 +640,10 @@ func IBCPacketReceive(
 	checkAndPinQuerier(querier, pinner)
 	defer pinner.Unpin()
 
-	callID := startCall()
-	defer endCall(callID)
+	callFrame := startCall()
+	defer endCall(callFrame)
 
-	dbState := buildDBState(store, callID)
+	dbState := buildDBState(store, callFrame, cache.iterators)
 	db := buildDB(&dbState, gasMeter)
 	a := buildAPI(api)
 	q := buildQuerier(querier)
@@ -626,9 +653,9 @@ func IBCPacketReceive(
 	res, err := C.ibc_packet_receive(cache.ptr, cs, e, pa, db, a, q, cu64(gasLimit), cbool(printDebug), &gasReport, &errmsg)
 	if err != nil && err.(syscall.Errno) != C.ErrnoValue_Success {
 		// Depending on the nature of the error, `gasUsed` will either have a meaningful value, or just 0.
-		return nil, convertGasReport(gasReport), errorWithMessage(err, errmsg)
+		return nil, convertGasReport(gasReport), callError(err, errmsg, &dbState)
 	}
-	return copyAndDestroyUnmanagedVector(res), convertGasReport(gasReport), nil
+	return handleResult(res, convertGasReport(gasReport), decode)
 }
 
 func IBCPacketAck(
@@ -642,6 +669,7 @@ func IBCPacketAck(
 	querier *Querier,
 	gasLimit uint64,
 	printDebug bool,
+	decode ResultDecoder,
 ) ([]byte, types.GasReport, error) {
 	cs := makeView(checksum)
 	defer runtime.KeepAlive(checksum)
@@ -655,10 +683,10 @@ func IBCPacketAck(
 	checkAndPinQuerier(querier, pinner)
 	defer pinner.Unpin()
 
-	callID := startCall()
-	defer endCall(callID)
+	callFrame := startCall()
+	defer endCall(callFrame)
 
-	dbState := buildDBState(store, callID)
+	dbState := buildDBState(store, callFrame, cache.iterators)
 	db := buildDB(&dbState, gasMeter)
 	a := buildAPI(api)
 	q := buildQuerier(querier)
@@ -668,9 +696,9 @@ func IBCPacketAck(
 	res, err := C.ibc_packet_ack(cache.ptr, cs, e, ac, db, a, q, cu64(gasLimit), cbool(printDebug), &gasReport, &errmsg)
 	if err != nil && err.(syscall.Errno) != C.ErrnoValue_Success {
 		// Depending on the nature of the error, `gasUsed` will either have a meaningful value, or just 0.
-		return nil, convertGasReport(gasReport), errorWithMessage(err, errmsg)
+		return nil, convertGasReport(gasReport), callError(err, errmsg, &dbState)
 	}
-	return copyAndDestroyUnmanagedVector(res), convertGasReport(gasReport), nil
+	return handleResult(res, convertGasReport(gasReport), decode)
 }
 
 func IBCPacketTimeout(
@@ -684,6 +712,7 @@ func IBCPacketTimeout(
 	querier *Querier,
 	gasLimit uint64,
 	printDebug bool,
+	decode ResultDecoder,
 ) ([]byte, types.GasReport, error) {
 	cs := makeView(checksum)
 	defer runtime.KeepAlive(checksum)
@@ -697,10 +726,10 @@ func IBCPacketTimeout(
 	checkAndPinQuerier(querier, pinner)
 	defer pinner.Unpin()
 
-	callID := startCall()
-	defer endCall(callID)
+	callFrame := startCall()
+	defer endCall(callFrame)
 
-	dbState := buildDBState(store, callID)
+	dbState := buildDBState(store, callFrame, cache.iterators)
 	db := buildDB(&dbState, gasMeter)
 	a := buildAPI(api)
 	q := buildQuerier(querier)
@@ -710,9 +739,9 @@ func IBCPacketTimeout(
 	res, err := C.ibc_packet_timeout(cache.ptr, cs, e, pa, db, a, q, cu64(gasLimit), cbool(printDebug), &gasReport, &errmsg)
 	if err != nil && err.(syscall.Errno) != C.ErrnoValue_Success {
 		// Depending on the nature of the error, `gasUsed` will either have a meaningful value, or just 0.
-		return nil, convertGasReport(gasReport), errorWithMessage(err, errmsg)
+		return nil, convertGasReport(gasReport), callError(err, errmsg, &dbState)
 	}
-	return copyAndDestroyUnmanagedVector(res), convertGasReport(gasReport), nil
+	return handleResult(res, convertGasReport(gasReport), decode)
 }
 
 func IBCSourceCallback(
@@ -726,6 +755,7 @@ func IBCSourceCallback(
 	querier *Querier,
 	gasLimit uint64,
 	printDebug bool,
+	decode ResultDecoder,
 ) ([]byte, types.GasReport, error) {
 	cs := makeView(checksum)
 	defer runtime.KeepAlive(checksum)
@@ -739,10 +769,10 @@ func IBCSourceCallback(
 	checkAndPinQuerier(querier, pinner)
 	defer pinner.Unpin()
 
-	callID := startCall()
-	defer endCall(callID)
+	callFrame := startCall()
+	defer endCall(callFrame)
 
-	dbState := buildDBState(store, callID)
+	dbState := buildDBState(store, callFrame, cache.iterators)
 	db := buildDB(&dbState, gasMeter)
 	a := buildAPI(api)
 	q := buildQuerier(querier)
@@ -752,9 +782,9 @@ func IBCSourceCallback(
 	res, err := C.ibc_source_callback(cache.ptr, cs, e, msgBytes, db, a, q, cu64(gasLimit), cbool(printDebug), &gasReport, &errmsg)
 	if err != nil && err.(syscall.Errno) != C.ErrnoValue_Success {
 		// Depending on the nature of the error, `gasUsed` will either have a meaningful value, or just 0.
-		return nil, convertGasReport(gasReport), errorWithMessage(err, errmsg)
+		return nil, convertGasReport(gasReport), callError(err, errmsg, &dbState)
 	}
-	return copyAndDestroyUnmanagedVector(res), convertGasReport(gasReport), nil
+	return handleResult(res, convertGasReport(gasReport), decode)
 }
 
 func IBCDestinationCallback(
@@ -768,6 +798,7 @@ func IBCDestinationCallback(
 	querier *Querier,
 	gasLimit uint64,
 	printDebug bool,
+	decode ResultDecoder,
 ) ([]byte, types.GasReport, error) {
 	cs := makeView(checksum)
 	defer runtime.KeepAlive(checksum)
@@ -781,10 +812,10 @@ func IBCDestinationCallback(
 	checkAndPinQuerier(querier, pinner)
 	defer pinner.Unpin()
 
-	callID := startCall()
-	defer endCall(callID)
+	callFrame := startCall()
+	defer endCall(callFrame)
 
-	dbState := buildDBState(store, callID)
+	dbState := buildDBState(store, callFrame, cache.iterators)
 	db := buildDB(&dbState, gasMeter)
 	a := buildAPI(api)
 	q := buildQuerier(querier)
@@ -794,9 +825,27 @@ func IBCDestinationCallback(
 	res, err := C.ibc_destination_callback(cache.ptr, cs, e, msgBytes, db, a, q, cu64(gasLimit), cbool(printDebug), &gasReport, &errmsg)
 	if err != nil && err.(syscall.Errno) != C.ErrnoValue_Success {
 		// Depending on the nature of the error, `gasUsed` will either have a meaningful value, or just 0.
-		return nil, convertGasReport(gasReport), errorWithMessage(err, errmsg)
+		return nil, convertGasReport(gasReport), callError(err, errmsg, &dbState)
 	}
-	return copyAndDestroyUnmanagedVector(res), convertGasReport(gasReport), nil
+	return handleResult(res, convertGasReport(gasReport), decode)
+}
+
+// ResultDecoder decodes the result of a contract call directly from the memory owned by Rust,
+// which avoids copying the result. The data is only valid until the decoder returns and must
+// not be retained. gasReport can be updated by the decoder, e.g. to charge gas for decoding.
+type ResultDecoder func(data []byte, gasReport *types.GasReport) error
+
+// handleResult frees the result of a successful contract call. If decode is nil, a copy of the
+// result is returned. Otherwise the result is passed to decode and nil is returned along with
+// the error of decode.
+func handleResult(res C.UnmanagedVector, gasReport types.GasReport, decode ResultDecoder) ([]byte, types.GasReport, error) {
+	if decode == nil {
+		return copyAndDestroyUnmanagedVector(res), gasReport, nil
+	}
+	err := decodeAndDestroyUnmanagedVector(res, func(data []byte) error {
+		return decode(data, &gasReport)
+	})
+	return nil, gasReport, err
 }
 
 func convertGasReport(report C.GasReport) types.GasReport {
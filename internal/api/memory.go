@@ -5,7 +5,10 @@ package api
 */
 import "C"
 
-import "unsafe"
+import (
+	"sync"
+	"unsafe"
+)
 
 // makeView creates a view into the given byte slice what allows Rust code to read it.
 // The byte slice is managed by Go and will be garbage collected. Use runtime.KeepAlive
@@ -75,6 +78,23 @@ func copyAndDestroyUnmanagedVector(v C.UnmanagedVector) []byte {
 	return out
 }
 
+// decodeAndDestroyUnmanagedVector passes the contents of v to decode without copying them
+// and destroys v afterwards. The data passed to decode is owned by Rust and must not be
+// retained after decode returns.
+func decodeAndDestroyUnmanagedVector(v C.UnmanagedVector, decode func(data []byte) error) error {
+	defer C.destroy_unmanaged_vector(v)
+	var data []byte
+	if v.is_none {
+		data = nil
+	} else if v.cap == cusize(0) {
+		// There is no allocation we can look into
+		data = []byte{}
+	} else {
+		data = unsafe.Slice((*byte)(unsafe.Pointer(v.ptr)), int(v.len))
+	}
+	return decode(data)
+}
+
 func optionalU64ToPtr(val C.OptionalU64) *uint64 {
 	if val.is_some {
 		return (*uint64)(&val.value)
@@ -82,6 +102,48 @@ func optionalU64ToPtr(val C.OptionalU64) *uint64 {
 	return nil
 }
 
+// viewU8Slice returns the contents of an Option<&[u8]> that was allocated on the Rust side without copying them.
+// Returns nil if and only if the source is None.
+//
+// The result points into memory owned by Rust and is only valid during the callback that received the view.
+// It must not be retained or passed to code that might retain it. Use copyU8Slice for that.
+func viewU8Slice(view C.U8SliceView) []byte {
+	if view.is_none {
+		return nil
+	}
+	if view.len == 0 {
+		// In this case, we don't want to look into the ptr
+		return []byte{}
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(view.ptr)), int(view.len))
+}
+
+// maxPooledBufferSize is the capacity up to which buffers are returned to bufferPool.
+// Larger buffers are left to the garbage collector to avoid holding on to a lot of memory.
+const maxPooledBufferSize = 64 * 1024
+
+// bufferPool holds buffers for data that is encoded in a callback and then copied to Rust by newUnmanagedVector
+var bufferPool = sync.Pool{
+	New: func() any {
+		buf := make([]byte, 0, 1024)
+		return &buf
+	},
+}
+
+// getBuffer returns an empty buffer from bufferPool. Return it with putBuffer once the data is copied.
+func getBuffer() *[]byte {
+	return bufferPool.Get().(*[]byte)
+}
+
+// putBuffer returns a buffer to bufferPool. The buffer must not be used afterwards.
+func putBuffer(buf *[]byte) {
+	if cap(*buf) > maxPooledBufferSize {
+		return
+	}
+	*buf = (*buf)[:0]
+	bufferPool.Put(buf)
+}
+
 // copyU8Slice copies the contents of an Option<&[u8]> that was allocated on the Rust side.
 // Returns nil if and only if the source is None.
 func copyU8Slice(view C.U8SliceView) []byte {
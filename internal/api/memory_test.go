@@ -1,10 +1,14 @@
 package api
 
 import (
+	"encoding/json"
+	"fmt"
 	"testing"
 	"unsafe"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
 )
 
 func TestMakeView(t *testing.T) {
@@ -76,3 +80,135 @@ func TestCopyDestroyUnmanagedVector(t *testing.T) {
 		require.Equal(t, []byte{}, copy)
 	}
 }
+
+func TestDecodeAndDestroyUnmanagedVector(t *testing.T) {
+	specs := map[string]struct {
+		original []byte
+	}{
+		"non-empty": {original: []byte{0xaa, 0xbb, 0x64}},
+		"empty":     {original: []byte{}},
+		"none":      {original: nil},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			unmanaged := newUnmanagedVector(spec.original)
+			var decoded []byte
+			err := decodeAndDestroyUnmanagedVector(unmanaged, func(data []byte) error {
+				if data != nil {
+					decoded = append([]byte{}, data...)
+				}
+				return nil
+			})
+			require.NoError(t, err)
+			require.Equal(t, spec.original, decoded)
+		})
+	}
+
+	// errors of the decoder are returned
+	myErr := fmt.Errorf("cannot decode")
+	err := decodeAndDestroyUnmanagedVector(newUnmanagedVector([]byte{1}), func([]byte) error { return myErr })
+	require.Equal(t, myErr, err)
+}
+
+func TestBufferPool(t *testing.T) {
+	buf := getBuffer()
+	require.Empty(t, *buf)
+	*buf = append(*buf, "some data"...)
+	putBuffer(buf)
+
+	buf = getBuffer()
+	require.Empty(t, *buf, "buffers are reset when returned to the pool")
+	putBuffer(buf)
+
+	// large buffers are not pooled, but returning them is fine
+	large := make([]byte, maxPooledBufferSize+1)
+	putBuffer(&large)
+	require.Len(t, large, maxPooledBufferSize+1)
+}
+
+// benchmarkResult returns a JSON encoded contract result with the given number of attributes
+func benchmarkResult(b *testing.B, attributes int) []byte {
+	b.Helper()
+	var res types.ContractResult
+	res.Ok = &types.Response{}
+	for i := 0; i < attributes; i++ {
+		res.Ok.Attributes = append(res.Ok.Attributes, types.EventAttribute{Key: fmt.Sprintf("key%d", i), Value: fmt.Sprintf("value%d", i)})
+	}
+	data, err := json.Marshal(res)
+	require.NoError(b, err)
+	return data
+}
+
+// BenchmarkCopyAndDestroyUnmanagedVector decodes a result after copying it to Go memory.
+func BenchmarkCopyAndDestroyUnmanagedVector(b *testing.B) {
+	data := benchmarkResult(b, 100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		unmanaged := newUnmanagedVector(data)
+		b.StartTimer()
+
+		var res types.ContractResult
+		if err := json.Unmarshal(copyAndDestroyUnmanagedVector(unmanaged), &res); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkDecodeAndDestroyUnmanagedVector decodes a result directly from Rust memory.
+func BenchmarkDecodeAndDestroyUnmanagedVector(b *testing.B) {
+	data := benchmarkResult(b, 100)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		b.StopTimer()
+		unmanaged := newUnmanagedVector(data)
+		b.StartTimer()
+
+		var res types.ContractResult
+		err := decodeAndDestroyUnmanagedVector(unmanaged, func(data []byte) error {
+			return json.Unmarshal(data, &res)
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkAppendBatchRecords encodes the records of an iterator batch into a fresh buffer.
+func BenchmarkAppendBatchRecords(b *testing.B) {
+	values := make([][]byte, 50)
+	for i := range values {
+		values[i] = []byte(fmt.Sprintf("value %d", i))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		var buf []byte
+		for _, v := range values {
+			buf = appendBatchRecord(buf, v, v)
+		}
+		unmanaged := newUnmanagedVector(buf)
+		copyAndDestroyUnmanagedVector(unmanaged)
+	}
+}
+
+// BenchmarkAppendBatchRecordsPooled encodes the records of an iterator batch into a pooled buffer.
+func BenchmarkAppendBatchRecordsPooled(b *testing.B) {
+	values := make([][]byte, 50)
+	for i := range values {
+		values[i] = []byte(fmt.Sprintf("value %d", i))
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		buf := getBuffer()
+		for _, v := range values {
+			*buf = appendBatchRecord(*buf, v, v)
+		}
+		unmanaged := newUnmanagedVector(*buf)
+		putBuffer(buf)
+		copyAndDestroyUnmanagedVector(unmanaged)
+	}
+}
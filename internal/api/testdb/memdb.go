@@ -161,21 +161,17 @@ func (db *MemDB) Stats() map[string]string {
 }
 
 // Iterator implements DB.
-// Takes out a read-lock on the database until the iterator is closed.
+// The iterator runs over a snapshot of the database, so the database can be
+// modified while iterating without affecting the iterator.
 func (db *MemDB) Iterator(start, end []byte) (Iterator, error) {
-	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
-		return nil, errKeyEmpty
-	}
-	return newMemDBIterator(db, start, end, false), nil
+	return db.Snapshot().Iterator(start, end)
 }
 
 // ReverseIterator implements DB.
-// Takes out a read-lock on the database until the iterator is closed.
+// The iterator runs over a snapshot of the database, so the database can be
+// modified while iterating without affecting the iterator.
 func (db *MemDB) ReverseIterator(start, end []byte) (Iterator, error) {
-	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
-		return nil, errKeyEmpty
-	}
-	return newMemDBIterator(db, start, end, true), nil
+	return db.Snapshot().ReverseIterator(start, end)
 }
 
 // IteratorNoMtx makes an iterator with no mutex.
@@ -183,7 +179,7 @@ func (db *MemDB) IteratorNoMtx(start, end []byte) (Iterator, error) {
 	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
 		return nil, errKeyEmpty
 	}
-	return newMemDBIteratorMtxChoice(db, start, end, false, false), nil
+	return newMemDBIterator(db.btree, start, end, false), nil
 }
 
 // ReverseIteratorNoMtx makes an iterator with no mutex.
@@ -191,5 +187,5 @@ func (db *MemDB) ReverseIteratorNoMtx(start, end []byte) (Iterator, error) {
 	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
 		return nil, errKeyEmpty
 	}
-	return newMemDBIteratorMtxChoice(db, start, end, true, false), nil
+	return newMemDBIterator(db.btree, start, end, true), nil
 }
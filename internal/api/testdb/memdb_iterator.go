@@ -21,17 +21,13 @@ type memDBIterator struct {
 	item   *item
 	start  []byte
 	end    []byte
-	useMtx bool
 }
 
 var _ Iterator = (*memDBIterator)(nil)
 
-// newMemDBIterator creates a new memDBIterator.
-func newMemDBIterator(db *MemDB, start []byte, end []byte, reverse bool) *memDBIterator {
-	return newMemDBIteratorMtxChoice(db, start, end, reverse, true)
-}
-
-func newMemDBIteratorMtxChoice(db *MemDB, start []byte, end []byte, reverse bool, useMtx bool) *memDBIterator {
+// newMemDBIterator creates a new memDBIterator over the given tree.
+// The tree must not be modified until the iterator is closed.
+func newMemDBIterator(tree *btree.BTree, start []byte, end []byte, reverse bool) *memDBIterator {
 	ctx, cancel := context.WithCancel(context.Background())
 	ch := make(chan *item, chBufferSize)
 	iter := &memDBIterator{
@@ -39,16 +35,9 @@ func newMemDBIteratorMtxChoice(db *MemDB, start []byte, end []byte, reverse bool
 		cancel: cancel,
 		start:  start,
 		end:    end,
-		useMtx: useMtx,
 	}
 
-	if useMtx {
-		db.mtx.RLock()
-	}
 	go func() {
-		if useMtx {
-			defer db.mtx.RUnlock()
-		}
 		// Because we use [start, end) for reverse ranges, while btree uses (start, end], we need
 		// the following variables to handle some reverse iteration conditions ourselves.
 		var (
@@ -73,23 +62,23 @@ func newMemDBIteratorMtxChoice(db *MemDB, start []byte, end []byte, reverse bool
 		}
 		switch {
 		case start == nil && end == nil && !reverse:
-			db.btree.Ascend(visitor)
+			tree.Ascend(visitor)
 		case start == nil && end == nil && reverse:
-			db.btree.Descend(visitor)
+			tree.Descend(visitor)
 		case end == nil && !reverse:
 			// must handle this specially, since nil is considered less than anything else
-			db.btree.AscendGreaterOrEqual(newKey(start), visitor)
+			tree.AscendGreaterOrEqual(newKey(start), visitor)
 		case !reverse:
-			db.btree.AscendRange(newKey(start), newKey(end), visitor)
+			tree.AscendRange(newKey(start), newKey(end), visitor)
 		case end == nil:
 			// abort after start, since we use [start, end) while btree uses (start, end]
 			abortLessThan = start
-			db.btree.Descend(visitor)
+			tree.Descend(visitor)
 		default:
 			// skip end and abort after start, since we use [start, end) while btree uses (start, end]
 			skipEqual = end
 			abortLessThan = start
-			db.btree.DescendLessOrEqual(newKey(end), visitor)
+			tree.DescendLessOrEqual(newKey(end), visitor)
 		}
 		close(ch)
 	}()
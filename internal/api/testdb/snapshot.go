@@ -0,0 +1,64 @@
+package testdb
+
+import (
+	"github.com/google/btree"
+)
+
+// Snapshot is an immutable point-in-time view of a MemDB.
+//
+// Taking a snapshot is cheap since the underlying B-tree is cloned copy-on-write:
+// nodes are only copied when the MemDB modifies them after the snapshot was taken.
+// A snapshot is safe for concurrent use and can be used while the MemDB is modified.
+type Snapshot struct {
+	btree *btree.BTree
+}
+
+// Snapshot returns a point-in-time view of the database.
+func (db *MemDB) Snapshot() *Snapshot {
+	// Clone modifies the copy-on-write context of the original tree, so this needs a write lock
+	db.mtx.Lock()
+	defer db.mtx.Unlock()
+
+	return &Snapshot{btree: db.btree.Clone()}
+}
+
+// Get returns the value of the key at the time of the snapshot, or nil if it did not exist.
+func (s *Snapshot) Get(key []byte) ([]byte, error) {
+	if len(key) == 0 {
+		return nil, errKeyEmpty
+	}
+	i := s.btree.Get(newKey(key))
+	if i != nil {
+		return i.(*item).value, nil
+	}
+	return nil, nil
+}
+
+// Has returns true if the key existed at the time of the snapshot.
+func (s *Snapshot) Has(key []byte) (bool, error) {
+	if len(key) == 0 {
+		return false, errKeyEmpty
+	}
+	return s.btree.Has(newKey(key)), nil
+}
+
+// Len returns the number of entries in the snapshot.
+func (s *Snapshot) Len() int {
+	return s.btree.Len()
+}
+
+// Iterator returns an iterator over the snapshot in ascending key order.
+func (s *Snapshot) Iterator(start, end []byte) (Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, errKeyEmpty
+	}
+	return newMemDBIterator(s.btree, start, end, false), nil
+}
+
+// ReverseIterator returns an iterator over the snapshot in descending key order.
+func (s *Snapshot) ReverseIterator(start, end []byte) (Iterator, error) {
+	if (start != nil && len(start) == 0) || (end != nil && len(end) == 0) {
+		return nil, errKeyEmpty
+	}
+	return newMemDBIterator(s.btree, start, end, true), nil
+}
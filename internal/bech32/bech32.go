@@ -0,0 +1,189 @@
+// Package bech32 implements the bech32 address format as specified in BIP-173.
+//
+// Unlike BIP-173, the length of an encoded string is limited to 1023 characters
+// instead of 90, which is what the Cosmos SDK uses for addresses.
+package bech32
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MaxLength is the maximum length of an encoded string.
+const MaxLength = 1023
+
+const (
+	charset      = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+	checksumSize = 6
+)
+
+var generator = [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+
+// charsetRev maps a character to its 5-bit value or -1 if it is not part of the charset
+var charsetRev = func() (rev [128]int8) {
+	for i := range rev {
+		rev[i] = -1
+	}
+	for i, c := range charset {
+		rev[c] = int8(i)
+	}
+	return rev
+}()
+
+// Encode encodes data with the human readable part hrp. The result is lowercase.
+func Encode(hrp string, data []byte) (string, error) {
+	if err := validateHRP(hrp); err != nil {
+		return "", err
+	}
+	hrp = strings.ToLower(hrp)
+	values := convertBits(data, 8, 5, true)
+	if len(hrp)+1+len(values)+checksumSize > MaxLength {
+		return "", fmt.Errorf("encoded length exceeds %d characters", MaxLength)
+	}
+
+	var sb strings.Builder
+	sb.Grow(len(hrp) + 1 + len(values) + checksumSize)
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range values {
+		sb.WriteByte(charset[v])
+	}
+	for _, v := range checksum(hrp, values) {
+		sb.WriteByte(charset[v])
+	}
+	return sb.String(), nil
+}
+
+// Decode decodes a bech32 string into its lowercase human readable part and its data.
+// Strings may be all lowercase or all uppercase, but not mixed case.
+func Decode(s string) (string, []byte, error) {
+	if len(s) < 8 {
+		return "", nil, errors.New("string too short")
+	}
+	if len(s) > MaxLength {
+		return "", nil, fmt.Errorf("string exceeds %d characters", MaxLength)
+	}
+	hasLower, hasUpper := false, false
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 33 || c > 126 {
+			return "", nil, fmt.Errorf("invalid character at position %d", i)
+		}
+		hasLower = hasLower || (c >= 'a' && c <= 'z')
+		hasUpper = hasUpper || (c >= 'A' && c <= 'Z')
+	}
+	if hasLower && hasUpper {
+		return "", nil, errors.New("string has mixed case")
+	}
+	s = strings.ToLower(s)
+
+	sep := strings.LastIndexByte(s, '1')
+	if sep < 1 {
+		return "", nil, errors.New("missing human readable part")
+	}
+	if sep+1+checksumSize > len(s) {
+		return "", nil, errors.New("checksum too short")
+	}
+	hrp := s[:sep]
+	if err := validateHRP(hrp); err != nil {
+		return "", nil, err
+	}
+	values := make([]byte, len(s)-sep-1)
+	for i := range values {
+		v := charsetRev[s[sep+1+i]]
+		if v < 0 {
+			return "", nil, fmt.Errorf("invalid data character at position %d", sep+1+i)
+		}
+		values[i] = byte(v)
+	}
+	if polymod(hrp, values) != 1 {
+		return "", nil, errors.New("invalid checksum")
+	}
+
+	data, err := convertBitsStrict(values[:len(values)-checksumSize])
+	if err != nil {
+		return "", nil, err
+	}
+	return hrp, data, nil
+}
+
+func validateHRP(hrp string) error {
+	if len(hrp) < 1 || len(hrp) > 83 {
+		return errors.New("human readable part must have 1 to 83 characters")
+	}
+	for i := 0; i < len(hrp); i++ {
+		if hrp[i] < 33 || hrp[i] > 126 {
+			return fmt.Errorf("invalid character in human readable part at position %d", i)
+		}
+	}
+	return nil
+}
+
+func polymod(hrp string, values []byte) uint32 {
+	chk := uint32(1)
+	step := func(v byte) {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i, g := range generator {
+			if (top>>i)&1 == 1 {
+				chk ^= g
+			}
+		}
+	}
+	for i := 0; i < len(hrp); i++ {
+		step(hrp[i] >> 5)
+	}
+	step(0)
+	for i := 0; i < len(hrp); i++ {
+		step(hrp[i] & 31)
+	}
+	for _, v := range values {
+		step(v)
+	}
+	return chk
+}
+
+func checksum(hrp string, values []byte) [checksumSize]byte {
+	padded := make([]byte, len(values)+checksumSize)
+	copy(padded, values)
+	mod := polymod(hrp, padded) ^ 1
+	var res [checksumSize]byte
+	for i := range res {
+		res[i] = byte(mod>>(5*(5-i))) & 31
+	}
+	return res
+}
+
+// convertBits regroups data from groups of fromBits to groups of toBits, padding the last group with zeros.
+func convertBits(data []byte, fromBits, toBits uint, pad bool) []byte {
+	var acc uint32
+	var bits uint
+	maxv := uint32(1)<<toBits - 1
+	res := make([]byte, 0, (len(data)*int(fromBits)+int(toBits)-1)/int(toBits))
+	for _, b := range data {
+		acc = acc<<fromBits | uint32(b)
+		bits += fromBits
+		for bits >= toBits {
+			bits -= toBits
+			res = append(res, byte(acc>>bits&maxv))
+		}
+	}
+	if pad && bits > 0 {
+		res = append(res, byte(acc<<(toBits-bits)&maxv))
+	}
+	return res
+}
+
+// convertBitsStrict converts 5-bit values to bytes and rejects padding that is longer
+// than 4 bits or not zero, as required by BIP-173.
+func convertBitsStrict(values []byte) ([]byte, error) {
+	if len(values)*5%8 > 4 {
+		return nil, errors.New("invalid padding")
+	}
+	data := convertBits(values, 5, 8, false)
+	if rest := len(values) * 5 % 8; rest > 0 && values[len(values)-1]&(1<<rest-1) != 0 {
+		return nil, errors.New("non-zero padding")
+	}
+	return data, nil
+}
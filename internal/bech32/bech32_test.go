@@ -0,0 +1,102 @@
+package bech32
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// test vectors from https://github.com/bitcoin/bips/blob/master/bip-0173.mediawiki#test-vectors
+func TestDecodeValid(t *testing.T) {
+	valid := []string{
+		"A12UEL5L",
+		"a12uel5l",
+		"an83characterlonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1tt5tgs",
+		"abcdef1qpzry9x8gf2tvdw0s3jn54khce6mua7lmqqqxw",
+		"11" + strings.Repeat("q", 82) + "c8247j",
+		"split1checkupstagehandshakeupstreamerranterredcaperred2y9e3w",
+		"?1ezyfcl",
+	}
+	for _, s := range valid {
+		t.Run(s, func(t *testing.T) {
+			hrp, data, err := Decode(s)
+			require.NoError(t, err)
+			encoded, err := Encode(hrp, data)
+			require.NoError(t, err)
+			assert.Equal(t, strings.ToLower(s), encoded)
+		})
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	specs := map[string]struct {
+		input  string
+		expErr string
+	}{
+		"space in hrp":         {input: "\x201nwldj5", expErr: "invalid character at position 0"},
+		"delete in hrp":        {input: "\x7f1axkwrx", expErr: "invalid character at position 0"},
+		"non-ascii in hrp":     {input: "\x801eym55h", expErr: "invalid character at position 0"},
+		"hrp too long":         {input: "an84characterslonghumanreadablepartthatcontainsthenumber1andtheexcludedcharactersbio1569pvx", expErr: "human readable part must have 1 to 83 characters"},
+		"no separator":         {input: "pzry9x0s0muk", expErr: "missing human readable part"},
+		"empty hrp":            {input: "1pzry9x0s0muk", expErr: "missing human readable part"},
+		"invalid data char":    {input: "x1b4n0q5v", expErr: "invalid data character at position 2"},
+		"checksum too short":   {input: "li1dgmt3", expErr: "checksum too short"},
+		"invalid char in data": {input: "de1lg7wt\xff", expErr: "invalid character at position 8"},
+		"uppercase checksum":   {input: "A1G7SGD8", expErr: "invalid checksum"},
+		"empty hrp 2":          {input: "10a06t8", expErr: "string too short"},
+		"empty hrp 3":          {input: "1qzzfhee", expErr: "missing human readable part"},
+		"mixed case":           {input: "A12uEL5L", expErr: "string has mixed case"},
+		"too short":            {input: "a1qqqqq", expErr: "string too short"},
+		"too long":             {input: "a1" + strings.Repeat("q", MaxLength), expErr: "string exceeds 1023 characters"},
+		// 3 values are 15 bits, i.e. one byte and 7 bits of padding
+		"invalid padding": {input: withChecksum("a", 0, 0, 0), expErr: "invalid padding"},
+		// 2 values are 10 bits, i.e. one byte and 2 bits of padding
+		"non-zero padding": {input: withChecksum("a", 0, 1), expErr: "non-zero padding"},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := Decode(spec.input)
+			require.EqualError(t, err, spec.expErr)
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	// a well-known address of 20 zero bytes
+	encoded, err := Encode("cosmos", make([]byte, 20))
+	require.NoError(t, err)
+	assert.Equal(t, "cosmos1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqnrql8a", encoded)
+
+	data, err := hex.DecodeString("751e76e8199196d454941c45d1b3a323f1433bd6")
+	require.NoError(t, err)
+	encoded, err = Encode("BC", data)
+	require.NoError(t, err)
+	hrp, decoded, err := Decode(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, "bc", hrp)
+	assert.Equal(t, data, decoded)
+
+	_, err = Encode("", data)
+	require.EqualError(t, err, "human readable part must have 1 to 83 characters")
+	_, err = Encode("a b", data)
+	require.EqualError(t, err, "invalid character in human readable part at position 1")
+	_, err = Encode("a", make([]byte, 700))
+	require.EqualError(t, err, "encoded length exceeds 1023 characters")
+}
+
+// withChecksum encodes the given 5-bit values with a valid checksum.
+func withChecksum(hrp string, values ...byte) string {
+	var sb strings.Builder
+	sb.WriteString(hrp)
+	sb.WriteByte('1')
+	for _, v := range values {
+		sb.WriteByte(charset[v])
+	}
+	for _, v := range checksum(hrp, values) {
+		sb.WriteByte(charset[v])
+	}
+	return sb.String()
+}
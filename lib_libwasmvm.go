@@ -6,10 +6,12 @@
 package cosmwasm
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 
 	"github.com/CosmWasm/wasmvm/v2/internal/api"
+	"github.com/CosmWasm/wasmvm/v2/replay"
 	"github.com/CosmWasm/wasmvm/v2/types"
 )
 
@@ -17,8 +19,11 @@ import (
 // You should create an instance with its own subdirectory to manage state inside,
 // and call it for all cosmwasm code related actions.
 type VM struct {
-	cache      api.Cache
-	printDebug bool
+	cache           api.Cache
+	printDebug      bool
+	responseOptions ResponseOptions
+	gasConfig       types.GasConfig
+	onRecord        func(*replay.Recording)
 }
 
 // NewVM creates a new VM.
@@ -28,13 +33,96 @@ type VM struct {
 // `memoryLimit` is the memory limit of each contract execution (in MiB)
 // `printDebug` is a flag to enable/disable printing debug logs from the contract to STDOUT. This should be false in production environments.
 // `cacheSize` sets the size in MiB of an in-memory cache for e.g. module caching. Set to 0 to disable.
-// `deserCost` sets the gas cost of deserializing one byte of data.
+//
+// The VM uses types.DefaultGasConfig() as its gas schedule. Use SetGasConfig to change it.
 func NewVM(dataDir string, supportedCapabilities []string, memoryLimit uint32, printDebug bool, cacheSize uint32) (*VM, error) {
 	cache, err := api.InitCache(dataDir, supportedCapabilities, cacheSize, memoryLimit)
 	if err != nil {
 		return nil, err
 	}
-	return &VM{cache: cache, printDebug: printDebug}, nil
+	return &VM{cache: cache, printDebug: printDebug, gasConfig: types.DefaultGasConfig()}, nil
+}
+
+// SetResponseOptions configures how contract responses are deserialized by this VM.
+// This must be called before the VM is used concurrently.
+func (vm *VM) SetResponseOptions(opts ResponseOptions) {
+	vm.responseOptions = opts
+}
+
+// SetGasConfig sets the gas schedule of this VM. Calls that pass a zero deserCost use the
+// deserialization cost of the config. Since it determines the gas consumption of contract calls,
+// all nodes of a chain must use the same configuration. Use the WithGasConfig option to override
+// it for individual calls.
+// This must be called before the VM is used concurrently.
+func (vm *VM) SetGasConfig(config types.GasConfig) {
+	vm.gasConfig = config
+}
+
+// GasConfig returns the gas schedule of this VM.
+func (vm *VM) GasConfig() types.GasConfig {
+	return vm.gasConfig
+}
+
+// DefaultIteratorLimit is the maximum number of iterators a single contract call can open
+// unless configured otherwise via SetIteratorLimit.
+const DefaultIteratorLimit = api.DefaultIteratorLimit
+
+// SetIteratorLimit sets the maximum number of iterators a single contract call can open.
+// A limit of 0 restores DefaultIteratorLimit. Use the WithIteratorLimit option to override it
+// for individual calls. This must be called before the VM is used concurrently.
+func (vm *VM) SetIteratorLimit(limit int) {
+	api.SetIteratorLimit(&vm.cache, limit)
+}
+
+// SetIteratorBatchSize sets the maximum number of records an iterator fetches from the store
+// per callback. Batching reduces the overhead of iterating over many records. A size of 0 or 1
+// disables batching, which is the default.
+//
+// Batching only applies to stores implementing types.MeteredKVStore, e.g. store.GasStore.
+// Batches are read from the unmetered store and every record is charged to the gas meter when
+// the contract reads it, so the gas consumed is the same for all batch sizes.
+// This must be called before the VM is used concurrently.
+func (vm *VM) SetIteratorBatchSize(size int) {
+	api.SetIteratorBatchSize(&vm.cache, size)
+}
+
+// CallOption overrides a setting of the VM for a single call.
+type CallOption func(*callConfig)
+
+// WithGasConfig makes a call use the given gas schedule instead of the one of the VM.
+func WithGasConfig(config types.GasConfig) CallOption {
+	return func(c *callConfig) {
+		c.gasConfig = config
+	}
+}
+
+// WithIteratorLimit makes a contract call use the given iterator limit instead of the one of the VM.
+// A limit of 0 means DefaultIteratorLimit.
+func WithIteratorLimit(limit int) CallOption {
+	return func(c *callConfig) {
+		api.SetIteratorLimit(&c.cache, limit)
+	}
+}
+
+// callConfig holds the settings of a single call, which are the settings of the VM
+// with the options of the call applied.
+type callConfig struct {
+	gasConfig types.GasConfig
+	// cache is the cache handle passed to the call. Its iterator settings only apply to the call.
+	cache api.Cache
+}
+
+func (vm *VM) newCall(opts []CallOption) callConfig {
+	c := callConfig{gasConfig: vm.gasConfig, cache: vm.cache}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// GetIteratorMetrics returns process-wide metrics of the iterators opened by contract calls.
+func (vm *VM) GetIteratorMetrics() types.IteratorMetrics {
+	return api.GetIteratorMetrics()
 }
 
 // Cleanup should be called when no longer using this instances.
@@ -53,8 +141,8 @@ func (vm *VM) Cleanup() {
 // be instantiated with custom inputs in the future.
 //
 // Returns both the checksum, as well as the gas cost of compilation (in CosmWasm Gas) or an error.
-func (vm *VM) StoreCode(code WasmCode, gasLimit uint64) (Checksum, uint64, error) {
-	gasCost := compileCost(code)
+func (vm *VM) StoreCode(code WasmCode, gasLimit uint64, opts ...CallOption) (Checksum, uint64, error) {
+	gasCost := vm.newCall(opts).gasConfig.CompileCost(len(code))
 	if gasLimit < gasCost {
 		return nil, gasCost, types.OutOfGasError{}
 	}
@@ -136,26 +224,29 @@ func (vm *VM) Instantiate(
 	gasMeter GasMeter,
 	gasLimit uint64,
 	deserCost types.UFraction,
+	opts ...CallOption,
 ) (*types.ContractResult, uint64, error) {
-	envBin, err := json.Marshal(env)
+	envBin, err := env.MarshalJSON()
 	if err != nil {
 		return nil, 0, err
 	}
-	infoBin, err := json.Marshal(info)
+	infoBin, err := info.MarshalJSON()
 	if err != nil {
 		return nil, 0, err
 	}
-	data, gasReport, err := api.Instantiate(vm.cache, checksum, envBin, infoBin, initMsg, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug)
+	c := vm.newCall(opts)
+	baseCost, gasLimit, err := c.applyGasConfig(replay.Instantiate, gasLimit, &goapi, &querier, &gasMeter)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost, err
 	}
-
+	finish := vm.recordCall(replay.Instantiate, checksum, envBin, infoBin, initMsg, gasLimit, &store, &goapi, &querier, &gasMeter)
 	var result types.ContractResult
-	err = DeserializeResponse(gasLimit, deserCost, &gasReport, data, &result)
+	_, gasReport, err := api.Instantiate(c.cache, checksum, envBin, infoBin, initMsg, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug, vm.decodeResult(finish, gasLimit, c.deserCost(deserCost), &result))
+	finish(nil, gasReport, err)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost + gasReport.UsedInternally, err
 	}
-	return &result, gasReport.UsedInternally, nil
+	return &result, baseCost + gasReport.UsedInternally, nil
 }
 
 // Execute calls a given contract. Since the only difference between contracts with the same Checksum is the
@@ -175,26 +266,29 @@ func (vm *VM) Execute(
 	gasMeter GasMeter,
 	gasLimit uint64,
 	deserCost types.UFraction,
+	opts ...CallOption,
 ) (*types.ContractResult, uint64, error) {
-	envBin, err := json.Marshal(env)
+	envBin, err := env.MarshalJSON()
 	if err != nil {
 		return nil, 0, err
 	}
-	infoBin, err := json.Marshal(info)
+	infoBin, err := info.MarshalJSON()
 	if err != nil {
 		return nil, 0, err
 	}
-	data, gasReport, err := api.Execute(vm.cache, checksum, envBin, infoBin, executeMsg, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug)
+	c := vm.newCall(opts)
+	baseCost, gasLimit, err := c.applyGasConfig(replay.Execute, gasLimit, &goapi, &querier, &gasMeter)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost, err
 	}
-
+	finish := vm.recordCall(replay.Execute, checksum, envBin, infoBin, executeMsg, gasLimit, &store, &goapi, &querier, &gasMeter)
 	var result types.ContractResult
-	err = DeserializeResponse(gasLimit, deserCost, &gasReport, data, &result)
+	_, gasReport, err := api.Execute(c.cache, checksum, envBin, infoBin, executeMsg, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug, vm.decodeResult(finish, gasLimit, c.deserCost(deserCost), &result))
+	finish(nil, gasReport, err)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost + gasReport.UsedInternally, err
 	}
-	return &result, gasReport.UsedInternally, nil
+	return &result, baseCost + gasReport.UsedInternally, nil
 }
 
 // Query allows a client to execute a contract-specific query. If the result is not empty, it should be
@@ -210,22 +304,25 @@ func (vm *VM) Query(
 	gasMeter GasMeter,
 	gasLimit uint64,
 	deserCost types.UFraction,
+	opts ...CallOption,
 ) (*types.QueryResult, uint64, error) {
-	envBin, err := json.Marshal(env)
+	envBin, err := env.MarshalJSON()
 	if err != nil {
 		return nil, 0, err
 	}
-	data, gasReport, err := api.Query(vm.cache, checksum, envBin, queryMsg, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug)
+	c := vm.newCall(opts)
+	baseCost, gasLimit, err := c.applyGasConfig(replay.Query, gasLimit, &goapi, &querier, &gasMeter)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost, err
 	}
-
+	finish := vm.recordCall(replay.Query, checksum, envBin, nil, queryMsg, gasLimit, &store, &goapi, &querier, &gasMeter)
 	var result types.QueryResult
-	err = DeserializeResponse(gasLimit, deserCost, &gasReport, data, &result)
+	_, gasReport, err := api.Query(c.cache, checksum, envBin, queryMsg, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug, vm.decodeResult(finish, gasLimit, c.deserCost(deserCost), &result))
+	finish(nil, gasReport, err)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost + gasReport.UsedInternally, err
 	}
-	return &result, gasReport.UsedInternally, nil
+	return &result, baseCost + gasReport.UsedInternally, nil
 }
 
 // Migrate will migrate an existing contract to a new code binary.
@@ -244,22 +341,25 @@ func (vm *VM) Migrate(
 	gasMeter GasMeter,
 	gasLimit uint64,
 	deserCost types.UFraction,
+	opts ...CallOption,
 ) (*types.ContractResult, uint64, error) {
-	envBin, err := json.Marshal(env)
+	envBin, err := env.MarshalJSON()
 	if err != nil {
 		return nil, 0, err
 	}
-	data, gasReport, err := api.Migrate(vm.cache, checksum, envBin, migrateMsg, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug)
+	c := vm.newCall(opts)
+	baseCost, gasLimit, err := c.applyGasConfig(replay.Migrate, gasLimit, &goapi, &querier, &gasMeter)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost, err
 	}
-
+	finish := vm.recordCall(replay.Migrate, checksum, envBin, nil, migrateMsg, gasLimit, &store, &goapi, &querier, &gasMeter)
 	var result types.ContractResult
-	err = DeserializeResponse(gasLimit, deserCost, &gasReport, data, &result)
+	_, gasReport, err := api.Migrate(c.cache, checksum, envBin, migrateMsg, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug, vm.decodeResult(finish, gasLimit, c.deserCost(deserCost), &result))
+	finish(nil, gasReport, err)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost + gasReport.UsedInternally, err
 	}
-	return &result, gasReport.UsedInternally, nil
+	return &result, baseCost + gasReport.UsedInternally, nil
 }
 
 // Sudo allows native Go modules to make priviledged (sudo) calls on the contract.
@@ -278,22 +378,25 @@ func (vm *VM) Sudo(
 	gasMeter GasMeter,
 	gasLimit uint64,
 	deserCost types.UFraction,
+	opts ...CallOption,
 ) (*types.ContractResult, uint64, error) {
-	envBin, err := json.Marshal(env)
+	envBin, err := env.MarshalJSON()
 	if err != nil {
 		return nil, 0, err
 	}
-	data, gasReport, err := api.Sudo(vm.cache, checksum, envBin, sudoMsg, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug)
+	c := vm.newCall(opts)
+	baseCost, gasLimit, err := c.applyGasConfig(replay.Sudo, gasLimit, &goapi, &querier, &gasMeter)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost, err
 	}
-
+	finish := vm.recordCall(replay.Sudo, checksum, envBin, nil, sudoMsg, gasLimit, &store, &goapi, &querier, &gasMeter)
 	var result types.ContractResult
-	err = DeserializeResponse(gasLimit, deserCost, &gasReport, data, &result)
+	_, gasReport, err := api.Sudo(c.cache, checksum, envBin, sudoMsg, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug, vm.decodeResult(finish, gasLimit, c.deserCost(deserCost), &result))
+	finish(nil, gasReport, err)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost + gasReport.UsedInternally, err
 	}
-	return &result, gasReport.UsedInternally, nil
+	return &result, baseCost + gasReport.UsedInternally, nil
 }
 
 // Reply allows the native Go wasm modules to make a priviledged call to return the result
@@ -310,8 +413,9 @@ func (vm *VM) Reply(
 	gasMeter GasMeter,
 	gasLimit uint64,
 	deserCost types.UFraction,
+	opts ...CallOption,
 ) (*types.ContractResult, uint64, error) {
-	envBin, err := json.Marshal(env)
+	envBin, err := env.MarshalJSON()
 	if err != nil {
 		return nil, 0, err
 	}
@@ -319,17 +423,19 @@ func (vm *VM) Reply(
 	if err != nil {
 		return nil, 0, err
 	}
-	data, gasReport, err := api.Reply(vm.cache, checksum, envBin, replyBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug)
+	c := vm.newCall(opts)
+	baseCost, gasLimit, err := c.applyGasConfig(replay.Reply, gasLimit, &goapi, &querier, &gasMeter)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost, err
 	}
-
+	finish := vm.recordCall(replay.Reply, checksum, envBin, nil, replyBin, gasLimit, &store, &goapi, &querier, &gasMeter)
 	var result types.ContractResult
-	err = DeserializeResponse(gasLimit, deserCost, &gasReport, data, &result)
+	_, gasReport, err := api.Reply(c.cache, checksum, envBin, replyBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug, vm.decodeResult(finish, gasLimit, c.deserCost(deserCost), &result))
+	finish(nil, gasReport, err)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost + gasReport.UsedInternally, err
 	}
-	return &result, gasReport.UsedInternally, nil
+	return &result, baseCost + gasReport.UsedInternally, nil
 }
 
 // IBCChannelOpen is available on IBC-enabled contracts and is a hook to call into
@@ -344,8 +450,9 @@ func (vm *VM) IBCChannelOpen(
 	gasMeter GasMeter,
 	gasLimit uint64,
 	deserCost types.UFraction,
+	opts ...CallOption,
 ) (*types.IBCChannelOpenResult, uint64, error) {
-	envBin, err := json.Marshal(env)
+	envBin, err := env.MarshalJSON()
 	if err != nil {
 		return nil, 0, err
 	}
@@ -353,17 +460,19 @@ func (vm *VM) IBCChannelOpen(
 	if err != nil {
 		return nil, 0, err
 	}
-	data, gasReport, err := api.IBCChannelOpen(vm.cache, checksum, envBin, msgBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug)
+	c := vm.newCall(opts)
+	baseCost, gasLimit, err := c.applyGasConfig(replay.IBCChannelOpen, gasLimit, &goapi, &querier, &gasMeter)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost, err
 	}
-
+	finish := vm.recordCall(replay.IBCChannelOpen, checksum, envBin, nil, msgBin, gasLimit, &store, &goapi, &querier, &gasMeter)
 	var result types.IBCChannelOpenResult
-	err = DeserializeResponse(gasLimit, deserCost, &gasReport, data, &result)
+	_, gasReport, err := api.IBCChannelOpen(c.cache, checksum, envBin, msgBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug, vm.decodeResult(finish, gasLimit, c.deserCost(deserCost), &result))
+	finish(nil, gasReport, err)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost + gasReport.UsedInternally, err
 	}
-	return &result, gasReport.UsedInternally, nil
+	return &result, baseCost + gasReport.UsedInternally, nil
 }
 
 // IBCChannelConnect is available on IBC-enabled contracts and is a hook to call into
@@ -378,8 +487,9 @@ func (vm *VM) IBCChannelConnect(
 	gasMeter GasMeter,
 	gasLimit uint64,
 	deserCost types.UFraction,
+	opts ...CallOption,
 ) (*types.IBCBasicResult, uint64, error) {
-	envBin, err := json.Marshal(env)
+	envBin, err := env.MarshalJSON()
 	if err != nil {
 		return nil, 0, err
 	}
@@ -387,17 +497,19 @@ func (vm *VM) IBCChannelConnect(
 	if err != nil {
 		return nil, 0, err
 	}
-	data, gasReport, err := api.IBCChannelConnect(vm.cache, checksum, envBin, msgBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug)
+	c := vm.newCall(opts)
+	baseCost, gasLimit, err := c.applyGasConfig(replay.IBCChannelConnect, gasLimit, &goapi, &querier, &gasMeter)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost, err
 	}
-
+	finish := vm.recordCall(replay.IBCChannelConnect, checksum, envBin, nil, msgBin, gasLimit, &store, &goapi, &querier, &gasMeter)
 	var result types.IBCBasicResult
-	err = DeserializeResponse(gasLimit, deserCost, &gasReport, data, &result)
+	_, gasReport, err := api.IBCChannelConnect(c.cache, checksum, envBin, msgBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug, vm.decodeResult(finish, gasLimit, c.deserCost(deserCost), &result))
+	finish(nil, gasReport, err)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost + gasReport.UsedInternally, err
 	}
-	return &result, gasReport.UsedInternally, nil
+	return &result, baseCost + gasReport.UsedInternally, nil
 }
 
 // IBCChannelClose is available on IBC-enabled contracts and is a hook to call into
@@ -412,8 +524,9 @@ func (vm *VM) IBCChannelClose(
 	gasMeter GasMeter,
 	gasLimit uint64,
 	deserCost types.UFraction,
+	opts ...CallOption,
 ) (*types.IBCBasicResult, uint64, error) {
-	envBin, err := json.Marshal(env)
+	envBin, err := env.MarshalJSON()
 	if err != nil {
 		return nil, 0, err
 	}
@@ -421,17 +534,19 @@ func (vm *VM) IBCChannelClose(
 	if err != nil {
 		return nil, 0, err
 	}
-	data, gasReport, err := api.IBCChannelClose(vm.cache, checksum, envBin, msgBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug)
+	c := vm.newCall(opts)
+	baseCost, gasLimit, err := c.applyGasConfig(replay.IBCChannelClose, gasLimit, &goapi, &querier, &gasMeter)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost, err
 	}
-
+	finish := vm.recordCall(replay.IBCChannelClose, checksum, envBin, nil, msgBin, gasLimit, &store, &goapi, &querier, &gasMeter)
 	var result types.IBCBasicResult
-	err = DeserializeResponse(gasLimit, deserCost, &gasReport, data, &result)
+	_, gasReport, err := api.IBCChannelClose(c.cache, checksum, envBin, msgBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug, vm.decodeResult(finish, gasLimit, c.deserCost(deserCost), &result))
+	finish(nil, gasReport, err)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost + gasReport.UsedInternally, err
 	}
-	return &result, gasReport.UsedInternally, nil
+	return &result, baseCost + gasReport.UsedInternally, nil
 }
 
 // IBCPacketReceive is available on IBC-enabled contracts and is called when an incoming
@@ -446,8 +561,9 @@ func (vm *VM) IBCPacketReceive(
 	gasMeter GasMeter,
 	gasLimit uint64,
 	deserCost types.UFraction,
+	opts ...CallOption,
 ) (*types.IBCReceiveResult, uint64, error) {
-	envBin, err := json.Marshal(env)
+	envBin, err := env.MarshalJSON()
 	if err != nil {
 		return nil, 0, err
 	}
@@ -455,17 +571,19 @@ func (vm *VM) IBCPacketReceive(
 	if err != nil {
 		return nil, 0, err
 	}
-	data, gasReport, err := api.IBCPacketReceive(vm.cache, checksum, envBin, msgBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug)
+	c := vm.newCall(opts)
+	baseCost, gasLimit, err := c.applyGasConfig(replay.IBCPacketReceive, gasLimit, &goapi, &querier, &gasMeter)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost, err
 	}
-
+	finish := vm.recordCall(replay.IBCPacketReceive, checksum, envBin, nil, msgBin, gasLimit, &store, &goapi, &querier, &gasMeter)
 	var result types.IBCReceiveResult
-	err = DeserializeResponse(gasLimit, deserCost, &gasReport, data, &result)
+	_, gasReport, err := api.IBCPacketReceive(c.cache, checksum, envBin, msgBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug, vm.decodeResult(finish, gasLimit, c.deserCost(deserCost), &result))
+	finish(nil, gasReport, err)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost + gasReport.UsedInternally, err
 	}
-	return &result, gasReport.UsedInternally, nil
+	return &result, baseCost + gasReport.UsedInternally, nil
 }
 
 // IBCPacketAck is available on IBC-enabled contracts and is called when an
@@ -481,8 +599,9 @@ func (vm *VM) IBCPacketAck(
 	gasMeter GasMeter,
 	gasLimit uint64,
 	deserCost types.UFraction,
+	opts ...CallOption,
 ) (*types.IBCBasicResult, uint64, error) {
-	envBin, err := json.Marshal(env)
+	envBin, err := env.MarshalJSON()
 	if err != nil {
 		return nil, 0, err
 	}
@@ -490,17 +609,19 @@ func (vm *VM) IBCPacketAck(
 	if err != nil {
 		return nil, 0, err
 	}
-	data, gasReport, err := api.IBCPacketAck(vm.cache, checksum, envBin, msgBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug)
+	c := vm.newCall(opts)
+	baseCost, gasLimit, err := c.applyGasConfig(replay.IBCPacketAck, gasLimit, &goapi, &querier, &gasMeter)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost, err
 	}
-
+	finish := vm.recordCall(replay.IBCPacketAck, checksum, envBin, nil, msgBin, gasLimit, &store, &goapi, &querier, &gasMeter)
 	var result types.IBCBasicResult
-	err = DeserializeResponse(gasLimit, deserCost, &gasReport, data, &result)
+	_, gasReport, err := api.IBCPacketAck(c.cache, checksum, envBin, msgBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug, vm.decodeResult(finish, gasLimit, c.deserCost(deserCost), &result))
+	finish(nil, gasReport, err)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost + gasReport.UsedInternally, err
 	}
-	return &result, gasReport.UsedInternally, nil
+	return &result, baseCost + gasReport.UsedInternally, nil
 }
 
 // IBCPacketTimeout is available on IBC-enabled contracts and is called when an
@@ -516,8 +637,9 @@ func (vm *VM) IBCPacketTimeout(
 	gasMeter GasMeter,
 	gasLimit uint64,
 	deserCost types.UFraction,
+	opts ...CallOption,
 ) (*types.IBCBasicResult, uint64, error) {
-	envBin, err := json.Marshal(env)
+	envBin, err := env.MarshalJSON()
 	if err != nil {
 		return nil, 0, err
 	}
@@ -525,17 +647,19 @@ func (vm *VM) IBCPacketTimeout(
 	if err != nil {
 		return nil, 0, err
 	}
-	data, gasReport, err := api.IBCPacketTimeout(vm.cache, checksum, envBin, msgBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug)
+	c := vm.newCall(opts)
+	baseCost, gasLimit, err := c.applyGasConfig(replay.IBCPacketTimeout, gasLimit, &goapi, &querier, &gasMeter)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost, err
 	}
-
+	finish := vm.recordCall(replay.IBCPacketTimeout, checksum, envBin, nil, msgBin, gasLimit, &store, &goapi, &querier, &gasMeter)
 	var result types.IBCBasicResult
-	err = DeserializeResponse(gasLimit, deserCost, &gasReport, data, &result)
+	_, gasReport, err := api.IBCPacketTimeout(c.cache, checksum, envBin, msgBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug, vm.decodeResult(finish, gasLimit, c.deserCost(deserCost), &result))
+	finish(nil, gasReport, err)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost + gasReport.UsedInternally, err
 	}
-	return &result, gasReport.UsedInternally, nil
+	return &result, baseCost + gasReport.UsedInternally, nil
 }
 
 // IBCSourceCallback is available on IBC-enabled contracts with the corresponding entrypoint
@@ -551,8 +675,9 @@ func (vm *VM) IBCSourceCallback(
 	gasMeter GasMeter,
 	gasLimit uint64,
 	deserCost types.UFraction,
+	opts ...CallOption,
 ) (*types.IBCBasicResult, uint64, error) {
-	envBin, err := json.Marshal(env)
+	envBin, err := env.MarshalJSON()
 	if err != nil {
 		return nil, 0, err
 	}
@@ -560,17 +685,19 @@ func (vm *VM) IBCSourceCallback(
 	if err != nil {
 		return nil, 0, err
 	}
-	data, gasReport, err := api.IBCSourceCallback(vm.cache, checksum, envBin, msgBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug)
+	c := vm.newCall(opts)
+	baseCost, gasLimit, err := c.applyGasConfig(replay.IBCSourceCallback, gasLimit, &goapi, &querier, &gasMeter)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost, err
 	}
-
+	finish := vm.recordCall(replay.IBCSourceCallback, checksum, envBin, nil, msgBin, gasLimit, &store, &goapi, &querier, &gasMeter)
 	var result types.IBCBasicResult
-	err = DeserializeResponse(gasLimit, deserCost, &gasReport, data, &result)
+	_, gasReport, err := api.IBCSourceCallback(c.cache, checksum, envBin, msgBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug, vm.decodeResult(finish, gasLimit, c.deserCost(deserCost), &result))
+	finish(nil, gasReport, err)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost + gasReport.UsedInternally, err
 	}
-	return &result, gasReport.UsedInternally, nil
+	return &result, baseCost + gasReport.UsedInternally, nil
 }
 
 // IBCDestinationCallback is available on IBC-enabled contracts with the corresponding entrypoint
@@ -585,8 +712,9 @@ func (vm *VM) IBCDestinationCallback(
 	gasMeter GasMeter,
 	gasLimit uint64,
 	deserCost types.UFraction,
+	opts ...CallOption,
 ) (*types.IBCBasicResult, uint64, error) {
-	envBin, err := json.Marshal(env)
+	envBin, err := env.MarshalJSON()
 	if err != nil {
 		return nil, 0, err
 	}
@@ -594,26 +722,43 @@ func (vm *VM) IBCDestinationCallback(
 	if err != nil {
 		return nil, 0, err
 	}
-	data, gasReport, err := api.IBCDestinationCallback(vm.cache, checksum, envBin, msgBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug)
+	c := vm.newCall(opts)
+	baseCost, gasLimit, err := c.applyGasConfig(replay.IBCDestinationCallback, gasLimit, &goapi, &querier, &gasMeter)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost, err
 	}
-
+	finish := vm.recordCall(replay.IBCDestinationCallback, checksum, envBin, nil, msgBin, gasLimit, &store, &goapi, &querier, &gasMeter)
 	var result types.IBCBasicResult
-	err = DeserializeResponse(gasLimit, deserCost, &gasReport, data, &result)
+	_, gasReport, err := api.IBCDestinationCallback(c.cache, checksum, envBin, msgBin, &gasMeter, store, &goapi, &querier, gasLimit, vm.printDebug, vm.decodeResult(finish, gasLimit, c.deserCost(deserCost), &result))
+	finish(nil, gasReport, err)
 	if err != nil {
-		return nil, gasReport.UsedInternally, err
+		return nil, baseCost + gasReport.UsedInternally, err
 	}
-	return &result, gasReport.UsedInternally, nil
+	return &result, baseCost + gasReport.UsedInternally, nil
 }
 
-func compileCost(code WasmCode) uint64 {
-	// CostPerByte is how much CosmWasm gas is charged *per byte* for compiling WASM code.
-	// Benchmarks and numbers (in SDK Gas) were discussed in:
-	// https://github.com/CosmWasm/wasmd/pull/634#issuecomment-938056803
-	const CostPerByte uint64 = 3 * 140_000
+// applyGasConfig applies the gas schedule of the call to a call of the given entrypoint.
+// It charges the base cost of the entrypoint and wraps goapi, querier and gasMeter such that they
+// charge the configured address and query costs and report CosmWasm gas.
+// It returns the base cost and the gas limit left for the call.
+func (c callConfig) applyGasConfig(entrypoint string, gasLimit uint64, goapi *GoAPI, querier *Querier, gasMeter *GasMeter) (uint64, uint64, error) {
+	baseCost := c.gasConfig.EntrypointBaseCost(entrypoint)
+	if gasLimit < baseCost {
+		return baseCost, 0, types.OutOfGasError{}
+	}
+	*goapi = c.gasConfig.GoAPI(*goapi)
+	*querier = c.gasConfig.Querier(*querier)
+	*gasMeter = c.gasConfig.GasMeter(*gasMeter)
+	return baseCost, gasLimit - baseCost, nil
+}
 
-	return CostPerByte * uint64(len(code))
+// deserCost returns the deserialization cost of a call, which is the cost of the
+// gas schedule if deserCost is zero.
+func (c callConfig) deserCost(deserCost types.UFraction) types.UFraction {
+	if deserCost == (types.UFraction{}) {
+		return c.gasConfig.DeserializationCost
+	}
+	return deserCost
 }
 
 // hasSubMessages is an interface for contract results that can contain sub-messages.
@@ -629,18 +774,85 @@ var (
 	_ hasSubMessages = (*types.ContractResult)(nil)
 )
 
+// validatable is implemented by contract results that support strict validation (see types.ContractResult.Validate).
+type validatable interface {
+	Validate() error
+}
+
+var (
+	_ validatable = (*types.IBCChannelOpenResult)(nil)
+	_ validatable = (*types.IBCBasicResult)(nil)
+	_ validatable = (*types.IBCReceiveResult)(nil)
+	_ validatable = (*types.ContractResult)(nil)
+)
+
+// ResponseOptions configures optional checks performed when deserializing contract responses.
+// The zero value performs no additional checks.
+type ResponseOptions struct {
+	// ValidateEnums rejects responses in which an enum-like type (e.g. CosmosMsg) has zero or
+	// multiple variants set, or in which a result has both the ok and the error case set.
+	ValidateEnums bool
+	// RejectUnknownFields rejects responses containing JSON keys that do not map to a field
+	// of the Go types, e.g. a CosmosMsg variant added in a newer version of cosmwasm-std.
+	// By default such keys are silently ignored.
+	RejectUnknownFields bool
+	// Limits restricts the size and structure of responses. They are checked before the
+	// response is decoded. Violations are reported as types.ResponseLimitError.
+	Limits types.ResponseLimits
+}
+
+// decodeResult returns an api.ResultDecoder that deserializes the result of a call into response
+// directly from the memory owned by Rust.
+//
+// Since the result is not available as a copy afterwards, it passes the outcome of the call to
+// finish (see recordCall) before deserializing. Calling finish again after the call is a no-op then.
+func (vm *VM) decodeResult(finish func([]byte, types.GasReport, error), gasLimit uint64, deserCost types.UFraction, response any) api.ResultDecoder {
+	return func(data []byte, gasReport *types.GasReport) error {
+		if vm.onRecord != nil {
+			finish(bytes.Clone(data), *gasReport, nil)
+		}
+		return DeserializeResponseWithOptions(gasLimit, deserCost, gasReport, data, response, vm.responseOptions)
+	}
+}
+
+// DeserializeResponse is the same as DeserializeResponseWithOptions with default options.
 func DeserializeResponse(gasLimit uint64, deserCost types.UFraction, gasReport *types.GasReport, data []byte, response any) error {
-	gasForDeserialization := deserCost.Mul(uint64(len(data))).Floor()
-	if gasLimit < gasForDeserialization+gasReport.UsedInternally {
+	return DeserializeResponseWithOptions(gasLimit, deserCost, gasReport, data, response, ResponseOptions{})
+}
+
+// DeserializeResponseWithOptions charges gas for deserializing the given data, decodes it into
+// response and performs the checks configured in opts.
+func DeserializeResponseWithOptions(gasLimit uint64, deserCost types.UFraction, gasReport *types.GasReport, data []byte, response any, opts ResponseOptions) error {
+	gasForDeserialization, err := deserCost.MulFloor(uint64(len(data)))
+	if err != nil {
+		return fmt.Errorf("Cannot compute gas for deserializing contract execution result (%d bytes): %w", len(data), err)
+	}
+	if gasForDeserialization > gasLimit || gasReport.UsedInternally > gasLimit-gasForDeserialization {
 		return fmt.Errorf("Insufficient gas left to deserialize contract execution result (%d bytes)", len(data))
 	}
 	gasReport.UsedInternally += gasForDeserialization
 	gasReport.Remaining -= gasForDeserialization
 
-	err := json.Unmarshal(data, response)
+	if !opts.Limits.IsZero() {
+		if err := opts.Limits.Check(data); err != nil {
+			return err
+		}
+	}
+
+	// the results of instantiate/execute/migrate/sudo/reply are decoded without reflection
+	if result, ok := response.(*types.ContractResult); ok {
+		err = types.UnmarshalContractResult(data, result)
+	} else {
+		err = json.Unmarshal(data, response)
+	}
 	if err != nil {
 		return err
 	}
+	if opts.RejectUnknownFields {
+		if err := types.CheckUnknownFields(data, response); err != nil {
+			return err
+		}
+	}
 
 	// All responses that have sub-messages need their payload size to be checked
 	const ReplyPayloadMaxBytes = 128 * 1024 // 128 KiB
@@ -653,5 +865,13 @@ func DeserializeResponse(gasLimit uint64, deserCost types.UFraction, gasReport *
 		}
 	}
 
+	if opts.ValidateEnums {
+		if response, ok := response.(validatable); ok {
+			if err := response.Validate(); err != nil {
+				return err
+			}
+		}
+	}
+
 	return nil
 }
@@ -132,6 +132,70 @@ func TestRemoveCode(t *testing.T) {
 	require.ErrorContains(t, err, "Wasm file does not exist")
 }
 
+func TestGasConfig(t *testing.T) {
+	vm := withVM(t)
+	require.Equal(t, types.DefaultGasConfig(), vm.GasConfig())
+
+	wasm, err := os.ReadFile(HACKATOM_TEST_CONTRACT)
+	require.NoError(t, err)
+
+	config := types.DefaultGasConfig()
+	config.CompileCostBase = 1_000_000
+	config.CompileCostPerByte = 7
+	config.EntrypointBaseCosts = map[string]types.Gas{"instantiate": 5_000_000}
+	vm.SetGasConfig(config)
+
+	checksum, gasCost, err := vm.StoreCode(wasm, TESTING_GAS_LIMIT)
+	require.NoError(t, err)
+	assert.Equal(t, 1_000_000+7*uint64(len(wasm)), gasCost)
+
+	_, gasCost, err = vm.StoreCode(wasm, 1_000_000)
+	require.ErrorIs(t, err, types.OutOfGasError{})
+	assert.Equal(t, 1_000_000+7*uint64(len(wasm)), gasCost)
+
+	instantiate := func(gasLimit uint64, opts ...CallOption) (uint64, error) {
+		gasMeter := api.NewMockGasMeter(TESTING_GAS_LIMIT)
+		store := api.NewLookup(gasMeter)
+		goapi := api.NewMockAPI()
+		querier := api.DefaultQuerier(api.MOCK_CONTRACT_ADDR, nil)
+		msg := []byte(`{"verifier": "fred", "beneficiary": "bob"}`)
+		// a zero deserCost uses the deserialization cost of the gas config
+		_, gasUsed, err := vm.Instantiate(checksum, api.MockEnv(), api.MockInfo("creator", nil), msg, store, *goapi, querier, gasMeter, gasLimit, types.UFraction{}, opts...)
+		return gasUsed, err
+	}
+
+	withBaseCost, err := instantiate(TESTING_GAS_LIMIT)
+	require.NoError(t, err)
+	withoutBaseCost, err := instantiate(TESTING_GAS_LIMIT, WithGasConfig(types.DefaultGasConfig()))
+	require.NoError(t, err)
+	assert.Equal(t, withoutBaseCost+5_000_000, withBaseCost)
+
+	_, err = instantiate(4_999_999)
+	require.ErrorIs(t, err, types.OutOfGasError{})
+}
+
+func TestIteratorLimitOption(t *testing.T) {
+	vm := withVM(t)
+	checksum := createTestContract(t, vm, "./testdata/queue.wasm")
+
+	gasMeter := api.NewMockGasMeter(TESTING_GAS_LIMIT)
+	store := api.NewLookup(gasMeter)
+	goapi := api.NewMockAPI()
+	querier := api.DefaultQuerier(api.MOCK_CONTRACT_ADDR, nil)
+	deserCost := types.UFraction{Numerator: 1, Denominator: 1}
+	_, _, err := vm.Instantiate(checksum, api.MockEnv(), api.MockInfo("creator", nil), []byte(`{}`), store, *goapi, querier, gasMeter, TESTING_GAS_LIMIT, deserCost)
+	require.NoError(t, err)
+
+	query := func(opts ...CallOption) error {
+		gasMeter := api.NewMockGasMeter(TESTING_GAS_LIMIT)
+		_, _, err := vm.Query(checksum, api.MockEnv(), []byte(`{"open_iterators":{"count":5}}`), store, *goapi, querier, gasMeter, TESTING_GAS_LIMIT, deserCost, opts...)
+		return err
+	}
+	require.ErrorIs(t, query(WithIteratorLimit(4)), types.IteratorLimitError{Limit: 4})
+	// the option does not change the limit of the VM
+	require.NoError(t, query())
+}
+
 func TestHappyPath(t *testing.T) {
 	vm := withVM(t)
 	checksum := createTestContract(t, vm, HACKATOM_TEST_CONTRACT)
@@ -410,3 +474,135 @@ func TestLongPayloadDeserialization(t *testing.T) {
 	require.Error(t, err)
 	require.Contains(t, err.Error(), "payload")
 }
+
+func BenchmarkDeserializeResponse(b *testing.B) {
+	deserCost := types.UFraction{Numerator: 1, Denominator: 1}
+	resultJson := []byte(`{"ok":{"messages":[{"id":0,"msg":{"bank":{"send":{"to_address":"bob","amount":[{"denom":"ATOM","amount":"250"}]}}},"reply_on":"never"},{"id":1,"msg":{"wasm":{"execute":{"contract_addr":"contract","msg":"eyJyZWxlYXNlIjp7fX0=","funds":[]}}},"reply_on":"success"}],"data":"8Auq","attributes":[{"key":"action","value":"release"},{"key":"destination","value":"bob"}],"events":[{"type":"hackatom","attributes":[{"key":"action","value":"release"}]}]}}`)
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		gasReport := types.GasReport{}
+		var result types.ContractResult
+		err := DeserializeResponse(math.MaxUint64, deserCost, &gasReport, resultJson, &result)
+		require.NoError(b, err)
+	}
+}
+
+func TestDeserializeResponseGas(t *testing.T) {
+	resultJson := []byte(`{"ok":{"messages":[],"attributes":[],"events":[]}}`)
+	size := uint64(len(resultJson))
+
+	specs := map[string]struct {
+		deserCost types.UFraction
+		gasLimit  uint64
+		used      uint64
+		expGas    uint64
+		expErr    string
+	}{
+		"charged": {
+			deserCost: types.UFraction{Numerator: 3, Denominator: 2},
+			gasLimit:  math.MaxUint64,
+			used:      100,
+			expGas:    100 + size*3/2,
+		},
+		"exactly enough gas": {
+			deserCost: types.UFraction{Numerator: 1, Denominator: 1},
+			gasLimit:  100 + size,
+			used:      100,
+			expGas:    100 + size,
+		},
+		"insufficient gas": {
+			deserCost: types.UFraction{Numerator: 1, Denominator: 1},
+			gasLimit:  100 + size - 1,
+			used:      100,
+			expErr:    "Insufficient gas left",
+		},
+		"used and deserialization overflow": {
+			deserCost: types.UFraction{Numerator: math.MaxUint64 / size, Denominator: 1},
+			gasLimit:  math.MaxUint64,
+			used:      math.MaxUint64 - 1,
+			expErr:    "Insufficient gas left",
+		},
+		"cost overflow": {
+			deserCost: types.UFraction{Numerator: math.MaxUint64, Denominator: 1},
+			gasLimit:  math.MaxUint64,
+			expErr:    "Fraction overflow",
+		},
+		"zero denominator": {
+			deserCost: types.UFraction{Numerator: 1, Denominator: 0},
+			gasLimit:  math.MaxUint64,
+			expErr:    "zero denominator",
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			gasReport := types.GasReport{UsedInternally: spec.used, Remaining: spec.gasLimit - spec.used}
+			var result types.ContractResult
+			err := DeserializeResponse(spec.gasLimit, spec.deserCost, &gasReport, resultJson, &result)
+			if spec.expErr != "" {
+				require.ErrorContains(t, err, spec.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, spec.expGas, gasReport.UsedInternally)
+		})
+	}
+}
+
+func TestDeserializeResponseValidateEnums(t *testing.T) {
+	deserCost := types.UFraction{Numerator: 1, Denominator: 1}
+	resultJson := []byte(`{"ok":{"messages":[{"id":0,"msg":{"bank":{"send":{"to_address":"bob","amount":[]}}},"reply_on":"never"},{"id":1,"msg":{"bank":{"send":{"to_address":"bob","amount":[]},"burn":{"amount":[]}}},"reply_on":"never"}],"attributes":[],"events":[]}}`)
+
+	// accepted by default
+	gasReport := types.GasReport{}
+	var result types.ContractResult
+	err := DeserializeResponse(math.MaxUint64, deserCost, &gasReport, resultJson, &result)
+	require.NoError(t, err)
+
+	// rejected in strict mode
+	gasReport = types.GasReport{}
+	result = types.ContractResult{}
+	err = DeserializeResponseWithOptions(math.MaxUint64, deserCost, &gasReport, resultJson, &result, ResponseOptions{ValidateEnums: true})
+	require.EqualError(t, err, "invalid BankMsg at messages[1].msg.bank: multiple variants set: send, burn")
+
+	// both ok and error
+	gasReport = types.GasReport{}
+	var ibcResult types.IBCBasicResult
+	err = DeserializeResponseWithOptions(math.MaxUint64, deserCost, &gasReport, []byte(`{"ok":{"messages":[],"attributes":[],"events":[]},"error":"foo"}`), &ibcResult, ResponseOptions{ValidateEnums: true})
+	require.EqualError(t, err, "invalid IBCBasicResult: both ok and error set")
+}
+
+func TestDeserializeResponseRejectUnknownFields(t *testing.T) {
+	deserCost := types.UFraction{Numerator: 1, Denominator: 1}
+	resultJson := []byte(`{"ok":{"messages":[{"id":0,"msg":{"new_module":{"do":{}}},"reply_on":"never"}],"attributes":[],"events":[]}}`)
+
+	// accepted by default
+	gasReport := types.GasReport{}
+	var result types.ContractResult
+	err := DeserializeResponse(math.MaxUint64, deserCost, &gasReport, resultJson, &result)
+	require.NoError(t, err)
+	require.Equal(t, types.CosmosMsg{}, result.Ok.Messages[0].Msg)
+
+	// rejected in strict mode
+	gasReport = types.GasReport{}
+	result = types.ContractResult{}
+	err = DeserializeResponseWithOptions(math.MaxUint64, deserCost, &gasReport, resultJson, &result, ResponseOptions{RejectUnknownFields: true})
+	require.EqualError(t, err, `unknown variant "new_module" in CosmosMsg at ok.messages[0].msg`)
+}
+
+func TestDeserializeResponseLimits(t *testing.T) {
+	deserCost := types.UFraction{Numerator: 1, Denominator: 1}
+	resultJson := []byte(`{"ok":{"messages":[],"attributes":[{"key":"a","value":"b"}],"events":[{"type":"foo","attributes":[]},{"type":"bar","attributes":[]}]}}`)
+
+	gasReport := types.GasReport{}
+	var result types.ContractResult
+	err := DeserializeResponseWithOptions(math.MaxUint64, deserCost, &gasReport, resultJson, &result, ResponseOptions{Limits: types.ResponseLimits{MaxEvents: 2}})
+	require.NoError(t, err)
+
+	gasReport = types.GasReport{}
+	result = types.ContractResult{}
+	err = DeserializeResponseWithOptions(math.MaxUint64, deserCost, &gasReport, resultJson, &result, ResponseOptions{Limits: types.ResponseLimits{MaxEvents: 1}})
+	require.ErrorIs(t, err, types.ResponseLimitError{Limit: "events", Max: 1, Path: "ok.events"})
+	// the response was not decoded
+	require.Nil(t, result.Ok)
+}
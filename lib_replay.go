@@ -0,0 +1,94 @@
+//go:build cgo && !nolink_libwasmvm
+
+package cosmwasm
+
+import (
+	"fmt"
+
+	"github.com/CosmWasm/wasmvm/v2/internal/api"
+	"github.com/CosmWasm/wasmvm/v2/replay"
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// SetRecorder makes the VM record all callbacks of every contract call and pass the recording
+// to onRecord once the call finished, e.g. to write it to a file for replaying it later with Replay.
+// Recording is slow and memory intensive, so it should only be enabled for debugging.
+// Pass nil to disable recording. This must be called before the VM is used concurrently.
+func (vm *VM) SetRecorder(onRecord func(*replay.Recording)) {
+	vm.onRecord = onRecord
+}
+
+// recordCall wraps the callbacks of a call in a replay.Recorder if recording is enabled.
+// The returned function must be called with the outcome of the call.
+func (vm *VM) recordCall(entrypoint string, checksum Checksum, env, info, msg []byte, gasLimit uint64, store *KVStore, goapi *GoAPI, querier *Querier, gasMeter *GasMeter) func([]byte, types.GasReport, error) {
+	if vm.onRecord == nil {
+		return func([]byte, types.GasReport, error) {}
+	}
+	r := replay.NewRecorder(entrypoint, checksum, env, info, msg, gasLimit)
+	*store = r.KVStore(*store)
+	*goapi = r.GoAPI(*goapi)
+	*querier = r.Querier(*querier)
+	*gasMeter = r.GasMeter(*gasMeter)
+	finished := false
+	return func(data []byte, gasReport types.GasReport, err error) {
+		// only the first outcome is recorded, see decodeResult
+		if finished {
+			return
+		}
+		finished = true
+		vm.onRecord(r.Finish(data, gasReport, err))
+	}
+}
+
+// Replay executes a recorded call again without any chain state by answering all callbacks from the recording.
+// The code of the recorded checksum must be stored in this VM.
+//
+// It returns the recording of the replayed call, which can be compared to the original one using replay.Diff.
+// The error is a replay.DivergenceError if the replayed call made different callbacks than the recorded one.
+// Errors of the contract call itself are part of the returned recording.
+func (vm *VM) Replay(rec *replay.Recording) (*replay.Recording, error) {
+	p := replay.NewPlayer(rec)
+	store := p.KVStore()
+	goapi := p.GoAPI()
+	querier := p.Querier()
+	gasMeter := p.GasMeter()
+
+	var (
+		data      []byte
+		gasReport types.GasReport
+		err       error
+	)
+	switch rec.Entrypoint {
+	case replay.Instantiate:
+		data, gasReport, err = api.Instantiate(vm.cache, rec.Checksum, rec.Env, rec.Info, rec.Msg, &gasMeter, store, &goapi, &querier, rec.GasLimit, vm.printDebug, nil)
+	case replay.Execute:
+		data, gasReport, err = api.Execute(vm.cache, rec.Checksum, rec.Env, rec.Info, rec.Msg, &gasMeter, store, &goapi, &querier, rec.GasLimit, vm.printDebug, nil)
+	case replay.Query:
+		data, gasReport, err = api.Query(vm.cache, rec.Checksum, rec.Env, rec.Msg, &gasMeter, store, &goapi, &querier, rec.GasLimit, vm.printDebug, nil)
+	case replay.Migrate:
+		data, gasReport, err = api.Migrate(vm.cache, rec.Checksum, rec.Env, rec.Msg, &gasMeter, store, &goapi, &querier, rec.GasLimit, vm.printDebug, nil)
+	case replay.Sudo:
+		data, gasReport, err = api.Sudo(vm.cache, rec.Checksum, rec.Env, rec.Msg, &gasMeter, store, &goapi, &querier, rec.GasLimit, vm.printDebug, nil)
+	case replay.Reply:
+		data, gasReport, err = api.Reply(vm.cache, rec.Checksum, rec.Env, rec.Msg, &gasMeter, store, &goapi, &querier, rec.GasLimit, vm.printDebug, nil)
+	case replay.IBCChannelOpen:
+		data, gasReport, err = api.IBCChannelOpen(vm.cache, rec.Checksum, rec.Env, rec.Msg, &gasMeter, store, &goapi, &querier, rec.GasLimit, vm.printDebug, nil)
+	case replay.IBCChannelConnect:
+		data, gasReport, err = api.IBCChannelConnect(vm.cache, rec.Checksum, rec.Env, rec.Msg, &gasMeter, store, &goapi, &querier, rec.GasLimit, vm.printDebug, nil)
+	case replay.IBCChannelClose:
+		data, gasReport, err = api.IBCChannelClose(vm.cache, rec.Checksum, rec.Env, rec.Msg, &gasMeter, store, &goapi, &querier, rec.GasLimit, vm.printDebug, nil)
+	case replay.IBCPacketReceive:
+		data, gasReport, err = api.IBCPacketReceive(vm.cache, rec.Checksum, rec.Env, rec.Msg, &gasMeter, store, &goapi, &querier, rec.GasLimit, vm.printDebug, nil)
+	case replay.IBCPacketAck:
+		data, gasReport, err = api.IBCPacketAck(vm.cache, rec.Checksum, rec.Env, rec.Msg, &gasMeter, store, &goapi, &querier, rec.GasLimit, vm.printDebug, nil)
+	case replay.IBCPacketTimeout:
+		data, gasReport, err = api.IBCPacketTimeout(vm.cache, rec.Checksum, rec.Env, rec.Msg, &gasMeter, store, &goapi, &querier, rec.GasLimit, vm.printDebug, nil)
+	case replay.IBCSourceCallback:
+		data, gasReport, err = api.IBCSourceCallback(vm.cache, rec.Checksum, rec.Env, rec.Msg, &gasMeter, store, &goapi, &querier, rec.GasLimit, vm.printDebug, nil)
+	case replay.IBCDestinationCallback:
+		data, gasReport, err = api.IBCDestinationCallback(vm.cache, rec.Checksum, rec.Env, rec.Msg, &gasMeter, store, &goapi, &querier, rec.GasLimit, vm.printDebug, nil)
+	default:
+		return nil, fmt.Errorf("unknown entrypoint %q", rec.Entrypoint)
+	}
+	return p.Finish(data, gasReport, err)
+}
@@ -0,0 +1,69 @@
+//go:build cgo && !nolink_libwasmvm
+
+package cosmwasm
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CosmWasm/wasmvm/v2/internal/api"
+	"github.com/CosmWasm/wasmvm/v2/replay"
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	vm := withVM(t)
+	checksum := createTestContract(t, vm, HACKATOM_TEST_CONTRACT)
+
+	deserCost := types.UFraction{Numerator: 1, Denominator: 1}
+	gasMeter1 := api.NewMockGasMeter(TESTING_GAS_LIMIT)
+	store := api.NewLookup(gasMeter1)
+	goapi := api.NewMockAPI()
+	balance := types.Array[types.Coin]{types.NewCoin(250, "ATOM")}
+	querier := api.DefaultQuerier(api.MOCK_CONTRACT_ADDR, balance)
+
+	env := api.MockEnv()
+	info := api.MockInfo("creator", nil)
+	msg := []byte(`{"verifier": "fred", "beneficiary": "bob"}`)
+	_, _, err := vm.Instantiate(checksum, env, info, msg, store, *goapi, querier, gasMeter1, TESTING_GAS_LIMIT, deserCost)
+	require.NoError(t, err)
+
+	// record an execution
+	var recordings []*replay.Recording
+	vm.SetRecorder(func(rec *replay.Recording) { recordings = append(recordings, rec) })
+	gasMeter2 := api.NewMockGasMeter(TESTING_GAS_LIMIT)
+	store.SetGasMeter(gasMeter2)
+	info = api.MockInfo("fred", nil)
+	_, _, err = vm.Execute(checksum, env, info, []byte(`{"release":{}}`), store, *goapi, querier, gasMeter2, TESTING_GAS_LIMIT, deserCost)
+	require.NoError(t, err)
+	vm.SetRecorder(nil)
+	require.Len(t, recordings, 1)
+	rec := recordings[0]
+	assert.Equal(t, replay.Execute, rec.Entrypoint)
+	assert.NotEmpty(t, rec.Ops)
+
+	path := filepath.Join(t.TempDir(), "execute.json")
+	require.NoError(t, rec.WriteFile(path))
+	rec, err = replay.ReadFile(path)
+	require.NoError(t, err)
+
+	// replay on a VM without any state
+	vm2 := withVM(t)
+	createTestContract(t, vm2, HACKATOM_TEST_CONTRACT)
+	replayed, err := vm2.Replay(rec)
+	require.NoError(t, err)
+	assert.Empty(t, replay.Diff(rec, replayed))
+
+	// a call that reads different data does not write the same
+	for _, op := range rec.Ops {
+		if op.Kind == replay.OpQuery {
+			op.Response = []byte(`{"amount":{"denom":"ATOM","amount":"1"}}`)
+		}
+	}
+	replayed, err = vm2.Replay(rec)
+	require.NoError(t, err)
+	assert.NotEmpty(t, replay.Diff(rec, replayed))
+}
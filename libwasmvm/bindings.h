@@ -276,9 +276,10 @@ typedef struct U8SliceView {
  */
 typedef struct IteratorReference {
   /**
-   * An ID assigned to this contract call
+   * The state of the contract call that owns the iterator. This is the same
+   * pointer as `Db::state`, so Go can find the iterator without a global lookup.
    */
-  uint64_t call_id;
+  struct db_t *state;
   /**
    * An ID assigned to this iterator
    */
@@ -302,6 +303,25 @@ typedef struct IteratorVtable {
                         uint64_t *gas_used,
                         struct UnmanagedVector *value_out,
                         struct UnmanagedVector *err_msg_out);
+  /**
+   * Fetches up to `max_records` records at once without charging gas for them. The records
+   * are written to `records_out` in the format decoded by `decode_batch`. Fewer records than
+   * requested means the end of the iterator was reached.
+   */
+  int32_t (*next_batch)(struct IteratorReference iterator,
+                        struct gas_meter_t *gas_meter,
+                        uint64_t *gas_used,
+                        uint64_t max_records,
+                        struct UnmanagedVector *records_out,
+                        struct UnmanagedVector *err_msg_out);
+  /**
+   * Charges the gas of reading the next record like `next` does, without returning the
+   * record. This is called for every record fetched by `next_batch` when it is handed out.
+   */
+  int32_t (*charge_next)(struct IteratorReference iterator,
+                         struct gas_meter_t *gas_meter,
+                         uint64_t *gas_used,
+                         struct UnmanagedVector *err_msg_out);
 } IteratorVtable;
 
 typedef struct GoIter {
@@ -312,6 +332,11 @@ typedef struct GoIter {
    */
   struct IteratorReference reference;
   struct IteratorVtable vtable;
+  /**
+   * The maximum number of records fetched with one call to `next_batch`.
+   * Batching is disabled when this is 0 or 1.
+   */
+  uint64_t batch_size;
 } GoIter;
 
 typedef struct DbVtable {
@@ -1,6 +1,9 @@
+use std::collections::VecDeque;
+
 use cosmwasm_std::Record;
 use cosmwasm_vm::{BackendError, BackendResult, GasInfo};
 
+use crate::db::db_t;
 use crate::error::GoError;
 use crate::gas_meter::gas_meter_t;
 use crate::memory::UnmanagedVector;
@@ -9,14 +12,24 @@ use crate::vtables::Vtable;
 /// A reference to some tables on the Go side which allow accessing
 /// the actual iterator instance.
 #[repr(C)]
-#[derive(Default, Copy, Clone)]
+#[derive(Copy, Clone)]
 pub struct IteratorReference {
-    /// An ID assigned to this contract call
-    pub call_id: u64,
+    /// The state of the contract call that owns the iterator. This is the same
+    /// pointer as `Db::state`, so Go can find the iterator without a global lookup.
+    pub state: *mut db_t,
     /// An ID assigned to this iterator
     pub iterator_id: u64,
 }
 
+impl Default for IteratorReference {
+    fn default() -> Self {
+        IteratorReference {
+            state: std::ptr::null_mut(),
+            iterator_id: 0,
+        }
+    }
+}
+
 // These functions should return GoError but because we don't trust them here, we treat the return value as i32
 // and then check it when converting to GoError manually
 #[repr(C)]
@@ -50,6 +63,29 @@ pub struct IteratorVtable {
             err_msg_out: *mut UnmanagedVector,
         ) -> i32,
     >,
+    /// Fetches up to `max_records` records at once without charging gas for them. The records
+    /// are written to `records_out` in the format decoded by `decode_batch`. Fewer records than
+    /// requested means the end of the iterator was reached.
+    pub next_batch: Option<
+        extern "C" fn(
+            iterator: IteratorReference,
+            gas_meter: *mut gas_meter_t,
+            gas_used: *mut u64,
+            max_records: u64,
+            records_out: *mut UnmanagedVector,
+            err_msg_out: *mut UnmanagedVector,
+        ) -> i32,
+    >,
+    /// Charges the gas of reading the next record like `next` does, without returning the
+    /// record. This is called for every record fetched by `next_batch` when it is handed out.
+    pub charge_next: Option<
+        extern "C" fn(
+            iterator: IteratorReference,
+            gas_meter: *mut gas_meter_t,
+            gas_used: *mut u64,
+            err_msg_out: *mut UnmanagedVector,
+        ) -> i32,
+    >,
 }
 
 impl Vtable for IteratorVtable {}
@@ -61,6 +97,9 @@ pub struct GoIter {
     /// actual iterator instance in Go. Once fully initalized, this is immutable.
     pub reference: IteratorReference,
     pub vtable: IteratorVtable,
+    /// The maximum number of records fetched with one call to `next_batch`.
+    /// Batching is disabled when this is 0 or 1.
+    pub batch_size: u64,
 }
 
 impl GoIter {
@@ -74,7 +113,82 @@ impl GoIter {
             reference: IteratorReference::default(),
             gas_meter: std::ptr::null_mut(),
             vtable: IteratorVtable::default(),
+            batch_size: 0,
+        }
+    }
+
+    /// Returns true if records can be fetched in batches
+    pub fn supports_batches(&self) -> bool {
+        self.batch_size > 1 && self.vtable.next_batch.is_some() && self.vtable.charge_next.is_some()
+    }
+
+    /// Fetches up to `batch_size` records. The gas of reading them is charged separately
+    /// by `charge_next`.
+    pub fn next_batch(&mut self) -> BackendResult<Vec<Record>> {
+        let next_batch = self
+            .vtable
+            .next_batch
+            .expect("iterator vtable function 'next_batch' not set");
+
+        let mut output = UnmanagedVector::default();
+        let mut error_msg = UnmanagedVector::default();
+        let mut used_gas = 0_u64;
+        let go_result: GoError = (next_batch)(
+            self.reference,
+            self.gas_meter,
+            &mut used_gas as *mut u64,
+            self.batch_size,
+            &mut output as *mut UnmanagedVector,
+            &mut error_msg as *mut UnmanagedVector,
+        )
+        .into();
+        // We destruct the `UnmanagedVector` here, no matter if we need the data.
+        let output = output.consume();
+
+        let gas_info = GasInfo::with_externally_used(used_gas);
+
+        // return complete error message (reading from buffer for GoError::Other)
+        let default = || "Failed to fetch next items from iterator".to_string();
+        unsafe {
+            if let Err(err) = go_result.into_result(error_msg, default) {
+                return (Err(err), gas_info);
+            }
+        }
+
+        let result = decode_batch(output.as_deref().unwrap_or_default()).ok_or_else(|| {
+            BackendError::unknown("Failed to decode the records fetched from the iterator")
+        });
+        (result, gas_info)
+    }
+
+    /// Charges the gas of reading the next record of a batch.
+    pub fn charge_next(&mut self) -> BackendResult<()> {
+        let charge_next = self
+            .vtable
+            .charge_next
+            .expect("iterator vtable function 'charge_next' not set");
+
+        let mut error_msg = UnmanagedVector::default();
+        let mut used_gas = 0_u64;
+        let go_result: GoError = (charge_next)(
+            self.reference,
+            self.gas_meter,
+            &mut used_gas as *mut u64,
+            &mut error_msg as *mut UnmanagedVector,
+        )
+        .into();
+
+        let gas_info = GasInfo::with_externally_used(used_gas);
+
+        // return complete error message (reading from buffer for GoError::Other)
+        let default = || "Failed to charge the next item of the iterator".to_string();
+        unsafe {
+            if let Err(err) = go_result.into_result(error_msg, default) {
+                return (Err(err), gas_info);
+            }
         }
+
+        (Ok(()), gas_info)
     }
 
     pub fn next(&mut self) -> BackendResult<Option<Record>> {
@@ -180,6 +294,101 @@ impl GoIter {
     }
 }
 
+/// Decodes the records written by `next_batch`. Each record is encoded as
+/// key length (u32), key, value length (u32) and value, with all integers in big endian.
+///
+/// Returns None if the data is malformed.
+pub fn decode_batch(mut data: &[u8]) -> Option<Vec<Record>> {
+    fn take<'a>(data: &mut &'a [u8], len: usize) -> Option<&'a [u8]> {
+        if data.len() < len {
+            return None;
+        }
+        let (head, tail) = (*data).split_at(len);
+        *data = tail;
+        Some(head)
+    }
+    fn take_len(data: &mut &[u8]) -> Option<usize> {
+        let bytes = take(data, 4)?;
+        Some(u32::from_be_bytes(bytes.try_into().ok()?) as usize)
+    }
+
+    let mut records = Vec::new();
+    while !data.is_empty() {
+        let key_len = take_len(&mut data)?;
+        let key = take(&mut data, key_len)?.to_vec();
+        let value_len = take_len(&mut data)?;
+        let value = take(&mut data, value_len)?.to_vec();
+        records.push((key, value));
+    }
+    Some(records)
+}
+
+/// An iterator that fetches records from Go in batches if the Go side supports it.
+///
+/// Batches are fetched without charging gas. The gas of a record is charged with `charge_next`
+/// when the record is returned, such that the gas meter and the results of `next`, `next_key`
+/// and `next_value` are the same as the ones of `GoIter`, no matter the batch size.
+pub struct PrefetchingIter {
+    iter: GoIter,
+    buffer: VecDeque<Record>,
+    exhausted: bool,
+}
+
+impl PrefetchingIter {
+    pub fn new(iter: GoIter) -> Self {
+        PrefetchingIter {
+            iter,
+            buffer: VecDeque::new(),
+            exhausted: false,
+        }
+    }
+
+    pub fn next(&mut self) -> BackendResult<Option<Record>> {
+        if !self.iter.supports_batches() {
+            return self.iter.next();
+        }
+        self.next_buffered()
+    }
+
+    pub fn next_key(&mut self) -> BackendResult<Option<Vec<u8>>> {
+        if !self.iter.supports_batches() {
+            return self.iter.next_key();
+        }
+        let (result, gas_info) = self.next_buffered();
+        (result.map(|r| r.map(|(key, _)| key)), gas_info)
+    }
+
+    pub fn next_value(&mut self) -> BackendResult<Option<Vec<u8>>> {
+        if !self.iter.supports_batches() {
+            return self.iter.next_value();
+        }
+        let (result, gas_info) = self.next_buffered();
+        (result.map(|r| r.map(|(_, value)| value)), gas_info)
+    }
+
+    fn next_buffered(&mut self) -> BackendResult<Option<Record>> {
+        let mut fetch_gas = 0;
+        if self.buffer.is_empty() && !self.exhausted {
+            let (result, gas_info) = self.iter.next_batch();
+            fetch_gas = gas_info.externally_used;
+            let records = match result {
+                Ok(records) => records,
+                Err(err) => return (Err(err), gas_info),
+            };
+            // Fewer records than requested means the Go iterator is at the end
+            self.exhausted = (records.len() as u64) < self.iter.batch_size;
+            self.buffer.extend(records);
+        }
+        let Some(record) = self.buffer.pop_front() else {
+            return (Ok(None), GasInfo::with_externally_used(fetch_gas));
+        };
+        let (result, gas_info) = self.iter.charge_next();
+        let gas_info =
+            GasInfo::with_externally_used(fetch_gas.saturating_add(gas_info.externally_used));
+        (result.map(|()| Some(record)), gas_info)
+    }
+}
+
 #[cfg(test)]
 mod test {
     use super::*;
@@ -194,10 +403,48 @@ mod test {
         // creates an all null-instance
         let iter = GoIter::stub();
         assert!(iter.gas_meter.is_null());
-        assert_eq!(iter.reference.call_id, 0);
+        assert!(iter.reference.state.is_null());
         assert_eq!(iter.reference.iterator_id, 0);
         assert!(iter.vtable.next.is_none());
         assert!(iter.vtable.next_key.is_none());
         assert!(iter.vtable.next_value.is_none());
+        assert!(iter.vtable.next_batch.is_none());
+        assert!(iter.vtable.charge_next.is_none());
+        assert_eq!(iter.batch_size, 0);
+        assert!(!iter.supports_batches());
+    }
+
+    fn encode_record(out: &mut Vec<u8>, key: &[u8], value: &[u8]) {
+        out.extend_from_slice(&(key.len() as u32).to_be_bytes());
+        out.extend_from_slice(key);
+        out.extend_from_slice(&(value.len() as u32).to_be_bytes());
+        out.extend_from_slice(value);
+    }
+
+    #[test]
+    fn decode_batch_works() {
+        assert_eq!(decode_batch(&[]), Some(vec![]));
+
+        let mut data = Vec::new();
+        encode_record(&mut data, b"foo", b"bar");
+        encode_record(&mut data, b"", b"");
+        encode_record(&mut data, b"k", b"some value");
+        assert_eq!(
+            decode_batch(&data),
+            Some(vec![
+                (b"foo".to_vec(), b"bar".to_vec()),
+                (vec![], vec![]),
+                (b"k".to_vec(), b"some value".to_vec()),
+            ])
+        );
+    }
+
+    #[test]
+    fn decode_batch_fails_for_truncated_data() {
+        let mut data = Vec::new();
+        encode_record(&mut data, b"foo", b"bar");
+        for len in 1..data.len() {
+            assert_eq!(decode_batch(&data[..len]), None, "length {len}");
+        }
     }
 }
@@ -6,12 +6,12 @@ use cosmwasm_vm::{BackendError, BackendResult, GasInfo, Storage};
 
 use crate::db::Db;
 use crate::error::GoError;
-use crate::iterator::GoIter;
+use crate::iterator::{GoIter, PrefetchingIter};
 use crate::memory::{U8SliceView, UnmanagedVector};
 
 pub struct GoStorage {
     db: Db,
-    iterators: HashMap<u32, GoIter>,
+    iterators: HashMap<u32, PrefetchingIter>,
 }
 
 impl GoStorage {
@@ -109,7 +109,7 @@ impl Storage for GoStorage {
             .len()
             .try_into()
             .expect("Iterator count exceeded uint32 range. This is a bug.");
-        self.iterators.insert(next_id, iter); // This moves iter. Is this okay?
+        self.iterators.insert(next_id, PrefetchingIter::new(iter)); // This moves iter. Is this okay?
         (Ok(next_id), gas_info)
     }
 
@@ -0,0 +1,52 @@
+package replay
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// Diff compares the writes, gas and outcome of two recordings of the same call,
+// typically the original recording and the one returned by Player.Finish.
+// It returns a human readable line for every difference, so an empty result means
+// the calls behaved the same.
+func Diff(expected, actual *Recording) []string {
+	var res []string
+
+	ew, aw := expected.Writes(), actual.Writes()
+	for i := 0; i < len(ew) || i < len(aw); i++ {
+		switch {
+		case i >= len(aw):
+			res = append(res, fmt.Sprintf("write %d: expected %s, got none", i, describeWrite(ew[i])))
+		case i >= len(ew):
+			res = append(res, fmt.Sprintf("write %d: expected none, got %s", i, describeWrite(aw[i])))
+		case ew[i].Kind != aw[i].Kind || !bytes.Equal(ew[i].Key, aw[i].Key) || !bytes.Equal(ew[i].Value, aw[i].Value):
+			res = append(res, fmt.Sprintf("write %d: expected %s, got %s", i, describeWrite(ew[i]), describeWrite(aw[i])))
+		}
+	}
+
+	eg, ag := expected.GasReport, actual.GasReport
+	if eg.UsedInternally != ag.UsedInternally {
+		res = append(res, fmt.Sprintf("gas used internally: expected %d, got %d", eg.UsedInternally, ag.UsedInternally))
+	}
+	if eg.UsedExternally != ag.UsedExternally {
+		res = append(res, fmt.Sprintf("gas used externally: expected %d, got %d", eg.UsedExternally, ag.UsedExternally))
+	}
+	if eg.Remaining != ag.Remaining {
+		res = append(res, fmt.Sprintf("gas remaining: expected %d, got %d", eg.Remaining, ag.Remaining))
+	}
+
+	if expected.Error != actual.Error {
+		res = append(res, fmt.Sprintf("error: expected %q, got %q", expected.Error, actual.Error))
+	}
+	if !bytes.Equal(expected.Result, actual.Result) {
+		res = append(res, fmt.Sprintf("result: expected %s, got %s", expected.Result, actual.Result))
+	}
+	return res
+}
+
+func describeWrite(op *Op) string {
+	if op.Kind == OpDelete {
+		return fmt.Sprintf("delete(%X)", op.Key)
+	}
+	return fmt.Sprintf("set(%X, %X)", op.Key, op.Value)
+}
@@ -0,0 +1,299 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// DivergenceError is returned when a replayed call makes a different callback than the recorded one.
+type DivergenceError struct {
+	// Index is the index of the op in the recording
+	Index int
+	// Expected is the recorded op and Actual the op made during replay
+	Expected string
+	Actual   string
+}
+
+var _ error = DivergenceError{}
+
+func (e DivergenceError) Error() string {
+	return fmt.Sprintf("replay diverged at op %d: expected %s, got %s", e.Index, e.Expected, e.Actual)
+}
+
+// Player answers the callbacks of a replayed call from a recording.
+// Use its methods to get the store, API, querier and gas meter to pass to the VM
+// and call Finish with the outcome of the replayed call.
+//
+// When the replayed call makes a callback that does not match the next recorded op,
+// the callback panics with a DivergenceError, which makes the call fail.
+// Writes only need to match the kind of the recorded op, so a call that writes different
+// keys or values can still be replayed to the end and the differences are reported by Diff.
+type Player struct {
+	mtx sync.Mutex
+	rec *Recording
+	pos int
+	err error
+	// out is the recording of the replay
+	out *Recording
+}
+
+// NewPlayer creates a Player for the given recording.
+func NewPlayer(rec *Recording) *Player {
+	return &Player{rec: rec, out: &Recording{
+		Entrypoint: rec.Entrypoint,
+		Checksum:   rec.Checksum,
+		Env:        rec.Env,
+		Info:       rec.Info,
+		Msg:        rec.Msg,
+		GasLimit:   rec.GasLimit,
+	}}
+}
+
+// next returns the next recorded op after checking it matches the given one.
+func (p *Player) next(actual *Op) *Op {
+	_, op := p.nextIndexed(actual)
+	return op
+}
+
+// nextIndexed is like next but also returns the index of the op in the recording.
+// Writes are added to the replay's recording as made by the replayed call, all other ops as recorded.
+func (p *Player) nextIndexed(actual *Op) (int, *Op) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	if p.err != nil {
+		panic(p.err)
+	}
+	var expected *Op
+	if p.pos < len(p.rec.Ops) {
+		expected = p.rec.Ops[p.pos]
+	}
+	if expected == nil || !matches(expected, actual) {
+		p.err = DivergenceError{Index: p.pos, Expected: describe(expected), Actual: describe(actual)}
+		panic(p.err)
+	}
+	index := p.pos
+	p.pos++
+	if actual.Kind == OpSet || actual.Kind == OpDelete {
+		p.out.Ops = append(p.out.Ops, actual)
+	} else {
+		p.out.Ops = append(p.out.Ops, expected)
+	}
+	return index, expected
+}
+
+// matches returns true if the arguments of both ops are the same.
+// Writes match by kind only, since their arguments do not affect the rest of the call.
+func matches(expected, actual *Op) bool {
+	if expected.Kind != actual.Kind {
+		return false
+	}
+	switch actual.Kind {
+	case OpGet:
+		return bytes.Equal(expected.Key, actual.Key)
+	case OpSet, OpDelete:
+		return true
+	case OpScan:
+		return bytes.Equal(expected.Start, actual.Start) && bytes.Equal(expected.End, actual.End) && expected.Reverse == actual.Reverse
+	case OpQuery:
+		e, err1 := json.Marshal(expected.Request)
+		a, err2 := json.Marshal(actual.Request)
+		return err1 == nil && err2 == nil && bytes.Equal(e, a) && expected.GasLimit == actual.GasLimit
+	case OpHumanize:
+		return bytes.Equal(expected.Canonical, actual.Canonical)
+	case OpCanonicalize, OpValidate:
+		return expected.Address == actual.Address
+	default:
+		return true
+	}
+}
+
+// describe returns a short description of an op for error messages
+func describe(op *Op) string {
+	if op == nil {
+		return "end of recording"
+	}
+	switch op.Kind {
+	case OpGet, OpDelete:
+		return fmt.Sprintf("%s(%X)", op.Kind, op.Key)
+	case OpSet:
+		return fmt.Sprintf("%s(%X, %X)", op.Kind, op.Key, op.Value)
+	case OpScan:
+		return fmt.Sprintf("%s(%X, %X, reverse=%t)", op.Kind, op.Start, op.End, op.Reverse)
+	case OpQuery:
+		bz, _ := json.Marshal(op.Request)
+		return fmt.Sprintf("%s(%s)", op.Kind, bz)
+	case OpHumanize:
+		return fmt.Sprintf("%s(%X)", op.Kind, op.Canonical)
+	case OpCanonicalize, OpValidate:
+		return fmt.Sprintf("%s(%s)", op.Kind, op.Address)
+	default:
+		return op.Kind
+	}
+}
+
+// Finish stores the outcome of the replayed call and returns the recording of the replay,
+// which can be compared to the original one using Diff.
+// The returned error is a DivergenceError if the replay diverged from the recording.
+func (p *Player) Finish(result []byte, gasReport types.GasReport, err error) (*Recording, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+
+	p.out.Result = bytes.Clone(result)
+	p.out.GasReport = gasReport
+	if err != nil {
+		p.out.Error = err.Error()
+	}
+	if p.err == nil && p.pos < len(p.rec.Ops) {
+		p.err = DivergenceError{Index: p.pos, Expected: describe(p.rec.Ops[p.pos]), Actual: "end of call"}
+	}
+	return p.out, p.err
+}
+
+// KVStore returns the store of the replayed call.
+func (p *Player) KVStore() types.KVStore {
+	return replayStore{p: p}
+}
+
+// GoAPI returns the address API of the replayed call.
+func (p *Player) GoAPI() types.GoAPI {
+	return types.GoAPI{
+		HumanizeAddress: func(canon []byte) (string, uint64, error) {
+			op := p.next(&Op{Kind: OpHumanize, Canonical: bytes.Clone(canon)})
+			return op.Address, op.Cost, op.err()
+		},
+		CanonicalizeAddress: func(addr string) ([]byte, uint64, error) {
+			op := p.next(&Op{Kind: OpCanonicalize, Address: addr})
+			return op.Canonical, op.Cost, op.err()
+		},
+		ValidateAddress: func(addr string) (uint64, error) {
+			op := p.next(&Op{Kind: OpValidate, Address: addr})
+			return op.Cost, op.err()
+		},
+	}
+}
+
+// Querier returns the querier of the replayed call.
+func (p *Player) Querier() types.Querier {
+	return replayQuerier{p: p}
+}
+
+// GasMeter returns the gas meter of the replayed call.
+func (p *Player) GasMeter() types.GasMeter {
+	return replayGasMeter{p: p}
+}
+
+type replayStore struct {
+	p *Player
+}
+
+func (s replayStore) Get(key []byte) []byte {
+	return s.p.next(&Op{Kind: OpGet, Key: bytes.Clone(key)}).Value
+}
+
+func (s replayStore) Set(key, value []byte) {
+	s.p.next(&Op{Kind: OpSet, Key: bytes.Clone(key), Value: bytes.Clone(value)})
+}
+
+func (s replayStore) Delete(key []byte) {
+	s.p.next(&Op{Kind: OpDelete, Key: bytes.Clone(key)})
+}
+
+func (s replayStore) Iterator(start, end []byte) types.Iterator {
+	return s.newIterator(start, end, false)
+}
+
+func (s replayStore) ReverseIterator(start, end []byte) types.Iterator {
+	return s.newIterator(start, end, true)
+}
+
+func (s replayStore) newIterator(start, end []byte, reverse bool) types.Iterator {
+	index, op := s.p.nextIndexed(&Op{Kind: OpScan, Start: bytes.Clone(start), End: bytes.Clone(end), Reverse: reverse})
+	return &replayIterator{p: s.p, op: op, index: index}
+}
+
+// replayIterator returns the recorded entries of a db_scan op
+type replayIterator struct {
+	p     *Player
+	op    *Op
+	index int
+	pos   int
+}
+
+func (it *replayIterator) Domain() ([]byte, []byte) {
+	return it.op.Start, it.op.End
+}
+
+func (it *replayIterator) Valid() bool {
+	if it.pos < len(it.op.Entries) {
+		return true
+	}
+	if !it.op.Exhausted {
+		// the recorded iterator was not used this far
+		it.p.mtx.Lock()
+		defer it.p.mtx.Unlock()
+		if it.p.err == nil {
+			it.p.err = DivergenceError{
+				Index:    it.index,
+				Expected: fmt.Sprintf("iteration over %d entries", len(it.op.Entries)),
+				Actual:   "iteration past the recorded entries",
+			}
+		}
+		panic(it.p.err)
+	}
+	return false
+}
+
+func (it *replayIterator) Next() {
+	if !it.Valid() {
+		panic("iterator is invalid")
+	}
+	it.pos++
+}
+
+func (it *replayIterator) Key() []byte {
+	if !it.Valid() {
+		panic("iterator is invalid")
+	}
+	return it.op.Entries[it.pos].Key
+}
+
+func (it *replayIterator) Value() []byte {
+	if !it.Valid() {
+		panic("iterator is invalid")
+	}
+	return it.op.Entries[it.pos].Value
+}
+
+func (it *replayIterator) Error() error {
+	return nil
+}
+
+func (it *replayIterator) Close() error {
+	return nil
+}
+
+type replayQuerier struct {
+	p *Player
+}
+
+func (q replayQuerier) Query(request types.QueryRequest, gasLimit uint64) ([]byte, error) {
+	op := q.p.next(&Op{Kind: OpQuery, Request: &request, GasLimit: gasLimit})
+	return op.Response, op.err()
+}
+
+func (q replayQuerier) GasConsumed() uint64 {
+	return q.p.next(&Op{Kind: OpQueryGas}).Gas
+}
+
+type replayGasMeter struct {
+	p *Player
+}
+
+func (m replayGasMeter) GasConsumed() types.Gas {
+	return m.p.next(&Op{Kind: OpGasConsumed}).Gas
+}
@@ -0,0 +1,180 @@
+package replay
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// Recorder records all callbacks of a single contract call.
+// Use its methods to wrap the store, API, querier and gas meter passed to the VM
+// and call Finish with the outcome of the call.
+type Recorder struct {
+	mtx sync.Mutex
+	rec *Recording
+}
+
+// NewRecorder creates a Recorder for a call of the given entrypoint.
+// env, info and msg are the serialized arguments of the call. info is nil for entrypoints without message info.
+func NewRecorder(entrypoint string, checksum types.Checksum, env, info, msg []byte, gasLimit uint64) *Recorder {
+	return &Recorder{rec: &Recording{
+		Entrypoint: entrypoint,
+		Checksum:   bytes.Clone(checksum),
+		Env:        bytes.Clone(env),
+		Info:       bytes.Clone(info),
+		Msg:        bytes.Clone(msg),
+		GasLimit:   gasLimit,
+	}}
+}
+
+func (r *Recorder) add(op *Op) {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+	r.rec.Ops = append(r.rec.Ops, op)
+}
+
+// Finish stores the outcome of the call and returns the recording.
+func (r *Recorder) Finish(result []byte, gasReport types.GasReport, err error) *Recording {
+	r.mtx.Lock()
+	defer r.mtx.Unlock()
+
+	r.rec.Result = bytes.Clone(result)
+	r.rec.GasReport = gasReport
+	if err != nil {
+		r.rec.Error = err.Error()
+	}
+	return r.rec
+}
+
+// KVStore wraps the contract store.
+func (r *Recorder) KVStore(parent types.KVStore) types.KVStore {
+	return recordingStore{parent: parent, r: r}
+}
+
+// GoAPI wraps the address API.
+func (r *Recorder) GoAPI(parent types.GoAPI) types.GoAPI {
+	return types.GoAPI{
+		HumanizeAddress: func(canon []byte) (string, uint64, error) {
+			addr, cost, err := parent.HumanizeAddress(canon)
+			op := &Op{Kind: OpHumanize, Canonical: bytes.Clone(canon), Address: addr, Cost: cost}
+			opError(op, err)
+			r.add(op)
+			return addr, cost, err
+		},
+		CanonicalizeAddress: func(addr string) ([]byte, uint64, error) {
+			canon, cost, err := parent.CanonicalizeAddress(addr)
+			op := &Op{Kind: OpCanonicalize, Address: addr, Canonical: bytes.Clone(canon), Cost: cost}
+			opError(op, err)
+			r.add(op)
+			return canon, cost, err
+		},
+		ValidateAddress: func(addr string) (uint64, error) {
+			cost, err := parent.ValidateAddress(addr)
+			op := &Op{Kind: OpValidate, Address: addr, Cost: cost}
+			opError(op, err)
+			r.add(op)
+			return cost, err
+		},
+	}
+}
+
+// Querier wraps the querier.
+func (r *Recorder) Querier(parent types.Querier) types.Querier {
+	return recordingQuerier{parent: parent, r: r}
+}
+
+// GasMeter wraps the gas meter.
+func (r *Recorder) GasMeter(parent types.GasMeter) types.GasMeter {
+	return recordingGasMeter{parent: parent, r: r}
+}
+
+type recordingStore struct {
+	parent types.KVStore
+	r      *Recorder
+}
+
+func (s recordingStore) Get(key []byte) []byte {
+	value := s.parent.Get(key)
+	s.r.add(&Op{Kind: OpGet, Key: bytes.Clone(key), Value: bytes.Clone(value)})
+	return value
+}
+
+func (s recordingStore) Set(key, value []byte) {
+	s.parent.Set(key, value)
+	s.r.add(&Op{Kind: OpSet, Key: bytes.Clone(key), Value: bytes.Clone(value)})
+}
+
+func (s recordingStore) Delete(key []byte) {
+	s.parent.Delete(key)
+	s.r.add(&Op{Kind: OpDelete, Key: bytes.Clone(key)})
+}
+
+func (s recordingStore) Iterator(start, end []byte) types.Iterator {
+	return s.newIterator(s.parent.Iterator(start, end), start, end, false)
+}
+
+func (s recordingStore) ReverseIterator(start, end []byte) types.Iterator {
+	return s.newIterator(s.parent.ReverseIterator(start, end), start, end, true)
+}
+
+func (s recordingStore) newIterator(parent types.Iterator, start, end []byte, reverse bool) types.Iterator {
+	op := &Op{Kind: OpScan, Start: bytes.Clone(start), End: bytes.Clone(end), Reverse: reverse}
+	it := &recordingIterator{Iterator: parent, r: s.r, op: op}
+	it.record()
+	s.r.add(op)
+	return it
+}
+
+// recordingIterator adds every entry it passes to the entries of its db_scan op
+type recordingIterator struct {
+	types.Iterator
+	r  *Recorder
+	op *Op
+}
+
+func (it *recordingIterator) Next() {
+	it.Iterator.Next()
+	it.record()
+}
+
+func (it *recordingIterator) record() {
+	it.r.mtx.Lock()
+	defer it.r.mtx.Unlock()
+
+	if !it.Iterator.Valid() {
+		it.op.Exhausted = true
+		return
+	}
+	it.op.Entries = append(it.op.Entries, Entry{Key: bytes.Clone(it.Iterator.Key()), Value: bytes.Clone(it.Iterator.Value())})
+}
+
+type recordingQuerier struct {
+	parent types.Querier
+	r      *Recorder
+}
+
+func (q recordingQuerier) Query(request types.QueryRequest, gasLimit uint64) ([]byte, error) {
+	res, err := q.parent.Query(request, gasLimit)
+	op := &Op{Kind: OpQuery, Request: &request, GasLimit: gasLimit, Response: bytes.Clone(res)}
+	opError(op, err)
+	q.r.add(op)
+	return res, err
+}
+
+func (q recordingQuerier) GasConsumed() uint64 {
+	gas := q.parent.GasConsumed()
+	q.r.add(&Op{Kind: OpQueryGas, Gas: gas})
+	return gas
+}
+
+type recordingGasMeter struct {
+	parent types.GasMeter
+	r      *Recorder
+}
+
+func (m recordingGasMeter) GasConsumed() types.Gas {
+	gas := m.parent.GasConsumed()
+	m.r.add(&Op{Kind: OpGasConsumed, Gas: gas})
+	return gas
+}
@@ -0,0 +1,164 @@
+// Package replay records the callbacks of a single contract call and replays them later
+// without any chain state. This allows reproducing failures offline.
+//
+// A Recorder wraps the store, API, querier and gas meter passed to the VM and logs every
+// call made to them in order. A Player answers the same calls from such a log and collects
+// the writes of the replayed call, which can then be compared with the recorded ones using Diff.
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// Entrypoint names as used in recordings
+const (
+	Instantiate            = "instantiate"
+	Execute                = "execute"
+	Migrate                = "migrate"
+	Sudo                   = "sudo"
+	Reply                  = "reply"
+	Query                  = "query"
+	IBCChannelOpen         = "ibc_channel_open"
+	IBCChannelConnect      = "ibc_channel_connect"
+	IBCChannelClose        = "ibc_channel_close"
+	IBCPacketReceive       = "ibc_packet_receive"
+	IBCPacketAck           = "ibc_packet_ack"
+	IBCPacketTimeout       = "ibc_packet_timeout"
+	IBCSourceCallback      = "ibc_source_callback"
+	IBCDestinationCallback = "ibc_destination_callback"
+)
+
+// Op kinds
+const (
+	OpGet          = "db_get"
+	OpSet          = "db_set"
+	OpDelete       = "db_delete"
+	OpScan         = "db_scan"
+	OpQuery        = "query"
+	OpQueryGas     = "query_gas"
+	OpHumanize     = "humanize_address"
+	OpCanonicalize = "canonicalize_address"
+	OpValidate     = "validate_address"
+	OpGasConsumed  = "gas_consumed"
+)
+
+// Recording is everything needed to replay a single contract call deterministically.
+type Recording struct {
+	Entrypoint string         `json:"entrypoint"`
+	Checksum   types.Checksum `json:"checksum"`
+	Env        []byte         `json:"env"`
+	// Info is the message info, which is only set for entrypoints that take one
+	Info     []byte `json:"info,omitempty"`
+	Msg      []byte `json:"msg"`
+	GasLimit uint64 `json:"gas_limit"`
+	// Ops are all calls to the store, API, querier and gas meter in the order they happened
+	Ops []*Op `json:"ops"`
+
+	// Result is the serialized contract result, which is empty if Error is set
+	Result    []byte          `json:"result"`
+	Error     string          `json:"error,omitempty"`
+	GasReport types.GasReport `json:"gas_report"`
+}
+
+// Op is a single call to the store, API, querier or gas meter and its result.
+// Which fields are used depends on the Kind.
+type Op struct {
+	Kind string `json:"kind"`
+
+	// Key and Value are used by db_get, db_set and db_delete. Value is nil if the key was not found.
+	Key   []byte `json:"key,omitempty"`
+	Value []byte `json:"value"`
+
+	// Start, End, Reverse, Entries and Exhausted are used by db_scan. Entries are all entries
+	// the iterator returned and Exhausted is true if the iterator was iterated until the end.
+	Start     []byte  `json:"start,omitempty"`
+	End       []byte  `json:"end,omitempty"`
+	Reverse   bool    `json:"reverse,omitempty"`
+	Entries   []Entry `json:"entries,omitempty"`
+	Exhausted bool    `json:"exhausted,omitempty"`
+
+	// Request and GasLimit are used by query. Its result is in Response, SystemError or Error.
+	Request  *types.QueryRequest `json:"request,omitempty"`
+	GasLimit uint64              `json:"gas_limit,omitempty"`
+	Response []byte              `json:"response,omitempty"`
+
+	// Address, Canonical and Cost are used by the address functions
+	Address   string `json:"address,omitempty"`
+	Canonical []byte `json:"canonical,omitempty"`
+	Cost      uint64 `json:"cost,omitempty"`
+
+	// Gas is the result of query_gas and gas_consumed
+	Gas uint64 `json:"gas,omitempty"`
+
+	// SystemError and Error are set if the call failed
+	SystemError *types.SystemError `json:"system_error,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// Entry is a key-value pair returned by an iterator
+type Entry struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// Writes returns all db_set and db_delete ops in the order they happened.
+func (r *Recording) Writes() []*Op {
+	var res []*Op
+	for _, op := range r.Ops {
+		if op.Kind == OpSet || op.Kind == OpDelete {
+			res = append(res, op)
+		}
+	}
+	return res
+}
+
+// WriteFile writes the recording as JSON to the given file.
+func (r *Recording) WriteFile(path string) error {
+	bz, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, bz, 0o644)
+}
+
+// ReadFile reads a recording written by WriteFile.
+func ReadFile(path string) (*Recording, error) {
+	bz, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var r Recording
+	if err := json.Unmarshal(bz, &r); err != nil {
+		return nil, fmt.Errorf("cannot parse recording %s: %w", path, err)
+	}
+	return &r, nil
+}
+
+// opError converts an error returned by a callback into the fields of an op
+func opError(op *Op, err error) {
+	if err == nil {
+		return
+	}
+	if syserr := types.ToSystemError(err); syserr != nil {
+		op.SystemError = syserr
+	} else {
+		op.Error = err.Error()
+	}
+}
+
+// err restores the error recorded by opError
+func (op *Op) err() error {
+	switch {
+	case op.SystemError != nil:
+		return op.SystemError
+	case op.Error != "":
+		return errors.New(op.Error)
+	default:
+		return nil
+	}
+}
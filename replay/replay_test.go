@@ -0,0 +1,225 @@
+package replay
+
+import (
+	"bytes"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	wasmvmtesting "github.com/CosmWasm/wasmvm/v2/testing"
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// contract simulates the callbacks a contract call makes
+type contract func(store types.KVStore, api types.GoAPI, querier types.Querier, meter types.GasMeter) ([]byte, error)
+
+func counter(store types.KVStore, api types.GoAPI, querier types.Querier, meter types.GasMeter) ([]byte, error) {
+	before := meter.GasConsumed()
+	count := store.Get([]byte("count"))
+	if count == nil {
+		count = []byte{0}
+	}
+	store.Set([]byte("count"), []byte{count[0] + 1})
+	_ = meter.GasConsumed() - before
+
+	it := store.Iterator([]byte("item/"), []byte("item0"))
+	var items []byte
+	for ; it.Valid(); it.Next() {
+		items = append(items, it.Value()...)
+	}
+	it.Close()
+	// an iterator that is not iterated until the end
+	rit := store.ReverseIterator(nil, nil)
+	last := rit.Key()
+	rit.Close()
+
+	store.Delete([]byte("item/a"))
+	if _, _, err := api.CanonicalizeAddress(strings.Repeat("a", 100)); err == nil {
+		return nil, errors.New("expected invalid address")
+	}
+	canon, _, err := api.CanonicalizeAddress(wasmvmtesting.MockContractAddr)
+	if err != nil {
+		return nil, err
+	}
+	if _, _, err := api.HumanizeAddress(canon); err != nil {
+		return nil, err
+	}
+	if _, err := api.ValidateAddress(wasmvmtesting.MockContractAddr); err != nil {
+		return nil, err
+	}
+	res, err := querier.Query(types.QueryRequest{Bank: &types.BankQuery{Balance: &types.BalanceQuery{
+		Address: wasmvmtesting.MockContractAddr,
+		Denom:   "atom",
+	}}}, 1000)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := querier.Query(types.QueryRequest{Wasm: &types.WasmQuery{ContractInfo: &types.ContractInfoQuery{ContractAddr: "missing"}}}, 1000); err == nil {
+		return nil, errors.New("expected missing contract")
+	}
+	_ = querier.GasConsumed()
+	return append(append(res, items...), last...), nil
+}
+
+func record(t *testing.T, c contract) *Recording {
+	t.Helper()
+	store := wasmvmtesting.NewLookup(wasmvmtesting.NewMockGasMeter(1 << 62))
+	store.Set([]byte("item/a"), []byte("A"))
+	store.Set([]byte("item/b"), []byte("B"))
+	store.Set([]byte("other"), []byte("O"))
+	querier := wasmvmtesting.DefaultQuerier(wasmvmtesting.MockContractAddr, types.Array[types.Coin]{{Denom: "atom", Amount: "100"}})
+	meter := wasmvmtesting.NewMockGasMeter(1 << 62)
+
+	r := NewRecorder(Execute, types.Checksum(bytes.Repeat([]byte{0xab}, 32)), []byte(`{"env":1}`), []byte(`{"info":1}`), []byte(`{}`), 5000)
+	res, err := c(r.KVStore(store), r.GoAPI(*wasmvmtesting.NewMockAPI()), r.Querier(querier), r.GasMeter(meter))
+	return r.Finish(res, types.GasReport{Limit: 5000, Remaining: 4000, UsedInternally: 1000}, err)
+}
+
+func play(t *testing.T, rec *Recording, c contract) (*Recording, error) {
+	t.Helper()
+	p := NewPlayer(rec)
+	res, err := func() (res []byte, err error) {
+		// callback panics are turned into errors by the VM
+		defer func() {
+			if r := recover(); r != nil {
+				err = r.(error)
+			}
+		}()
+		return c(p.KVStore(), p.GoAPI(), p.Querier(), p.GasMeter())
+	}()
+	return p.Finish(res, types.GasReport{Limit: 5000, Remaining: 4000, UsedInternally: 1000}, err)
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	rec := record(t, counter)
+	require.Empty(t, rec.Error)
+	assert.Equal(t, []*Op{
+		{Kind: OpSet, Key: []byte("count"), Value: []byte{1}},
+		{Kind: OpDelete, Key: []byte("item/a")},
+	}, rec.Writes())
+
+	// roundtrip through a file
+	path := filepath.Join(t.TempDir(), "recording.json")
+	require.NoError(t, rec.WriteFile(path))
+	loaded, err := ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, rec.Entrypoint, loaded.Entrypoint)
+	assert.Equal(t, rec.Checksum, loaded.Checksum)
+
+	replayed, err := play(t, loaded, counter)
+	require.NoError(t, err)
+	assert.Empty(t, Diff(loaded, replayed))
+	assert.Equal(t, rec.Result, replayed.Result)
+}
+
+func TestReplayDivergence(t *testing.T) {
+	rec := record(t, counter)
+
+	specs := map[string]struct {
+		contract contract
+		expErr   string
+	}{
+		"different read": {
+			contract: func(store types.KVStore, api types.GoAPI, querier types.Querier, meter types.GasMeter) ([]byte, error) {
+				meter.GasConsumed()
+				store.Get([]byte("counter"))
+				return nil, nil
+			},
+			expErr: "replay diverged at op 1: expected db_get(636F756E74), got db_get(636F756E746572)",
+		},
+		"missing ops": {
+			contract: func(store types.KVStore, api types.GoAPI, querier types.Querier, meter types.GasMeter) ([]byte, error) {
+				meter.GasConsumed()
+				return nil, nil
+			},
+			expErr: "replay diverged at op 1: expected db_get(636F756E74), got end of call",
+		},
+		"iterating further": {
+			contract: func(store types.KVStore, api types.GoAPI, querier types.Querier, meter types.GasMeter) ([]byte, error) {
+				meter.GasConsumed()
+				store.Get([]byte("count"))
+				store.Set([]byte("count"), []byte{1})
+				meter.GasConsumed()
+				it := store.Iterator([]byte("item/"), []byte("item0"))
+				for ; it.Valid(); it.Next() {
+				}
+				it = store.ReverseIterator(nil, nil)
+				it.Next()
+				it.Valid()
+				return nil, nil
+			},
+			expErr: "replay diverged at op 5: expected iteration over 1 entries, got iteration past the recorded entries",
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			replayed, err := play(t, rec, spec.contract)
+			require.Error(t, err)
+			var divergence DivergenceError
+			require.ErrorAs(t, err, &divergence)
+			assert.Equal(t, spec.expErr, err.Error())
+			assert.NotEmpty(t, Diff(rec, replayed))
+		})
+	}
+}
+
+// changedWriteStore appends 0xff to all values written
+type changedWriteStore struct {
+	types.KVStore
+}
+
+func (s changedWriteStore) Set(key, value []byte) {
+	s.KVStore.Set(key, append(bytes.Clone(value), 0xff))
+}
+
+func TestReplayChangedWrite(t *testing.T) {
+	rec := record(t, counter)
+
+	// a call writing a different value is replayed to the end and the difference is reported by Diff
+	replayed, err := play(t, rec, func(store types.KVStore, api types.GoAPI, querier types.Querier, meter types.GasMeter) ([]byte, error) {
+		return counter(changedWriteStore{store}, api, querier, meter)
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []*Op{
+		{Kind: OpSet, Key: []byte("count"), Value: []byte{1, 0xff}},
+		{Kind: OpDelete, Key: []byte("item/a")},
+	}, replayed.Writes())
+	assert.Equal(t, []string{
+		"write 0: expected set(636F756E74, 01), got set(636F756E74, 01FF)",
+	}, Diff(rec, replayed))
+}
+
+func TestDiff(t *testing.T) {
+	a := &Recording{
+		Ops: []*Op{
+			{Kind: OpGet, Key: []byte{1}},
+			{Kind: OpSet, Key: []byte{1}, Value: []byte{2}},
+			{Kind: OpDelete, Key: []byte{3}},
+		},
+		Result:    []byte(`{"ok":{}}`),
+		GasReport: types.GasReport{UsedInternally: 10, Remaining: 5},
+	}
+	assert.Empty(t, Diff(a, a))
+
+	b := &Recording{
+		Ops: []*Op{
+			{Kind: OpSet, Key: []byte{1}, Value: []byte{3}},
+			{Kind: OpDelete, Key: []byte{3}},
+			{Kind: OpDelete, Key: []byte{4}},
+		},
+		Error:     "out of gas",
+		GasReport: types.GasReport{UsedInternally: 15},
+	}
+	assert.Equal(t, []string{
+		"write 0: expected set(01, 02), got set(01, 03)",
+		"write 2: expected none, got delete(04)",
+		"gas used internally: expected 10, got 15",
+		"gas remaining: expected 5, got 0",
+		`error: expected "", got "out of gas"`,
+		`result: expected {"ok":{}}, got `,
+	}, Diff(a, b))
+}
@@ -0,0 +1,245 @@
+package store
+
+import (
+	"bytes"
+	"sync"
+
+	"github.com/google/btree"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// bTreeDegree is the degree of the B-tree holding the dirty entries of a CacheKVStore
+const bTreeDegree = 32
+
+// cacheEntry is a dirty entry of a CacheKVStore. A nil value marks a deletion.
+type cacheEntry struct {
+	key   []byte
+	value []byte
+}
+
+// Less implements btree.Item.
+func (e *cacheEntry) Less(other btree.Item) bool {
+	return bytes.Compare(e.key, other.(*cacheEntry).key) == -1
+}
+
+// CacheKVStore is a types.KVStore that buffers all writes to an underlying store in memory.
+// The buffered writes are applied to the underlying store by Write or dropped by Discard.
+// This allows running a contract call in a transaction that is only committed on success.
+//
+// The underlying store must not be modified while the CacheKVStore has uncommitted writes.
+// Branch creates a CacheKVStore on top of another one to nest transactions.
+type CacheKVStore struct {
+	mtx    sync.Mutex
+	parent types.KVStore
+	dirty  *btree.BTree
+}
+
+var _ types.KVStore = (*CacheKVStore)(nil)
+
+// NewCacheKVStore creates a CacheKVStore buffering writes to parent.
+func NewCacheKVStore(parent types.KVStore) *CacheKVStore {
+	return &CacheKVStore{parent: parent, dirty: btree.New(bTreeDegree)}
+}
+
+// Branch creates a CacheKVStore on top of this store. Writing the branch applies its changes
+// to this store only.
+func (s *CacheKVStore) Branch() *CacheKVStore {
+	return NewCacheKVStore(s)
+}
+
+func (s *CacheKVStore) Get(key []byte) []byte {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	if e := s.dirty.Get(&cacheEntry{key: key}); e != nil {
+		return e.(*cacheEntry).value
+	}
+	return s.parent.Get(key)
+}
+
+// Set stores a value. It panics if value is nil.
+func (s *CacheKVStore) Set(key, value []byte) {
+	if value == nil {
+		panic("value must not be nil")
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.dirty.ReplaceOrInsert(&cacheEntry{key: bytes.Clone(key), value: bytes.Clone(value)})
+}
+
+func (s *CacheKVStore) Delete(key []byte) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.dirty.ReplaceOrInsert(&cacheEntry{key: bytes.Clone(key)})
+}
+
+// Write applies all buffered writes to the underlying store in key order and resets the cache.
+func (s *CacheKVStore) Write() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.dirty.Ascend(func(i btree.Item) bool {
+		e := i.(*cacheEntry)
+		if e.value == nil {
+			s.parent.Delete(e.key)
+		} else {
+			s.parent.Set(e.key, e.value)
+		}
+		return true
+	})
+	s.dirty = btree.New(bTreeDegree)
+}
+
+// Discard drops all buffered writes.
+func (s *CacheKVStore) Discard() {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	s.dirty = btree.New(bTreeDegree)
+}
+
+func (s *CacheKVStore) Iterator(start, end []byte) types.Iterator {
+	return s.newIterator(start, end, false)
+}
+
+func (s *CacheKVStore) ReverseIterator(start, end []byte) types.Iterator {
+	return s.newIterator(start, end, true)
+}
+
+// newIterator merges the dirty entries in the domain with an iterator of the underlying store.
+// The dirty entries are copied, so the store can be modified while iterating.
+func (s *CacheKVStore) newIterator(start, end []byte, reverse bool) types.Iterator {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	var entries []*cacheEntry
+	collect := func(i btree.Item) bool {
+		entries = append(entries, i.(*cacheEntry))
+		return true
+	}
+	if start == nil {
+		s.dirty.Ascend(collect)
+	} else {
+		s.dirty.AscendGreaterOrEqual(&cacheEntry{key: start}, collect)
+	}
+	if end != nil {
+		n := 0
+		for n < len(entries) && bytes.Compare(entries[n].key, end) == -1 {
+			n++
+		}
+		entries = entries[:n]
+	}
+
+	var parent types.Iterator
+	if reverse {
+		for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+			entries[i], entries[j] = entries[j], entries[i]
+		}
+		parent = s.parent.ReverseIterator(start, end)
+	} else {
+		parent = s.parent.Iterator(start, end)
+	}
+
+	it := &cacheMergeIterator{parent: parent, entries: entries, reverse: reverse, start: start, end: end}
+	it.skip()
+	return it
+}
+
+// cacheMergeIterator iterates over the union of the dirty entries and the parent iterator.
+// Dirty entries shadow parent entries with the same key and deleted entries are skipped.
+type cacheMergeIterator struct {
+	parent     types.Iterator
+	entries    []*cacheEntry
+	reverse    bool
+	start, end []byte
+}
+
+var _ types.Iterator = (*cacheMergeIterator)(nil)
+
+// compare compares two keys in iteration order
+func (it *cacheMergeIterator) compare(a, b []byte) int {
+	if it.reverse {
+		return bytes.Compare(b, a)
+	}
+	return bytes.Compare(a, b)
+}
+
+// useEntry returns true if the current item is the next dirty entry and not the parent's item
+func (it *cacheMergeIterator) useEntry() bool {
+	if len(it.entries) == 0 {
+		return false
+	}
+	return !it.parent.Valid() || it.compare(it.entries[0].key, it.parent.Key()) <= 0
+}
+
+// skip moves past shadowed parent items and deleted entries until the current item is valid
+// or the iterator is exhausted.
+func (it *cacheMergeIterator) skip() {
+	for len(it.entries) > 0 {
+		e := it.entries[0]
+		if it.parent.Valid() {
+			c := it.compare(e.key, it.parent.Key())
+			if c > 0 {
+				return
+			}
+			if c == 0 {
+				it.parent.Next()
+			}
+		}
+		if e.value != nil {
+			return
+		}
+		it.entries = it.entries[1:]
+	}
+}
+
+func (it *cacheMergeIterator) Domain() ([]byte, []byte) {
+	return it.start, it.end
+}
+
+func (it *cacheMergeIterator) Valid() bool {
+	return len(it.entries) > 0 || it.parent.Valid()
+}
+
+func (it *cacheMergeIterator) Next() {
+	if !it.Valid() {
+		panic("iterator is invalid")
+	}
+	if it.useEntry() {
+		it.entries = it.entries[1:]
+	} else {
+		it.parent.Next()
+	}
+	it.skip()
+}
+
+func (it *cacheMergeIterator) Key() []byte {
+	if !it.Valid() {
+		panic("iterator is invalid")
+	}
+	if it.useEntry() {
+		return it.entries[0].key
+	}
+	return it.parent.Key()
+}
+
+func (it *cacheMergeIterator) Value() []byte {
+	if !it.Valid() {
+		panic("iterator is invalid")
+	}
+	if it.useEntry() {
+		return it.entries[0].value
+	}
+	return it.parent.Value()
+}
+
+func (it *cacheMergeIterator) Error() error {
+	return it.parent.Error()
+}
+
+func (it *cacheMergeIterator) Close() error {
+	return it.parent.Close()
+}
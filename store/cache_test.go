@@ -0,0 +1,226 @@
+package store
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+func entries(it types.Iterator) []string {
+	defer it.Close()
+	var res []string
+	for ; it.Valid(); it.Next() {
+		res = append(res, string(it.Key())+"="+string(it.Value()))
+	}
+	return res
+}
+
+func TestCacheKVStoreWriteDiscard(t *testing.T) {
+	parent := newParent(t)
+	parent.Set([]byte("a"), []byte("1"))
+	parent.Set([]byte("b"), []byte("2"))
+
+	cache := NewCacheKVStore(parent)
+	cache.Set([]byte("a"), []byte("10"))
+	cache.Delete([]byte("b"))
+	cache.Set([]byte("c"), []byte("3"))
+
+	assert.Equal(t, []byte("10"), cache.Get([]byte("a")))
+	assert.Nil(t, cache.Get([]byte("b")))
+	assert.Equal(t, []byte("3"), cache.Get([]byte("c")))
+	// parent is untouched
+	assert.Equal(t, []string{"a=1", "b=2"}, entries(parent.Iterator(nil, nil)))
+
+	cache.Discard()
+	assert.Equal(t, []string{"a=1", "b=2"}, entries(cache.Iterator(nil, nil)))
+
+	cache.Set([]byte("a"), []byte("10"))
+	cache.Delete([]byte("b"))
+	cache.Set([]byte("c"), []byte("3"))
+	cache.Write()
+	assert.Equal(t, []string{"a=10", "c=3"}, entries(parent.Iterator(nil, nil)))
+	assert.Equal(t, []string{"a=10", "c=3"}, entries(cache.Iterator(nil, nil)))
+
+	// a second write is a no-op
+	parent.Set([]byte("d"), []byte("4"))
+	cache.Write()
+	assert.Equal(t, []string{"a=10", "c=3", "d=4"}, entries(parent.Iterator(nil, nil)))
+}
+
+func TestCacheKVStoreBranch(t *testing.T) {
+	parent := newParent(t)
+	parent.Set([]byte("a"), []byte("1"))
+
+	outer := NewCacheKVStore(parent)
+	outer.Set([]byte("b"), []byte("2"))
+
+	failed := outer.Branch()
+	failed.Set([]byte("c"), []byte("3"))
+	failed.Delete([]byte("a"))
+	assert.Equal(t, []string{"b=2", "c=3"}, entries(failed.Iterator(nil, nil)))
+	failed.Discard()
+
+	succeeded := outer.Branch()
+	succeeded.Set([]byte("d"), []byte("4"))
+	succeeded.Write()
+
+	assert.Equal(t, []string{"a=1", "b=2", "d=4"}, entries(outer.Iterator(nil, nil)))
+	assert.Equal(t, []string{"a=1"}, entries(parent.Iterator(nil, nil)))
+
+	outer.Write()
+	assert.Equal(t, []string{"a=1", "b=2", "d=4"}, entries(parent.Iterator(nil, nil)))
+}
+
+func TestCacheKVStoreIterator(t *testing.T) {
+	parent := newParent(t)
+	for _, k := range []string{"a", "c", "e", "g"} {
+		parent.Set([]byte(k), []byte("p"))
+	}
+	cache := NewCacheKVStore(parent)
+	cache.Set([]byte("b"), []byte("c"))
+	cache.Set([]byte("c"), []byte("c"))
+	cache.Delete([]byte("e"))
+	cache.Delete([]byte("f"))
+	cache.Set([]byte("h"), []byte("c"))
+
+	specs := map[string]struct {
+		start, end []byte
+		exp        []string
+	}{
+		"full range":      {exp: []string{"a=p", "b=c", "c=c", "g=p", "h=c"}},
+		"start only":      {start: []byte("c"), exp: []string{"c=c", "g=p", "h=c"}},
+		"end only":        {end: []byte("g"), exp: []string{"a=p", "b=c", "c=c"}},
+		"bounded":         {start: []byte("b"), end: []byte("h"), exp: []string{"b=c", "c=c", "g=p"}},
+		"deleted only":    {start: []byte("d"), end: []byte("g"), exp: nil},
+		"between entries": {start: []byte("bb"), end: []byte("cc"), exp: []string{"c=c"}},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			it := cache.Iterator(spec.start, spec.end)
+			start, end := it.Domain()
+			assert.Equal(t, spec.start, start)
+			assert.Equal(t, spec.end, end)
+			assert.Equal(t, spec.exp, entries(it))
+
+			var reversed []string
+			for i := len(spec.exp) - 1; i >= 0; i-- {
+				reversed = append(reversed, spec.exp[i])
+			}
+			assert.Equal(t, reversed, entries(cache.ReverseIterator(spec.start, spec.end)))
+		})
+	}
+}
+
+func TestCacheKVStoreWriteWhileIterating(t *testing.T) {
+	cache := NewCacheKVStore(newParent(t))
+	cache.Set([]byte("a"), []byte("1"))
+	cache.Set([]byte("b"), []byte("2"))
+
+	it := cache.Iterator(nil, nil)
+	defer it.Close()
+	require.True(t, it.Valid())
+	cache.Set([]byte("b"), []byte("changed"))
+	cache.Set([]byte("c"), []byte("3"))
+	it.Next()
+	require.True(t, it.Valid())
+	// the iterator works on the entries at creation time
+	assert.Equal(t, []byte("2"), it.Value())
+	it.Next()
+	assert.False(t, it.Valid())
+	assert.Panics(t, it.Next)
+}
+
+func TestCacheKVStoreSetNil(t *testing.T) {
+	cache := NewCacheKVStore(newParent(t))
+	assert.Panics(t, func() { cache.Set([]byte("a"), nil) })
+}
+
+// TestCacheKVStoreRandom compares nested cache stores to a simple map based model
+func TestCacheKVStoreRandom(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randKey := func() []byte {
+		return []byte{byte(r.Intn(4)), byte(r.Intn(8))}
+	}
+
+	parent := newParent(t)
+	model := map[string]string{}
+	stores := []*CacheKVStore{NewCacheKVStore(parent)}
+	models := []map[string]string{clone(model)}
+
+	for i := 0; i < 2000; i++ {
+		s, m := stores[len(stores)-1], models[len(models)-1]
+		switch op := r.Intn(10); {
+		case op < 4:
+			k, v := randKey(), fmt.Sprint(i)
+			s.Set(k, []byte(v))
+			m[string(k)] = v
+		case op < 6:
+			k := randKey()
+			s.Delete(k)
+			delete(m, string(k))
+		case op < 7:
+			stores = append(stores, s.Branch())
+			models = append(models, clone(m))
+		case op < 8 && len(stores) > 1:
+			s.Write()
+			stores, models = stores[:len(stores)-1], models[:len(models)-1]
+			models[len(models)-1] = m
+		case op < 9 && len(stores) > 1:
+			s.Discard()
+			stores, models = stores[:len(stores)-1], models[:len(models)-1]
+		default:
+			start, end := randKey(), randKey()
+			if bytes.Compare(start, end) > 0 {
+				start, end = end, start
+			}
+			if r.Intn(3) == 0 {
+				start = nil
+			}
+			if r.Intn(3) == 0 {
+				end = nil
+			}
+			exp := modelEntries(m, start, end)
+			require.Equal(t, exp, entries(s.Iterator(start, end)), "iteration %d", i)
+			for a, b := 0, len(exp)-1; a < b; a, b = a+1, b-1 {
+				exp[a], exp[b] = exp[b], exp[a]
+			}
+			require.Equal(t, exp, entries(s.ReverseIterator(start, end)), "iteration %d", i)
+		}
+	}
+
+	for len(stores) > 0 {
+		stores[len(stores)-1].Write()
+		stores = stores[:len(stores)-1]
+	}
+	assert.Equal(t, modelEntries(models[len(models)-1], nil, nil), entries(parent.Iterator(nil, nil)))
+}
+
+func clone(m map[string]string) map[string]string {
+	res := make(map[string]string, len(m))
+	for k, v := range m {
+		res[k] = v
+	}
+	return res
+}
+
+func modelEntries(m map[string]string, start, end []byte) []string {
+	var keys []string
+	for k := range m {
+		if (start == nil || k >= string(start)) && (end == nil || k < string(end)) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	var res []string
+	for _, k := range keys {
+		res = append(res, k+"="+m[k])
+	}
+	return res
+}
@@ -0,0 +1,121 @@
+package store
+
+import (
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// GasMeter is a gas meter that can be charged. It is implemented by the Cosmos SDK gas meter,
+// so the meter passed to the VM can usually be used here as well.
+// Like the Cosmos SDK gas meter, ConsumeGas is expected to panic when running out of gas.
+type GasMeter interface {
+	types.GasMeter
+	ConsumeGas(amount types.Gas, descriptor string)
+}
+
+// GasConfig defines the gas costs of storage access.
+type GasConfig struct {
+	DeleteCost       types.Gas
+	ReadCostFlat     types.Gas
+	ReadCostPerByte  types.Gas
+	WriteCostFlat    types.Gas
+	WriteCostPerByte types.Gas
+	IterNextCostFlat types.Gas
+}
+
+// DefaultGasConfig returns the costs of the Cosmos SDK's KVGasConfig in SDK gas.
+func DefaultGasConfig() GasConfig {
+	return GasConfig{
+		DeleteCost:       1000,
+		ReadCostFlat:     1000,
+		ReadCostPerByte:  3,
+		WriteCostFlat:    2000,
+		WriteCostPerByte: 30,
+		IterNextCostFlat: 30,
+	}
+}
+
+// Gas consumption descriptors as in the Cosmos SDK
+const (
+	GasDeleteDesc           = "Delete"
+	GasReadCostFlatDesc     = "ReadFlat"
+	GasReadPerByteDesc      = "ReadPerByte"
+	GasWriteCostFlatDesc    = "WriteFlat"
+	GasWritePerByteDesc     = "WritePerByte"
+	GasIterNextCostFlatDesc = "IterNextFlat"
+)
+
+// GasStore is a types.KVStore that charges gas for all operations on an underlying store.
+type GasStore struct {
+	parent types.KVStore
+	meter  GasMeter
+	config GasConfig
+}
+
+var _ types.MeteredKVStore = GasStore{}
+
+// NewGasStore creates a store that charges gas to meter according to config for all access to parent.
+func NewGasStore(parent types.KVStore, meter GasMeter, config GasConfig) GasStore {
+	return GasStore{parent: parent, meter: meter, config: config}
+}
+
+// Unmetered returns the underlying store.
+func (s GasStore) Unmetered() types.KVStore {
+	return s.parent
+}
+
+func (s GasStore) Get(key []byte) []byte {
+	s.meter.ConsumeGas(s.config.ReadCostFlat, GasReadCostFlatDesc)
+	value := s.parent.Get(key)
+	s.meter.ConsumeGas(s.config.ReadCostPerByte*types.Gas(len(key)), GasReadPerByteDesc)
+	s.meter.ConsumeGas(s.config.ReadCostPerByte*types.Gas(len(value)), GasReadPerByteDesc)
+	return value
+}
+
+func (s GasStore) Set(key, value []byte) {
+	s.meter.ConsumeGas(s.config.WriteCostFlat, GasWriteCostFlatDesc)
+	s.meter.ConsumeGas(s.config.WriteCostPerByte*types.Gas(len(key)), GasWritePerByteDesc)
+	s.meter.ConsumeGas(s.config.WriteCostPerByte*types.Gas(len(value)), GasWritePerByteDesc)
+	s.parent.Set(key, value)
+}
+
+func (s GasStore) Delete(key []byte) {
+	s.meter.ConsumeGas(s.config.DeleteCost, GasDeleteDesc)
+	s.parent.Delete(key)
+}
+
+func (s GasStore) Iterator(start, end []byte) types.Iterator {
+	return s.newIterator(s.parent.Iterator(start, end))
+}
+
+func (s GasStore) ReverseIterator(start, end []byte) types.Iterator {
+	return s.newIterator(s.parent.ReverseIterator(start, end))
+}
+
+func (s GasStore) newIterator(parent types.Iterator) types.Iterator {
+	it := &gasIterator{Iterator: parent, meter: s.meter, config: s.config}
+	it.consumeSeekGas()
+	return it
+}
+
+// gasIterator charges gas for every step of the parent iterator
+type gasIterator struct {
+	types.Iterator
+	meter  GasMeter
+	config GasConfig
+}
+
+func (it *gasIterator) Next() {
+	it.Iterator.Next()
+	it.consumeSeekGas()
+}
+
+// consumeSeekGas charges for reading the current entry, if any
+func (it *gasIterator) consumeSeekGas() {
+	if !it.Valid() {
+		return
+	}
+	key, value := it.Key(), it.Value()
+	it.meter.ConsumeGas(it.config.ReadCostPerByte*types.Gas(len(key)), GasReadPerByteDesc)
+	it.meter.ConsumeGas(it.config.ReadCostPerByte*types.Gas(len(value)), GasReadPerByteDesc)
+	it.meter.ConsumeGas(it.config.IterNextCostFlat, GasIterNextCostFlatDesc)
+}
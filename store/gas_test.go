@@ -0,0 +1,85 @@
+package store
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	wasmvmtesting "github.com/CosmWasm/wasmvm/v2/testing"
+)
+
+func TestGasStore(t *testing.T) {
+	config := GasConfig{
+		DeleteCost:       7,
+		ReadCostFlat:     100,
+		ReadCostPerByte:  3,
+		WriteCostFlat:    200,
+		WriteCostPerByte: 5,
+		IterNextCostFlat: 11,
+	}
+	meter := wasmvmtesting.NewMockGasMeter(1 << 62)
+	s := NewGasStore(newParent(t), meter, config)
+
+	charged := func(f func()) uint64 {
+		before := meter.GasConsumed()
+		f()
+		return meter.GasConsumed() - before
+	}
+
+	assert.Equal(t, uint64(200+5*3+5*4), charged(func() { s.Set([]byte("foo"), []byte("barz")) }))
+	assert.Equal(t, uint64(100+3*3+3*4), charged(func() { s.Get([]byte("foo")) }))
+	assert.Equal(t, uint64(100+3*7), charged(func() { s.Get([]byte("missing")) }))
+	assert.Equal(t, uint64(7), charged(func() { s.Delete([]byte("foo")) }))
+
+	s.Set([]byte("a"), []byte("12"))
+	s.Set([]byte("bb"), []byte("3"))
+	entry := func(k, v string) uint64 { return 11 + 3*uint64(len(k)+len(v)) }
+
+	var it interface{ Next() }
+	assert.Equal(t, entry("a", "12"), charged(func() {
+		iter := s.Iterator(nil, nil)
+		t.Cleanup(func() { iter.Close() })
+		it = iter
+	}))
+	assert.Equal(t, entry("bb", "3"), charged(it.Next))
+	assert.Equal(t, uint64(0), charged(it.Next), "no charge past the end")
+
+	assert.Equal(t, entry("bb", "3"), charged(func() {
+		iter := s.ReverseIterator(nil, nil)
+		iter.Close()
+	}))
+}
+
+func TestGasStoreOutOfGas(t *testing.T) {
+	meter := wasmvmtesting.NewMockGasMeter(1000)
+	parent := newParent(t)
+	s := NewGasStore(parent, meter, DefaultGasConfig())
+
+	require.PanicsWithValue(t, wasmvmtesting.ErrorOutOfGas{Descriptor: GasWriteCostFlatDesc}, func() {
+		s.Set([]byte("foo"), []byte("bar"))
+	})
+	assert.Nil(t, parent.Get([]byte("foo")), "nothing must be written when running out of gas")
+}
+
+func TestGasStoreUnmetered(t *testing.T) {
+	meter := wasmvmtesting.NewMockGasMeter(1 << 62)
+	parent := newParent(t, []byte("a"), []byte("b"))
+	s := NewGasStore(parent, meter, DefaultGasConfig())
+
+	unmetered := s.Unmetered()
+	assert.Equal(t, [][]byte{[]byte("a"), []byte("b")}, collect(unmetered.Iterator(nil, nil)))
+	assert.Equal(t, []byte("value"), unmetered.Get([]byte("a")))
+	assert.Equal(t, uint64(0), meter.GasConsumed())
+}
+
+func TestGasStoreWithPrefix(t *testing.T) {
+	meter := wasmvmtesting.NewMockGasMeter(1 << 62)
+	parent := newParent(t)
+	s := NewGasStore(NewPrefixStore(parent, []byte("contract/")), meter, DefaultGasConfig())
+
+	s.Set([]byte("k"), []byte("v"))
+	assert.Equal(t, []byte("v"), parent.Get([]byte("contract/k")))
+	// gas is charged on the unprefixed key
+	assert.Equal(t, uint64(2000+30+30), meter.GasConsumed())
+}
@@ -0,0 +1,97 @@
+// Package store provides types.KVStore adapters commonly used by embedders of wasmvm, e.g. to give
+// every contract its own namespace in a shared store, to charge gas for storage access or to buffer
+// the writes of a contract call until it succeeded.
+package store
+
+import (
+	"bytes"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// PrefixStore is a types.KVStore that prepends a fixed prefix to all keys of an underlying store.
+// Iterators only return keys with the prefix, which is stripped from the returned keys.
+type PrefixStore struct {
+	parent types.KVStore
+	prefix []byte
+}
+
+var _ types.KVStore = PrefixStore{}
+
+// NewPrefixStore creates a store that operates on all keys of parent starting with prefix.
+func NewPrefixStore(parent types.KVStore, prefix []byte) PrefixStore {
+	return PrefixStore{parent: parent, prefix: bytes.Clone(prefix)}
+}
+
+func (s PrefixStore) key(key []byte) []byte {
+	res := make([]byte, 0, len(s.prefix)+len(key))
+	res = append(res, s.prefix...)
+	return append(res, key...)
+}
+
+func (s PrefixStore) Get(key []byte) []byte {
+	return s.parent.Get(s.key(key))
+}
+
+func (s PrefixStore) Set(key, value []byte) {
+	s.parent.Set(s.key(key), value)
+}
+
+func (s PrefixStore) Delete(key []byte) {
+	s.parent.Delete(s.key(key))
+}
+
+func (s PrefixStore) Iterator(start, end []byte) types.Iterator {
+	pstart, pend := s.domain(start, end)
+	return &prefixIterator{Iterator: s.parent.Iterator(pstart, pend), prefix: s.prefix, start: start, end: end}
+}
+
+func (s PrefixStore) ReverseIterator(start, end []byte) types.Iterator {
+	pstart, pend := s.domain(start, end)
+	return &prefixIterator{Iterator: s.parent.ReverseIterator(pstart, pend), prefix: s.prefix, start: start, end: end}
+}
+
+// domain converts an iterator domain to the domain in the parent store
+func (s PrefixStore) domain(start, end []byte) ([]byte, []byte) {
+	var pstart []byte
+	// with an empty prefix, an unbounded start must stay nil since stores reject empty keys
+	if start != nil || len(s.prefix) > 0 {
+		pstart = s.key(start)
+	}
+	var pend []byte
+	if end == nil {
+		pend = PrefixEnd(s.prefix)
+	} else {
+		pend = s.key(end)
+	}
+	return pstart, pend
+}
+
+// PrefixEnd returns the smallest key that is larger than all keys with the given prefix,
+// or nil if there is none, i.e. if the prefix is empty or only consists of 0xff bytes.
+// It can be used as the exclusive end of an iterator over all keys with the prefix.
+func PrefixEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	return nil
+}
+
+// prefixIterator strips the prefix from the keys of the parent iterator
+type prefixIterator struct {
+	types.Iterator
+	prefix     []byte
+	start, end []byte
+}
+
+func (it *prefixIterator) Domain() ([]byte, []byte) {
+	return it.start, it.end
+}
+
+func (it *prefixIterator) Key() []byte {
+	return it.Iterator.Key()[len(it.prefix):]
+}
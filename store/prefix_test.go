@@ -0,0 +1,165 @@
+package store
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	wasmvmtesting "github.com/CosmWasm/wasmvm/v2/testing"
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+func newParent(t *testing.T, keys ...[]byte) types.KVStore {
+	t.Helper()
+	parent := wasmvmtesting.NewLookup(wasmvmtesting.NewMockGasMeter(1 << 62))
+	for _, k := range keys {
+		parent.Set(k, []byte("value"))
+	}
+	return parent
+}
+
+func collect(it types.Iterator) [][]byte {
+	defer it.Close()
+	var keys [][]byte
+	for ; it.Valid(); it.Next() {
+		keys = append(keys, it.Key())
+	}
+	return keys
+}
+
+func TestPrefixEnd(t *testing.T) {
+	specs := map[string]struct {
+		prefix []byte
+		exp    []byte
+	}{
+		"empty":          {prefix: []byte{}, exp: nil},
+		"nil":            {prefix: nil, exp: nil},
+		"simple":         {prefix: []byte("abc"), exp: []byte("abd")},
+		"trailing 0xff":  {prefix: []byte{1, 0xff, 0xff}, exp: []byte{2}},
+		"only 0xff":      {prefix: []byte{0xff, 0xff}, exp: nil},
+		"inner 0xff":     {prefix: []byte{0xff, 1}, exp: []byte{0xff, 2}},
+		"leading zeroes": {prefix: []byte{0, 0}, exp: []byte{0, 1}},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			prefix := bytes.Clone(spec.prefix)
+			assert.Equal(t, spec.exp, PrefixEnd(spec.prefix))
+			assert.Equal(t, prefix, spec.prefix, "prefix must not be modified")
+		})
+	}
+}
+
+func TestPrefixStoreGetSetDelete(t *testing.T) {
+	parent := newParent(t)
+	s := NewPrefixStore(parent, []byte("p/"))
+
+	s.Set([]byte("foo"), []byte("bar"))
+	assert.Equal(t, []byte("bar"), s.Get([]byte("foo")))
+	assert.Equal(t, []byte("bar"), parent.Get([]byte("p/foo")))
+	assert.Nil(t, parent.Get([]byte("foo")))
+
+	s.Delete([]byte("foo"))
+	assert.Nil(t, s.Get([]byte("foo")))
+	assert.Nil(t, parent.Get([]byte("p/foo")))
+}
+
+func TestPrefixStoreIterator(t *testing.T) {
+	specs := map[string]struct {
+		prefix     []byte
+		start, end []byte
+		exp        [][]byte
+	}{
+		"full range": {
+			prefix: []byte{1},
+			exp:    [][]byte{{}, {0}, {1}, {0xff}, {0xff, 0xff}},
+		},
+		"start only": {
+			prefix: []byte{1},
+			start:  []byte{1},
+			exp:    [][]byte{{1}, {0xff}, {0xff, 0xff}},
+		},
+		"end only": {
+			prefix: []byte{1},
+			end:    []byte{0xff},
+			exp:    [][]byte{{}, {0}, {1}},
+		},
+		"bounded": {
+			prefix: []byte{1},
+			start:  []byte{0},
+			end:    []byte{0xff, 0xff},
+			exp:    [][]byte{{0}, {1}, {0xff}},
+		},
+		"prefix ending with 0xff": {
+			prefix: []byte{1, 0xff},
+			exp:    [][]byte{{}, {0xff}},
+		},
+		"prefix of only 0xff": {
+			prefix: []byte{0xff},
+			exp:    [][]byte{{}, {0xff}, {0xff, 0xff}},
+		},
+		"empty prefix": {
+			prefix: []byte{},
+			start:  []byte{1, 0xff},
+			end:    []byte{2},
+			exp:    [][]byte{{1, 0xff}, {1, 0xff, 0xff}},
+		},
+		"empty prefix full range": {
+			prefix: []byte{},
+			exp: [][]byte{
+				{0}, {0, 0xff},
+				{1}, {1, 0}, {1, 1}, {1, 0xff}, {1, 0xff, 0xff},
+				{2}, {2, 0},
+				{0xff}, {0xff, 0xff}, {0xff, 0xff, 0xff},
+			},
+		},
+		"empty prefix end only": {
+			prefix: nil,
+			end:    []byte{1},
+			exp:    [][]byte{{0}, {0, 0xff}},
+		},
+	}
+	keys := [][]byte{
+		{0}, {0, 0xff},
+		{1}, {1, 0}, {1, 1}, {1, 0xff}, {1, 0xff, 0xff},
+		{2}, {2, 0},
+		{0xff}, {0xff, 0xff}, {0xff, 0xff, 0xff},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			s := NewPrefixStore(newParent(t, keys...), spec.prefix)
+
+			it := s.Iterator(spec.start, spec.end)
+			start, end := it.Domain()
+			assert.Equal(t, spec.start, start)
+			assert.Equal(t, spec.end, end)
+			assert.Equal(t, spec.exp, collect(it))
+
+			var reversed [][]byte
+			for i := len(spec.exp) - 1; i >= 0; i-- {
+				reversed = append(reversed, spec.exp[i])
+			}
+			assert.Equal(t, reversed, collect(s.ReverseIterator(spec.start, spec.end)))
+		})
+	}
+}
+
+func TestPrefixStoreValues(t *testing.T) {
+	parent := newParent(t)
+	parent.Set([]byte("a1"), []byte("one"))
+	parent.Set([]byte("a2"), []byte("two"))
+	parent.Set([]byte("b1"), []byte("other"))
+
+	it := NewPrefixStore(parent, []byte("a")).Iterator(nil, nil)
+	defer it.Close()
+	require.True(t, it.Valid())
+	assert.Equal(t, []byte("1"), it.Key())
+	assert.Equal(t, []byte("one"), it.Value())
+	it.Next()
+	require.True(t, it.Valid())
+	assert.Equal(t, []byte("2"), it.Key())
+	assert.Equal(t, []byte("two"), it.Value())
+	it.Next()
+	assert.False(t, it.Valid())
+}
@@ -0,0 +1,73 @@
+package testing
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// CanonicalLength is the length of canonical addresses created by MockCanonicalizeAddress
+const CanonicalLength = 32
+
+// Gas costs of the mock address functions
+const (
+	CostCanonical uint64 = 440
+	CostHuman     uint64 = 550
+)
+
+// MockCanonicalizeAddress converts a human address of at most CanonicalLength bytes
+// to a canonical address by padding it with zeros.
+func MockCanonicalizeAddress(human string) ([]byte, uint64, error) {
+	if len(human) > CanonicalLength {
+		return nil, 0, fmt.Errorf("human encoding too long")
+	}
+	res := make([]byte, CanonicalLength)
+	copy(res, []byte(human))
+	return res, CostCanonical, nil
+}
+
+// MockHumanizeAddress is the inverse of MockCanonicalizeAddress.
+func MockHumanizeAddress(canon []byte) (string, uint64, error) {
+	if len(canon) != CanonicalLength {
+		return "", 0, fmt.Errorf("wrong canonical length")
+	}
+	cut := CanonicalLength
+	for i, v := range canon {
+		if v == 0 {
+			cut = i
+			break
+		}
+	}
+	human := string(canon[:cut])
+	return human, CostHuman, nil
+}
+
+// MockValidateAddress accepts lowercase addresses that survive a round trip
+// through MockCanonicalizeAddress and MockHumanizeAddress.
+func MockValidateAddress(input string) (gasCost uint64, _ error) {
+	canonicalized, gasCostCanonicalize, err := MockCanonicalizeAddress(input)
+	gasCost += gasCostCanonicalize
+	if err != nil {
+		return gasCost, err
+	}
+	humanized, gasCostHumanize, err := MockHumanizeAddress(canonicalized)
+	gasCost += gasCostHumanize
+	if err != nil {
+		return gasCost, err
+	}
+	if humanized != strings.ToLower(input) {
+		return gasCost, fmt.Errorf("address validation failed")
+	}
+
+	return gasCost, nil
+}
+
+// NewMockAPI returns a GoAPI using the mock address functions.
+func NewMockAPI() *types.GoAPI {
+	return &types.GoAPI{
+		HumanizeAddress:     MockHumanizeAddress,
+		CanonicalizeAddress: MockCanonicalizeAddress,
+		ValidateAddress:     MockValidateAddress,
+	}
+}
@@ -0,0 +1,30 @@
+package testing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMockApi(t *testing.T) {
+	human := "foobar"
+	canon, cost, err := MockCanonicalizeAddress(human)
+	require.NoError(t, err)
+	assert.Equal(t, CanonicalLength, len(canon))
+	assert.Equal(t, CostCanonical, cost)
+
+	recover, cost, err := MockHumanizeAddress(canon)
+	require.NoError(t, err)
+	assert.Equal(t, recover, human)
+	assert.Equal(t, CostHuman, cost)
+}
+
+func TestMockValidateAddress(t *testing.T) {
+	_, err := MockValidateAddress("foobar")
+	require.NoError(t, err)
+	_, err = MockValidateAddress("FooBar")
+	require.EqualError(t, err, "address validation failed")
+	_, err = MockValidateAddress("this-address-is-longer-than-32-bytes")
+	require.EqualError(t, err, "human encoding too long")
+}
@@ -0,0 +1,220 @@
+package testing
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// SmartQueryHandler answers smart queries to a contract
+type SmartQueryHandler func(msg []byte) ([]byte, error)
+
+// Contract is a contract registered in a Chain
+type Contract struct {
+	Info types.ContractInfoResponse
+	// Store is the storage used for raw queries. Raw queries return nothing if it is nil.
+	Store types.KVStore
+	// Smart answers smart queries. Smart queries are not supported if it is nil.
+	Smart SmartQueryHandler
+}
+
+// Chain is an in-memory model of the chain state contracts can query. It answers all
+// QueryRequest variants through the queriers returned by Querier, which use the
+// sub-queriers of MockQuerier.
+// The state can be modified between contract calls using the setter methods.
+// Chain is not safe for concurrent use.
+type Chain struct {
+	bank BankQuerier
+	// staking has validators sorted by address and delegations sorted by delegator and validator
+	staking StakingQuerier
+	// distribution has an entry in Rewards for every delegation
+	distribution DistributionQuerier
+	// channels are sorted by port and channel ID
+	channels []types.IBCChannel
+	wasm     WasmQuerier
+	grpc     GrpcQuerier
+
+	contracts map[string]Contract
+
+	// Custom answers custom queries. Custom queries are not supported if it is nil.
+	Custom CustomQuerier
+}
+
+// NewChain creates an empty Chain with the bonded denom "stake".
+func NewChain() *Chain {
+	c := &Chain{
+		bank: BankQuerier{
+			Balances:      map[string]types.Array[types.Coin]{},
+			DenomMetadata: map[string]types.DenomMetadata{},
+		},
+		staking: StakingQuerier{BondedDenom: "stake"},
+		distribution: DistributionQuerier{
+			WithdrawAddresses: map[string]string{},
+			Rewards:           map[string]map[string][]types.DecCoin{},
+		},
+		wasm: WasmQuerier{
+			Contracts: map[string]types.ContractInfoResponse{},
+			Codes:     map[uint64]types.CodeInfoResponse{},
+		},
+		grpc:      GrpcQuerier{Handlers: map[string]GrpcHandler{}},
+		contracts: map[string]Contract{},
+	}
+	c.wasm.Raw = c.queryRaw
+	c.wasm.Smart = c.querySmart
+	return c
+}
+
+// SetBalance sets all balances of an address.
+func (c *Chain) SetBalance(addr string, coins types.Array[types.Coin]) {
+	dst := make(types.Array[types.Coin], len(coins))
+	copy(dst, coins)
+	c.bank.Balances[addr] = dst
+}
+
+// SetDenomMetadata sets the metadata of the denom metadata.Base.
+func (c *Chain) SetDenomMetadata(metadata types.DenomMetadata) {
+	c.bank.DenomMetadata[metadata.Base] = metadata
+}
+
+// SetBondedDenom sets the staking denom.
+func (c *Chain) SetBondedDenom(denom string) {
+	c.staking.BondedDenom = denom
+}
+
+// AddValidator adds or replaces a validator.
+func (c *Chain) AddValidator(validator types.Validator) {
+	c.staking.Validators = upsertSorted(c.staking.Validators, validator, func(a, b types.Validator) bool {
+		return a.Address < b.Address
+	})
+}
+
+// SetDelegation adds or replaces a delegation. The validator must have been added before.
+func (c *Chain) SetDelegation(delegation types.FullDelegation) error {
+	if !slices.ContainsFunc(c.staking.Validators, func(v types.Validator) bool { return v.Address == delegation.Validator }) {
+		return fmt.Errorf("validator %s not found", delegation.Validator)
+	}
+	c.staking.Delegations = upsertSorted(c.staking.Delegations, delegation, func(a, b types.FullDelegation) bool {
+		return a.Delegator < b.Delegator || (a.Delegator == b.Delegator && a.Validator < b.Validator)
+	})
+	// the validators of a delegator in the distribution module are the validators it delegated to
+	rewards := c.distribution.Rewards
+	if rewards[delegation.Delegator] == nil {
+		rewards[delegation.Delegator] = map[string][]types.DecCoin{}
+	}
+	if _, ok := rewards[delegation.Delegator][delegation.Validator]; !ok {
+		rewards[delegation.Delegator][delegation.Validator] = nil
+	}
+	return nil
+}
+
+// SetWithdrawAddress sets the address rewards of delegator are withdrawn to.
+func (c *Chain) SetWithdrawAddress(delegator, withdrawAddress string) {
+	c.distribution.WithdrawAddresses[delegator] = withdrawAddress
+}
+
+// SetRewards sets the outstanding rewards of a delegation. The delegation must exist.
+func (c *Chain) SetRewards(delegator, validator string, rewards []types.DecCoin) error {
+	if _, ok := c.distribution.Rewards[delegator][validator]; !ok {
+		return fmt.Errorf("delegation of %s to %s not found", delegator, validator)
+	}
+	c.distribution.Rewards[delegator][validator] = rewards
+	return nil
+}
+
+// AddChannel adds or replaces an open channel. It is bound to the port of channel.Endpoint.
+func (c *Chain) AddChannel(channel types.IBCChannel) {
+	c.channels = upsertSorted(c.channels, channel, func(a, b types.IBCChannel) bool {
+		return a.Endpoint.PortID < b.Endpoint.PortID ||
+			(a.Endpoint.PortID == b.Endpoint.PortID && a.Endpoint.ChannelID < b.Endpoint.ChannelID)
+	})
+}
+
+// RegisterCode adds or replaces the info of a code.
+func (c *Chain) RegisterCode(info types.CodeInfoResponse) {
+	c.wasm.Codes[info.CodeID] = info
+}
+
+// RegisterContract adds or replaces a contract. Its code must have been registered before.
+func (c *Chain) RegisterContract(addr string, contract Contract) error {
+	if _, ok := c.wasm.Codes[contract.Info.CodeID]; !ok {
+		return types.NoSuchCode{CodeID: contract.Info.CodeID}
+	}
+	c.contracts[addr] = contract
+	c.wasm.Contracts[addr] = contract.Info
+	return nil
+}
+
+// SetGrpcHandler registers the handler for Stargate and gRPC queries to the given path,
+// e.g. "/cosmos.bank.v1beta1.Query/Balance".
+func (c *Chain) SetGrpcHandler(path string, handler GrpcHandler) {
+	c.grpc.Handlers[path] = handler
+}
+
+// Querier returns a querier for the given contract. The contract is used to answer
+// IBC queries for the contract's port. Like MockQuerier, it consumes one gas per byte
+// of the serialized request.
+func (c *Chain) Querier(contract string) *ChainQuerier {
+	return &ChainQuerier{chain: c, contract: contract}
+}
+
+// mockQuerier returns a MockQuerier for the current state of the chain
+func (c *Chain) mockQuerier(contract string) *MockQuerier {
+	return &MockQuerier{
+		Bank:         c.bank,
+		Staking:      c.staking,
+		Distribution: c.distribution,
+		IBC:          IBCQuerier{PortID: c.contracts[contract].Info.IBCPort, Channels: c.channels},
+		Wasm:         c.wasm,
+		Grpc:         c.grpc,
+		Custom:       c.Custom,
+	}
+}
+
+func (c *Chain) queryRaw(addr string, key []byte) []byte {
+	if store := c.contracts[addr].Store; store != nil {
+		return store.Get(key)
+	}
+	return nil
+}
+
+func (c *Chain) querySmart(addr string, msg []byte) ([]byte, error) {
+	smart := c.contracts[addr].Smart
+	if smart == nil {
+		return nil, types.UnsupportedRequest{Kind: "wasm smart"}
+	}
+	return smart(msg)
+}
+
+// ChainQuerier answers queries of a contract using the state of a Chain.
+// Changes to the Chain are visible to existing queriers.
+type ChainQuerier struct {
+	chain    *Chain
+	contract string
+	usedGas  uint64
+}
+
+var _ types.Querier = (*ChainQuerier)(nil)
+
+func (q *ChainQuerier) Query(request types.QueryRequest, gasLimit uint64) ([]byte, error) {
+	querier := q.chain.mockQuerier(q.contract)
+	res, err := querier.Query(request, gasLimit)
+	q.usedGas += querier.GasConsumed()
+	return res, err
+}
+
+func (q *ChainQuerier) GasConsumed() uint64 {
+	return q.usedGas
+}
+
+// upsertSorted replaces the element of the sorted list that is equal to v according to less
+// or inserts v at its position.
+func upsertSorted[T any](list []T, v T, less func(a, b T) bool) []T {
+	i := sort.Search(len(list), func(i int) bool { return !less(list[i], v) })
+	if i < len(list) && !less(v, list[i]) {
+		list[i] = v
+		return list
+	}
+	return slices.Insert(list, i, v)
+}
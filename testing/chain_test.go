@@ -0,0 +1,191 @@
+package testing
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+func TestChainAllDenomMetadataPagination(t *testing.T) {
+	chain := NewChain()
+	for _, denom := range []string{"uatom", "uosmo", "ujuno"} {
+		chain.SetDenomMetadata(types.DenomMetadata{Base: denom, Display: denom[1:], DenomUnits: []types.DenomUnit{}})
+	}
+	q := chain.Querier(MockContractAddr)
+
+	var denoms []string
+	var key []byte
+	for {
+		req := types.QueryRequest{Bank: &types.BankQuery{AllDenomMetadata: &types.AllDenomMetadataQuery{
+			Pagination: &types.PageRequest{Key: key, Limit: 2},
+		}}}
+		bz, err := q.Query(req, DefaultQuerierGasLimit)
+		require.NoError(t, err)
+		var resp types.AllDenomMetadataResponse
+		require.NoError(t, json.Unmarshal(bz, &resp))
+		for _, m := range resp.Metadata {
+			denoms = append(denoms, m.Base)
+		}
+		if resp.NextKey == nil {
+			break
+		}
+		key = resp.NextKey
+	}
+	assert.Equal(t, []string{"uatom", "ujuno", "uosmo"}, denoms)
+}
+
+func TestChainQuerier(t *testing.T) {
+	chain := NewChain()
+	chain.SetBalance("alice", types.Array[types.Coin]{types.NewCoin(100, "stake")})
+	chain.SetBalance("bob", types.Array[types.Coin]{types.NewCoin(50, "stake")})
+	chain.SetDenomMetadata(types.DenomMetadata{Base: "stake", Display: "STAKE", DenomUnits: []types.DenomUnit{}})
+	chain.AddValidator(types.Validator{Address: "val2", Commission: "0.1", MaxCommission: "0.2", MaxChangeRate: "0.01"})
+	chain.AddValidator(types.Validator{Address: "val1", Commission: "0.05", MaxCommission: "0.1", MaxChangeRate: "0.01"})
+	require.NoError(t, chain.SetDelegation(types.FullDelegation{
+		Delegator:          "alice",
+		Validator:          "val1",
+		Amount:             types.NewCoin(10, "stake"),
+		AccumulatedRewards: types.Array[types.Coin]{},
+		CanRedelegate:      types.NewCoin(10, "stake"),
+	}))
+	require.EqualError(t, chain.SetDelegation(types.FullDelegation{Delegator: "alice", Validator: "val3"}), "validator val3 not found")
+	require.NoError(t, chain.SetRewards("alice", "val1", []types.DecCoin{{Amount: "0.5", Denom: "stake"}}))
+	require.EqualError(t, chain.SetRewards("alice", "val2", nil), "delegation of alice to val2 not found")
+	chain.SetWithdrawAddress("alice", "bob")
+	chain.AddChannel(MockIBCChannel("channel-1", types.Unordered, "v1"))
+
+	chain.RegisterCode(types.CodeInfoResponse{CodeID: 1, Creator: "alice", Checksum: make(types.Checksum, 32)})
+	store := NewLookup(NewMockGasMeter(1 << 30))
+	store.Set([]byte("config"), []byte(`{"owner":"alice"}`))
+	require.NoError(t, chain.RegisterContract(MockContractAddr, Contract{
+		Info:  types.ContractInfoResponse{CodeID: 1, Creator: "alice", IBCPort: "my_port"},
+		Store: store,
+		Smart: func(msg []byte) ([]byte, error) { return []byte(`{"pong":true}`), nil },
+	}))
+	require.Equal(t, types.NoSuchCode{CodeID: 2}, chain.RegisterContract("other", Contract{Info: types.ContractInfoResponse{CodeID: 2}}))
+	chain.SetGrpcHandler("/cosmos.bank.v1beta1.Query/Params", func(data []byte) ([]byte, error) {
+		if len(data) != 0 {
+			return nil, errors.New("unexpected request")
+		}
+		return []byte{0x0a, 0x00}, nil
+	})
+
+	specs := map[string]struct {
+		request types.QueryRequest
+		exp     string
+		expRaw  []byte
+		expErr  error
+	}{
+		"bank balance": {
+			request: types.QueryRequest{Bank: &types.BankQuery{Balance: &types.BalanceQuery{Address: "alice", Denom: "stake"}}},
+			exp:     `{"amount":{"denom":"stake","amount":"100"}}`,
+		},
+		"bank supply": {
+			request: types.QueryRequest{Bank: &types.BankQuery{Supply: &types.SupplyQuery{Denom: "stake"}}},
+			exp:     `{"amount":{"denom":"stake","amount":"150"}}`,
+		},
+		"bank denom metadata": {
+			request: types.QueryRequest{Bank: &types.BankQuery{DenomMetadata: &types.DenomMetadataQuery{Denom: "stake"}}},
+			exp:     `{"metadata":{"description":"","denom_units":[],"base":"stake","display":"STAKE","name":"","symbol":"","uri":"","uri_hash":""}}`,
+		},
+		"staking all validators": {
+			request: types.QueryRequest{Staking: &types.StakingQuery{AllValidators: &types.AllValidatorsQuery{}}},
+			exp:     `{"validators":[{"address":"val1","commission":"0.05","max_commission":"0.1","max_change_rate":"0.01"},{"address":"val2","commission":"0.1","max_commission":"0.2","max_change_rate":"0.01"}]}`,
+		},
+		"staking delegation": {
+			request: types.QueryRequest{Staking: &types.StakingQuery{Delegation: &types.DelegationQuery{Delegator: "alice", Validator: "val1"}}},
+			exp:     `{"delegation":{"delegator":"alice","validator":"val1","amount":{"denom":"stake","amount":"10"},"accumulated_rewards":[],"can_redelegate":{"denom":"stake","amount":"10"}}}`,
+		},
+		"staking bonded denom": {
+			request: types.QueryRequest{Staking: &types.StakingQuery{BondedDenom: &struct{}{}}},
+			exp:     `{"denom":"stake"}`,
+		},
+		"distribution rewards": {
+			request: types.QueryRequest{Distribution: &types.DistributionQuery{DelegationRewards: &types.DelegationRewardsQuery{DelegatorAddress: "alice", ValidatorAddress: "val1"}}},
+			exp:     `{"rewards":[{"amount":"0.5","denom":"stake"}]}`,
+		},
+		"distribution total rewards": {
+			request: types.QueryRequest{Distribution: &types.DistributionQuery{DelegationTotalRewards: &types.DelegationTotalRewardsQuery{DelegatorAddress: "alice"}}},
+			exp:     `{"rewards":[{"reward":[{"amount":"0.5","denom":"stake"}],"validator_address":"val1"}],"total":[{"amount":"0.5","denom":"stake"}]}`,
+		},
+		"distribution delegator validators": {
+			request: types.QueryRequest{Distribution: &types.DistributionQuery{DelegatorValidators: &types.DelegatorValidatorsQuery{DelegatorAddress: "alice"}}},
+			exp:     `{"validators":["val1"]}`,
+		},
+		"distribution withdraw address": {
+			request: types.QueryRequest{Distribution: &types.DistributionQuery{DelegatorWithdrawAddress: &types.DelegatorWithdrawAddressQuery{DelegatorAddress: "alice"}}},
+			exp:     `{"withdraw_address":"bob"}`,
+		},
+		"ibc port": {
+			request: types.QueryRequest{IBC: &types.IBCQuery{PortID: &types.PortIDQuery{}}},
+			exp:     `{"port_id":"my_port"}`,
+		},
+		"ibc channel": {
+			request: types.QueryRequest{IBC: &types.IBCQuery{Channel: &types.ChannelQuery{ChannelID: "channel-1"}}},
+			exp:     `{"channel":{"endpoint":{"port_id":"my_port","channel_id":"channel-1"},"counterparty_endpoint":{"port_id":"their_port","channel_id":"channel-7"},"order":"ORDER_UNORDERED","version":"v1","connection_id":"connection-3"}}`,
+		},
+		"wasm smart": {
+			request: types.QueryRequest{Wasm: &types.WasmQuery{Smart: &types.SmartQuery{ContractAddr: MockContractAddr, Msg: []byte(`{"ping":{}}`)}}},
+			exp:     `{"pong":true}`,
+		},
+		"wasm raw": {
+			request: types.QueryRequest{Wasm: &types.WasmQuery{Raw: &types.RawQuery{ContractAddr: MockContractAddr, Key: []byte("config")}}},
+			exp:     `{"owner":"alice"}`,
+		},
+		"wasm contract info": {
+			request: types.QueryRequest{Wasm: &types.WasmQuery{ContractInfo: &types.ContractInfoQuery{ContractAddr: MockContractAddr}}},
+			exp:     `{"code_id":1,"creator":"alice","pinned":false,"ibc_port":"my_port"}`,
+		},
+		"wasm code info": {
+			request: types.QueryRequest{Wasm: &types.WasmQuery{CodeInfo: &types.CodeInfoQuery{CodeID: 1}}},
+			exp:     `{"code_id":1,"creator":"alice","checksum":"0000000000000000000000000000000000000000000000000000000000000000"}`,
+		},
+		"wasm missing contract": {
+			request: types.QueryRequest{Wasm: &types.WasmQuery{Smart: &types.SmartQuery{ContractAddr: "other"}}},
+			expErr:  types.NoSuchContract{Addr: "other"},
+		},
+		"grpc": {
+			request: types.QueryRequest{Grpc: &types.GrpcQuery{Path: "/cosmos.bank.v1beta1.Query/Params"}},
+			expRaw:  []byte{0x0a, 0x00},
+		},
+		"stargate": {
+			request: types.QueryRequest{Stargate: &types.StargateQuery{Path: "/cosmos.bank.v1beta1.Query/Params"}},
+			expRaw:  []byte{0x0a, 0x00},
+		},
+		"grpc unknown path": {
+			request: types.QueryRequest{Grpc: &types.GrpcQuery{Path: "/foo"}},
+			expErr:  types.UnsupportedRequest{Kind: "no handler for path /foo"},
+		},
+		"custom": {
+			request: types.QueryRequest{Custom: json.RawMessage(`{"ping":{}}`)},
+			expErr:  types.UnsupportedRequest{Kind: "custom"},
+		},
+	}
+	q := chain.Querier(MockContractAddr)
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			res, err := q.Query(spec.request, DefaultQuerierGasLimit)
+			if spec.expErr != nil {
+				require.Equal(t, spec.expErr, err)
+				return
+			}
+			require.NoError(t, err)
+			if spec.expRaw != nil {
+				assert.Equal(t, spec.expRaw, res)
+				return
+			}
+			assert.JSONEq(t, spec.exp, string(res))
+		})
+	}
+
+	// changes to the chain are visible to existing queriers
+	chain.Custom = ReflectCustom{}
+	res, err := q.Query(types.QueryRequest{Custom: json.RawMessage(`{"ping":{}}`)}, DefaultQuerierGasLimit)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"msg":"PONG"}`, string(res))
+}
@@ -0,0 +1,167 @@
+// Package testing provides mocks of the environment a contract is executed in, i.e. the
+// block and message info, IBC messages, gas meter, storage, Go API and querier.
+// They are meant for unit tests of code calling into wasmvm.
+//
+// Since the package name clashes with the standard library's testing package, it is
+// usually imported with an alias:
+//
+//	import wasmvmtesting "github.com/CosmWasm/wasmvm/v2/testing"
+package testing
+
+import (
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// MockContractAddr is the contract address used by MockEnv
+const MockContractAddr = "contract"
+
+// MockEnv returns an Env for a contract at MockContractAddr in block 123.
+func MockEnv() types.Env {
+	return types.Env{
+		Block: types.BlockInfo{
+			Height:  123,
+			Time:    1578939743_987654321,
+			ChainID: "foobar",
+		},
+		Transaction: &types.TransactionInfo{
+			Index: 4,
+		},
+		Contract: types.ContractInfo{
+			Address: MockContractAddr,
+		},
+	}
+}
+
+// MockInfo returns a MessageInfo with the given sender and funds.
+func MockInfo(sender types.HumanAddress, funds []types.Coin) types.MessageInfo {
+	return types.MessageInfo{
+		Sender: sender,
+		Funds:  funds,
+	}
+}
+
+// MockInfoWithFunds returns a MessageInfo with the given sender and 100 ATOM of funds.
+func MockInfoWithFunds(sender types.HumanAddress) types.MessageInfo {
+	return MockInfo(sender, []types.Coin{{
+		Denom:  "ATOM",
+		Amount: "100",
+	}})
+}
+
+// MockIBCChannel returns a channel from "my_port" to "their_port" on "channel-7".
+func MockIBCChannel(channelID string, ordering types.IBCOrder, ibcVersion string) types.IBCChannel {
+	return types.IBCChannel{
+		Endpoint: types.IBCEndpoint{
+			PortID:    "my_port",
+			ChannelID: channelID,
+		},
+		CounterpartyEndpoint: types.IBCEndpoint{
+			PortID:    "their_port",
+			ChannelID: "channel-7",
+		},
+		Order:        ordering,
+		Version:      ibcVersion,
+		ConnectionID: "connection-3",
+	}
+}
+
+func MockIBCChannelOpenInit(channelID string, ordering types.IBCOrder, ibcVersion string) types.IBCChannelOpenMsg {
+	return types.IBCChannelOpenMsg{
+		OpenInit: &types.IBCOpenInit{
+			Channel: MockIBCChannel(channelID, ordering, ibcVersion),
+		},
+		OpenTry: nil,
+	}
+}
+
+func MockIBCChannelOpenTry(channelID string, ordering types.IBCOrder, ibcVersion string) types.IBCChannelOpenMsg {
+	return types.IBCChannelOpenMsg{
+		OpenInit: nil,
+		OpenTry: &types.IBCOpenTry{
+			Channel:             MockIBCChannel(channelID, ordering, ibcVersion),
+			CounterpartyVersion: ibcVersion,
+		},
+	}
+}
+
+func MockIBCChannelConnectAck(channelID string, ordering types.IBCOrder, ibcVersion string) types.IBCChannelConnectMsg {
+	return types.IBCChannelConnectMsg{
+		OpenAck: &types.IBCOpenAck{
+			Channel:             MockIBCChannel(channelID, ordering, ibcVersion),
+			CounterpartyVersion: ibcVersion,
+		},
+		OpenConfirm: nil,
+	}
+}
+
+func MockIBCChannelConnectConfirm(channelID string, ordering types.IBCOrder, ibcVersion string) types.IBCChannelConnectMsg {
+	return types.IBCChannelConnectMsg{
+		OpenAck: nil,
+		OpenConfirm: &types.IBCOpenConfirm{
+			Channel: MockIBCChannel(channelID, ordering, ibcVersion),
+		},
+	}
+}
+
+func MockIBCChannelCloseInit(channelID string, ordering types.IBCOrder, ibcVersion string) types.IBCChannelCloseMsg {
+	return types.IBCChannelCloseMsg{
+		CloseInit: &types.IBCCloseInit{
+			Channel: MockIBCChannel(channelID, ordering, ibcVersion),
+		},
+		CloseConfirm: nil,
+	}
+}
+
+func MockIBCChannelCloseConfirm(channelID string, ordering types.IBCOrder, ibcVersion string) types.IBCChannelCloseMsg {
+	return types.IBCChannelCloseMsg{
+		CloseInit: nil,
+		CloseConfirm: &types.IBCCloseConfirm{
+			Channel: MockIBCChannel(channelID, ordering, ibcVersion),
+		},
+	}
+}
+
+// MockIBCPacket returns a packet with sequence 15 received on myChannel.
+func MockIBCPacket(myChannel string, data []byte) types.IBCPacket {
+	return types.IBCPacket{
+		Data: data,
+		Src: types.IBCEndpoint{
+			PortID:    "their_port",
+			ChannelID: "channel-7",
+		},
+		Dest: types.IBCEndpoint{
+			PortID:    "my_port",
+			ChannelID: myChannel,
+		},
+		Sequence: 15,
+		Timeout: types.IBCTimeout{
+			Block: &types.IBCTimeoutBlock{
+				Revision: 1,
+				Height:   123456,
+			},
+		},
+	}
+}
+
+func MockIBCPacketReceive(myChannel string, data []byte) types.IBCPacketReceiveMsg {
+	return types.IBCPacketReceiveMsg{
+		Packet: MockIBCPacket(myChannel, data),
+	}
+}
+
+func MockIBCPacketAck(myChannel string, data []byte, ack types.IBCAcknowledgement) types.IBCPacketAckMsg {
+	packet := MockIBCPacket(myChannel, data)
+
+	return types.IBCPacketAckMsg{
+		Acknowledgement: ack,
+		OriginalPacket:  packet,
+	}
+}
+
+func MockIBCPacketTimeout(myChannel string, data []byte) types.IBCPacketTimeoutMsg {
+	packet := MockIBCPacket(myChannel, data)
+
+	return types.IBCPacketTimeoutMsg{
+		Packet: packet,
+	}
+}
@@ -0,0 +1,71 @@
+package testing
+
+import (
+	"math"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// This code is borrowed from Cosmos-SDK store/types/gas.go
+
+// ErrorOutOfGas defines an error thrown when an action results in out of gas.
+type ErrorOutOfGas struct {
+	Descriptor string
+}
+
+// ErrorGasOverflow defines an error thrown when an action results gas consumption
+// unsigned integer overflow.
+type ErrorGasOverflow struct {
+	Descriptor string
+}
+
+// MockGasMeter is a gas meter that allows consuming gas.
+type MockGasMeter interface {
+	types.GasMeter
+	ConsumeGas(amount types.Gas, descriptor string)
+}
+
+type mockGasMeter struct {
+	limit    types.Gas
+	consumed types.Gas
+}
+
+// NewMockGasMeter returns a gas meter that panics with ErrorOutOfGas
+// when more than limit gas is consumed.
+func NewMockGasMeter(limit types.Gas) MockGasMeter {
+	return &mockGasMeter{
+		limit:    limit,
+		consumed: 0,
+	}
+}
+
+func (g *mockGasMeter) GasConsumed() types.Gas {
+	return g.consumed
+}
+
+func (g *mockGasMeter) Limit() types.Gas {
+	return g.limit
+}
+
+// addUint64Overflow performs the addition operation on two uint64 integers and
+// returns a boolean on whether or not the result overflows.
+func addUint64Overflow(a, b uint64) (uint64, bool) {
+	if math.MaxUint64-a < b {
+		return 0, true
+	}
+
+	return a + b, false
+}
+
+func (g *mockGasMeter) ConsumeGas(amount types.Gas, descriptor string) {
+	var overflow bool
+	// TODO: Should we set the consumed field after overflow checking?
+	g.consumed, overflow = addUint64Overflow(g.consumed, amount)
+	if overflow {
+		panic(ErrorGasOverflow{descriptor})
+	}
+
+	if g.consumed > g.limit {
+		panic(ErrorOutOfGas{descriptor})
+	}
+}
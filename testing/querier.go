@@ -0,0 +1,512 @@
+package testing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// DefaultQuerierGasLimit is a gas limit that is sufficient for queries to MockQuerier
+const DefaultQuerierGasLimit = 1_000_000
+
+// DefaultPageLimit is the number of results returned by paginated queries without a limit,
+// as in the Cosmos SDK.
+const DefaultPageLimit = 100
+
+// MockQuerier is a types.Querier answering queries from fixed data.
+// Every query consumes one gas per byte of the serialized request.
+type MockQuerier struct {
+	Bank         BankQuerier
+	Staking      StakingQuerier
+	Distribution DistributionQuerier
+	IBC          IBCQuerier
+	Wasm         WasmQuerier
+	// Grpc answers Stargate and gRPC queries. They are not supported if it has no handlers.
+	Grpc    GrpcQuerier
+	Custom  CustomQuerier
+	usedGas uint64
+}
+
+var _ types.Querier = &MockQuerier{}
+
+// DefaultQuerier returns a MockQuerier in which contractAddr owns the given coins.
+// Custom queries are not supported.
+func DefaultQuerier(contractAddr string, coins types.Array[types.Coin]) *MockQuerier {
+	balances := map[string]types.Array[types.Coin]{
+		contractAddr: coins,
+	}
+	return &MockQuerier{
+		Bank:    NewBankQuerier(balances),
+		IBC:     IBCQuerier{PortID: "wasm." + contractAddr},
+		Custom:  NoCustom{},
+		usedGas: 0,
+	}
+}
+
+func (q *MockQuerier) Query(request types.QueryRequest, _gasLimit uint64) ([]byte, error) {
+	marshaled, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	q.usedGas += uint64(len(marshaled))
+	switch {
+	case request.Bank != nil:
+		return q.Bank.Query(request.Bank)
+	case request.Custom != nil:
+		if q.Custom == nil {
+			return nil, types.UnsupportedRequest{Kind: "custom"}
+		}
+		return q.Custom.Query(request.Custom)
+	case request.Staking != nil:
+		return q.Staking.Query(request.Staking)
+	case request.Distribution != nil:
+		return q.Distribution.Query(request.Distribution)
+	case request.IBC != nil:
+		return q.IBC.Query(request.IBC)
+	case request.Wasm != nil:
+		return q.Wasm.Query(request.Wasm)
+	case request.Stargate != nil:
+		if q.Grpc.Handlers == nil {
+			return nil, types.UnsupportedRequest{Kind: "stargate"}
+		}
+		return q.Grpc.Query(request.Stargate.Path, request.Stargate.Data)
+	case request.Grpc != nil:
+		if q.Grpc.Handlers == nil {
+			return nil, types.UnsupportedRequest{Kind: "grpc"}
+		}
+		return q.Grpc.Query(request.Grpc.Path, request.Grpc.Data)
+	default:
+		return nil, types.Unknown{}
+	}
+}
+
+func (q MockQuerier) GasConsumed() uint64 {
+	return q.usedGas
+}
+
+// BankQuerier answers bank queries from a map of balances. The supply of a denom
+// is the sum of all balances in that denom.
+type BankQuerier struct {
+	Balances map[string]types.Array[types.Coin]
+	// DenomMetadata maps base denoms to their metadata
+	DenomMetadata map[string]types.DenomMetadata
+}
+
+// NewBankQuerier creates a BankQuerier with a copy of the given balances.
+func NewBankQuerier(balances map[string]types.Array[types.Coin]) BankQuerier {
+	bal := make(map[string]types.Array[types.Coin], len(balances))
+	for k, v := range balances {
+		dst := make([]types.Coin, len(v))
+		copy(dst, v)
+		bal[k] = dst
+	}
+	return BankQuerier{
+		Balances: bal,
+	}
+}
+
+func (q BankQuerier) Query(request *types.BankQuery) ([]byte, error) {
+	if request.Balance != nil {
+		denom := request.Balance.Denom
+		coin := types.NewCoin(0, denom)
+		for _, c := range q.Balances[request.Balance.Address] {
+			if c.Denom == denom {
+				coin = c
+			}
+		}
+		resp := types.BalanceResponse{
+			Amount: coin,
+		}
+		return json.Marshal(resp)
+	}
+	if request.AllBalances != nil {
+		coins := q.Balances[request.AllBalances.Address]
+		resp := types.AllBalancesResponse{
+			Amount: coins,
+		}
+		return json.Marshal(resp)
+	}
+	if request.Supply != nil {
+		supply, err := sumCoins(q.Balances, request.Supply.Denom)
+		if err != nil {
+			return nil, err
+		}
+		resp := types.SupplyResponse{
+			Amount: types.Coin{Denom: request.Supply.Denom, Amount: supply.String()},
+		}
+		return json.Marshal(resp)
+	}
+	if request.DenomMetadata != nil {
+		metadata, ok := q.DenomMetadata[request.DenomMetadata.Denom]
+		if !ok {
+			return nil, fmt.Errorf("denom metadata for %s not found", request.DenomMetadata.Denom)
+		}
+		return json.Marshal(types.DenomMetadataResponse{Metadata: metadata})
+	}
+	if request.AllDenomMetadata != nil {
+		page, nextKey := paginate(sortedKeys(q.DenomMetadata), request.AllDenomMetadata.Pagination)
+		resp := types.AllDenomMetadataResponse{Metadata: make([]types.DenomMetadata, 0, len(page)), NextKey: nextKey}
+		for _, denom := range page {
+			resp.Metadata = append(resp.Metadata, q.DenomMetadata[denom])
+		}
+		return json.Marshal(resp)
+	}
+	return nil, types.UnsupportedRequest{Kind: "Empty BankQuery"}
+}
+
+// sumCoins returns the sum of all coins of the given denom
+func sumCoins(balances map[string]types.Array[types.Coin], denom string) (*big.Int, error) {
+	sum := new(big.Int)
+	for _, coins := range balances {
+		for _, c := range coins {
+			if c.Denom != denom {
+				continue
+			}
+			amount, ok := new(big.Int).SetString(c.Amount, 10)
+			if !ok {
+				return nil, fmt.Errorf("invalid amount %q", c.Amount)
+			}
+			sum.Add(sum, amount)
+		}
+	}
+	return sum, nil
+}
+
+// StakingQuerier answers staking queries from a list of validators and delegations.
+type StakingQuerier struct {
+	BondedDenom string
+	Validators  []types.Validator
+	Delegations []types.FullDelegation
+}
+
+func (q StakingQuerier) Query(request *types.StakingQuery) ([]byte, error) {
+	switch {
+	case request.BondedDenom != nil:
+		return json.Marshal(types.BondedDenomResponse{Denom: q.BondedDenom})
+	case request.AllValidators != nil:
+		return json.Marshal(types.AllValidatorsResponse{Validators: q.Validators})
+	case request.Validator != nil:
+		resp := types.ValidatorResponse{}
+		for i, v := range q.Validators {
+			if v.Address == request.Validator.Address {
+				resp.Validator = &q.Validators[i]
+				break
+			}
+		}
+		return json.Marshal(resp)
+	case request.AllDelegations != nil:
+		resp := types.AllDelegationsResponse{Delegations: types.Array[types.Delegation]{}}
+		for _, d := range q.Delegations {
+			if d.Delegator == request.AllDelegations.Delegator {
+				resp.Delegations = append(resp.Delegations, types.Delegation{
+					Delegator: d.Delegator,
+					Validator: d.Validator,
+					Amount:    d.Amount,
+				})
+			}
+		}
+		return json.Marshal(resp)
+	case request.Delegation != nil:
+		resp := types.DelegationResponse{}
+		for i, d := range q.Delegations {
+			if d.Delegator == request.Delegation.Delegator && d.Validator == request.Delegation.Validator {
+				resp.Delegation = &q.Delegations[i]
+				break
+			}
+		}
+		return json.Marshal(resp)
+	default:
+		return nil, types.UnsupportedRequest{Kind: "Empty StakingQuery"}
+	}
+}
+
+// DistributionQuerier answers distribution queries.
+type DistributionQuerier struct {
+	// WithdrawAddresses maps delegators to their withdraw address.
+	// Delegators without an entry withdraw to their own address.
+	WithdrawAddresses map[string]string
+	// Rewards maps delegators to validators to the outstanding rewards.
+	// The validators of a delegator are the validators in this map.
+	Rewards map[string]map[string][]types.DecCoin
+}
+
+func (q DistributionQuerier) Query(request *types.DistributionQuery) ([]byte, error) {
+	switch {
+	case request.DelegatorWithdrawAddress != nil:
+		delegator := request.DelegatorWithdrawAddress.DelegatorAddress
+		addr, ok := q.WithdrawAddresses[delegator]
+		if !ok {
+			addr = delegator
+		}
+		return json.Marshal(types.DelegatorWithdrawAddressResponse{WithdrawAddress: addr})
+	case request.DelegationRewards != nil:
+		r := request.DelegationRewards
+		rewards := q.Rewards[r.DelegatorAddress][r.ValidatorAddress]
+		if rewards == nil {
+			rewards = []types.DecCoin{}
+		}
+		return json.Marshal(types.DelegationRewardsResponse{Rewards: rewards})
+	case request.DelegationTotalRewards != nil:
+		delegator := request.DelegationTotalRewards.DelegatorAddress
+		resp := types.DelegationTotalRewardsResponse{Rewards: []types.DelegatorReward{}}
+		total := map[string]*big.Rat{}
+		for _, validator := range q.validators(delegator) {
+			rewards := q.Rewards[delegator][validator]
+			for _, c := range rewards {
+				amount, ok := new(big.Rat).SetString(c.Amount)
+				if !ok {
+					return nil, fmt.Errorf("invalid decimal amount %q", c.Amount)
+				}
+				if total[c.Denom] == nil {
+					total[c.Denom] = new(big.Rat)
+				}
+				total[c.Denom].Add(total[c.Denom], amount)
+			}
+			resp.Rewards = append(resp.Rewards, types.DelegatorReward{Reward: rewards, ValidatorAddress: validator})
+		}
+		resp.Total = make([]types.DecCoin, 0, len(total))
+		for denom, amount := range total {
+			resp.Total = append(resp.Total, types.DecCoin{Denom: denom, Amount: formatDecimal(amount)})
+		}
+		sort.Slice(resp.Total, func(i, j int) bool { return resp.Total[i].Denom < resp.Total[j].Denom })
+		return json.Marshal(resp)
+	case request.DelegatorValidators != nil:
+		return json.Marshal(types.DelegatorValidatorsResponse{Validators: q.validators(request.DelegatorValidators.DelegatorAddress)})
+	default:
+		return nil, types.UnsupportedRequest{Kind: "Empty DistributionQuery"}
+	}
+}
+
+// validators returns the sorted validators of a delegator
+func (q DistributionQuerier) validators(delegator string) []string {
+	res := []string{}
+	for validator := range q.Rewards[delegator] {
+		res = append(res, validator)
+	}
+	sort.Strings(res)
+	return res
+}
+
+// formatDecimal formats a decimal with up to 18 fractional digits without trailing zeros
+func formatDecimal(r *big.Rat) string {
+	s := r.FloatString(18)
+	s = strings.TrimRight(s, "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// IBCQuerier answers IBC queries from a list of channels.
+type IBCQuerier struct {
+	// PortID is the port of the querying contract. It is used if a query does not specify a port.
+	// The port query fails if it is empty.
+	PortID   string
+	Channels []types.IBCChannel
+}
+
+func (q IBCQuerier) Query(request *types.IBCQuery) ([]byte, error) {
+	switch {
+	case request.PortID != nil:
+		if q.PortID == "" {
+			return nil, errors.New("contract has no IBC port")
+		}
+		return json.Marshal(types.PortIDResponse{PortID: q.PortID})
+	case request.ListChannels != nil:
+		portID := q.portID(request.ListChannels.PortID)
+		resp := types.ListChannelsResponse{Channels: types.Array[types.IBCChannel]{}}
+		for _, ch := range q.Channels {
+			if ch.Endpoint.PortID == portID {
+				resp.Channels = append(resp.Channels, ch)
+			}
+		}
+		return json.Marshal(resp)
+	case request.Channel != nil:
+		portID := q.portID(request.Channel.PortID)
+		resp := types.ChannelResponse{}
+		for i, ch := range q.Channels {
+			if ch.Endpoint.PortID == portID && ch.Endpoint.ChannelID == request.Channel.ChannelID {
+				resp.Channel = &q.Channels[i]
+				break
+			}
+		}
+		return json.Marshal(resp)
+	default:
+		return nil, types.UnsupportedRequest{Kind: "Empty IBCQuery"}
+	}
+}
+
+func (q IBCQuerier) portID(portID string) string {
+	if portID == "" {
+		return q.PortID
+	}
+	return portID
+}
+
+// WasmQuerier answers wasm queries about other contracts.
+type WasmQuerier struct {
+	// Contracts maps contract addresses to their info
+	Contracts map[string]types.ContractInfoResponse
+	// Codes maps code IDs to their info
+	Codes map[uint64]types.CodeInfoResponse
+	// Storage maps contract addresses to the contract's storage for raw queries
+	Storage map[string]map[string][]byte
+	// Raw handles raw queries to contracts in Contracts. Storage is used if it is nil.
+	Raw func(contract string, key []byte) []byte
+	// Smart handles smart queries to contracts in Contracts. Smart queries are not supported if it is nil.
+	Smart func(contract string, msg []byte) ([]byte, error)
+}
+
+func (q WasmQuerier) Query(request *types.WasmQuery) ([]byte, error) {
+	switch {
+	case request.Smart != nil:
+		addr := request.Smart.ContractAddr
+		if _, ok := q.Contracts[addr]; !ok {
+			return nil, types.NoSuchContract{Addr: addr}
+		}
+		if q.Smart == nil {
+			return nil, types.UnsupportedRequest{Kind: "wasm smart"}
+		}
+		return q.Smart(addr, request.Smart.Msg)
+	case request.Raw != nil:
+		addr := request.Raw.ContractAddr
+		if _, ok := q.Contracts[addr]; !ok {
+			return nil, types.NoSuchContract{Addr: addr}
+		}
+		if q.Raw != nil {
+			return q.Raw(addr, request.Raw.Key), nil
+		}
+		return q.Storage[addr][string(request.Raw.Key)], nil
+	case request.ContractInfo != nil:
+		addr := request.ContractInfo.ContractAddr
+		info, ok := q.Contracts[addr]
+		if !ok {
+			return nil, types.NoSuchContract{Addr: addr}
+		}
+		return json.Marshal(info)
+	case request.CodeInfo != nil:
+		info, ok := q.Codes[request.CodeInfo.CodeID]
+		if !ok {
+			return nil, types.NoSuchCode{CodeID: request.CodeInfo.CodeID}
+		}
+		return json.Marshal(info)
+	default:
+		return nil, types.UnsupportedRequest{Kind: "Empty WasmQuery"}
+	}
+}
+
+// GrpcHandler answers Stargate and gRPC queries to a path. It receives and returns protobuf encoded data.
+type GrpcHandler func(data []byte) ([]byte, error)
+
+// GrpcQuerier answers Stargate and gRPC queries.
+type GrpcQuerier struct {
+	// Handlers maps paths, e.g. "/cosmos.bank.v1beta1.Query/Balance", to their handler
+	Handlers map[string]GrpcHandler
+}
+
+func (q GrpcQuerier) Query(path string, data []byte) ([]byte, error) {
+	handler, ok := q.Handlers[path]
+	if !ok {
+		return nil, types.UnsupportedRequest{Kind: fmt.Sprintf("no handler for path %s", path)}
+	}
+	return handler(data)
+}
+
+// CustomQuerier answers custom queries
+type CustomQuerier interface {
+	Query(request json.RawMessage) ([]byte, error)
+}
+
+// NoCustom rejects all custom queries
+type NoCustom struct{}
+
+var _ CustomQuerier = NoCustom{}
+
+func (q NoCustom) Query(request json.RawMessage) ([]byte, error) {
+	return nil, types.UnsupportedRequest{Kind: "custom"}
+}
+
+// ReflectCustom fulfills the requirements for testing `reflect` contract
+type ReflectCustom struct{}
+
+var _ CustomQuerier = ReflectCustom{}
+
+type CustomQuery struct {
+	Ping        *struct{}         `json:"ping,omitempty"`
+	Capitalized *CapitalizedQuery `json:"capitalized,omitempty"`
+}
+
+type CapitalizedQuery struct {
+	Text string `json:"text"`
+}
+
+// CustomResponse is the response for all `CustomQuery`s
+type CustomResponse struct {
+	Msg string `json:"msg"`
+}
+
+func (q ReflectCustom) Query(request json.RawMessage) ([]byte, error) {
+	var query CustomQuery
+	err := json.Unmarshal(request, &query)
+	if err != nil {
+		return nil, err
+	}
+	var resp CustomResponse
+	if query.Ping != nil {
+		resp.Msg = "PONG"
+	} else if query.Capitalized != nil {
+		resp.Msg = strings.ToUpper(query.Capitalized.Text)
+	} else {
+		return nil, errors.New("Unsupported query")
+	}
+	return json.Marshal(resp)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// paginate returns the page of the sorted keys selected by page and the key of the next page.
+// The next key is nil if there are no more results.
+func paginate(keys []string, page *types.PageRequest) ([]string, []byte) {
+	var start []byte
+	limit := DefaultPageLimit
+	reverse := false
+	if page != nil {
+		start = page.Key
+		if page.Limit > 0 {
+			limit = int(page.Limit)
+		}
+		reverse = page.Reverse
+	}
+	if reverse {
+		reversed := make([]string, len(keys))
+		for i, k := range keys {
+			reversed[len(keys)-1-i] = k
+		}
+		keys = reversed
+	}
+	if start != nil {
+		i := 0
+		for i < len(keys) {
+			cmp := bytes.Compare([]byte(keys[i]), start)
+			if (!reverse && cmp >= 0) || (reverse && cmp <= 0) {
+				break
+			}
+			i++
+		}
+		keys = keys[i:]
+	}
+	if len(keys) <= limit {
+		return keys, nil
+	}
+	return keys[:limit], []byte(keys[limit])
+}
@@ -0,0 +1,348 @@
+package testing
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+func TestBankQuerierAllBalances(t *testing.T) {
+	addr := "foobar"
+	balance := types.Array[types.Coin]{types.NewCoin(12345678, "ATOM"), types.NewCoin(54321, "ETH")}
+	q := DefaultQuerier(addr, balance)
+
+	// query existing account
+	req := types.QueryRequest{
+		Bank: &types.BankQuery{
+			AllBalances: &types.AllBalancesQuery{
+				Address: addr,
+			},
+		},
+	}
+	res, err := q.Query(req, DefaultQuerierGasLimit)
+	require.NoError(t, err)
+	var resp types.AllBalancesResponse
+	err = json.Unmarshal(res, &resp)
+	require.NoError(t, err)
+	assert.Equal(t, resp.Amount, balance)
+
+	// query missing account
+	req2 := types.QueryRequest{
+		Bank: &types.BankQuery{
+			AllBalances: &types.AllBalancesQuery{
+				Address: "someone-else",
+			},
+		},
+	}
+	res, err = q.Query(req2, DefaultQuerierGasLimit)
+	require.NoError(t, err)
+	var resp2 types.AllBalancesResponse
+	err = json.Unmarshal(res, &resp2)
+	require.NoError(t, err)
+	// Array serializes nil as an empty list
+	assert.Equal(t, types.Array[types.Coin]{}, resp2.Amount)
+}
+
+func TestBankQuerierBalance(t *testing.T) {
+	addr := "foobar"
+	balance := types.Array[types.Coin]{types.NewCoin(12345678, "ATOM"), types.NewCoin(54321, "ETH")}
+	q := DefaultQuerier(addr, balance)
+
+	// query existing account with matching denom
+	req := types.QueryRequest{
+		Bank: &types.BankQuery{
+			Balance: &types.BalanceQuery{
+				Address: addr,
+				Denom:   "ATOM",
+			},
+		},
+	}
+	res, err := q.Query(req, DefaultQuerierGasLimit)
+	require.NoError(t, err)
+	var resp types.BalanceResponse
+	err = json.Unmarshal(res, &resp)
+	require.NoError(t, err)
+	assert.Equal(t, resp.Amount, types.NewCoin(12345678, "ATOM"))
+
+	// query existing account with missing denom
+	req2 := types.QueryRequest{
+		Bank: &types.BankQuery{
+			Balance: &types.BalanceQuery{
+				Address: addr,
+				Denom:   "BTC",
+			},
+		},
+	}
+	res, err = q.Query(req2, DefaultQuerierGasLimit)
+	require.NoError(t, err)
+	var resp2 types.BalanceResponse
+	err = json.Unmarshal(res, &resp2)
+	require.NoError(t, err)
+	assert.Equal(t, resp2.Amount, types.NewCoin(0, "BTC"))
+
+	// query missing account
+	req3 := types.QueryRequest{
+		Bank: &types.BankQuery{
+			Balance: &types.BalanceQuery{
+				Address: "someone-else",
+				Denom:   "ATOM",
+			},
+		},
+	}
+	res, err = q.Query(req3, DefaultQuerierGasLimit)
+	require.NoError(t, err)
+	var resp3 types.BalanceResponse
+	err = json.Unmarshal(res, &resp3)
+	require.NoError(t, err)
+	assert.Equal(t, resp3.Amount, types.NewCoin(0, "ATOM"))
+}
+
+func TestReflectCustomQuerier(t *testing.T) {
+	q := ReflectCustom{}
+
+	// try ping
+	msg, err := json.Marshal(CustomQuery{Ping: &struct{}{}})
+	require.NoError(t, err)
+	bz, err := q.Query(msg)
+	require.NoError(t, err)
+	var resp CustomResponse
+	err = json.Unmarshal(bz, &resp)
+	require.NoError(t, err)
+	assert.Equal(t, resp.Msg, "PONG")
+
+	// try capital
+	msg2, err := json.Marshal(CustomQuery{Capitalized: &CapitalizedQuery{Text: "small."}})
+	require.NoError(t, err)
+	bz, err = q.Query(msg2)
+	require.NoError(t, err)
+	var resp2 CustomResponse
+	err = json.Unmarshal(bz, &resp2)
+	require.NoError(t, err)
+	assert.Equal(t, resp2.Msg, "SMALL.")
+}
+
+func TestMockQuerier(t *testing.T) {
+	channel := MockIBCChannel("channel-1", types.Ordered, "v1")
+	q := &MockQuerier{
+		Bank: NewBankQuerier(map[string]types.Array[types.Coin]{
+			"alice": {types.NewCoin(100, "ATOM")},
+			"bob":   {types.NewCoin(23, "ATOM"), types.NewCoin(5, "ETH")},
+		}),
+		Staking: StakingQuerier{
+			BondedDenom: "stake",
+			Validators:  []types.Validator{{Address: "val1", Commission: "0.05", MaxCommission: "0.1", MaxChangeRate: "0.01"}},
+			Delegations: []types.FullDelegation{{
+				Delegator:          "alice",
+				Validator:          "val1",
+				Amount:             types.NewCoin(10, "stake"),
+				AccumulatedRewards: types.Array[types.Coin]{},
+				CanRedelegate:      types.NewCoin(10, "stake"),
+			}},
+		},
+		Distribution: DistributionQuerier{
+			WithdrawAddresses: map[string]string{"alice": "bob"},
+			Rewards: map[string]map[string][]types.DecCoin{
+				"alice": {
+					"val1": {{Amount: "1.5", Denom: "stake"}},
+					"val2": {{Amount: "2.75", Denom: "stake"}, {Amount: "0.1", Denom: "ATOM"}},
+				},
+			},
+		},
+		IBC: IBCQuerier{PortID: "my_port", Channels: []types.IBCChannel{channel}},
+		Wasm: WasmQuerier{
+			Contracts: map[string]types.ContractInfoResponse{"contract": {CodeID: 1, Creator: "alice"}},
+			Codes:     map[uint64]types.CodeInfoResponse{1: {CodeID: 1, Creator: "alice", Checksum: make(types.Checksum, 32)}},
+			Storage:   map[string]map[string][]byte{"contract": {"foo": []byte("bar")}},
+			Smart: func(contract string, msg []byte) ([]byte, error) {
+				return append([]byte(contract+":"), msg...), nil
+			},
+		},
+		Custom: NoCustom{},
+	}
+
+	specs := map[string]struct {
+		request types.QueryRequest
+		exp     string
+		expErr  error
+	}{
+		"bank supply": {
+			request: types.QueryRequest{Bank: &types.BankQuery{Supply: &types.SupplyQuery{Denom: "ATOM"}}},
+			exp:     `{"amount":{"denom":"ATOM","amount":"123"}}`,
+		},
+		"staking bonded denom": {
+			request: types.QueryRequest{Staking: &types.StakingQuery{BondedDenom: &struct{}{}}},
+			exp:     `{"denom":"stake"}`,
+		},
+		"staking validator": {
+			request: types.QueryRequest{Staking: &types.StakingQuery{Validator: &types.ValidatorQuery{Address: "val1"}}},
+			exp:     `{"validator":{"address":"val1","commission":"0.05","max_commission":"0.1","max_change_rate":"0.01"}}`,
+		},
+		"staking missing validator": {
+			request: types.QueryRequest{Staking: &types.StakingQuery{Validator: &types.ValidatorQuery{Address: "val2"}}},
+			exp:     `{"validator":null}`,
+		},
+		"staking all delegations": {
+			request: types.QueryRequest{Staking: &types.StakingQuery{AllDelegations: &types.AllDelegationsQuery{Delegator: "alice"}}},
+			exp:     `{"delegations":[{"delegator":"alice","validator":"val1","amount":{"denom":"stake","amount":"10"}}]}`,
+		},
+		"staking no delegations": {
+			request: types.QueryRequest{Staking: &types.StakingQuery{AllDelegations: &types.AllDelegationsQuery{Delegator: "bob"}}},
+			exp:     `{"delegations":[]}`,
+		},
+		"staking missing delegation": {
+			request: types.QueryRequest{Staking: &types.StakingQuery{Delegation: &types.DelegationQuery{Delegator: "bob", Validator: "val1"}}},
+			exp:     `{}`,
+		},
+		"distribution withdraw address": {
+			request: types.QueryRequest{Distribution: &types.DistributionQuery{DelegatorWithdrawAddress: &types.DelegatorWithdrawAddressQuery{DelegatorAddress: "alice"}}},
+			exp:     `{"withdraw_address":"bob"}`,
+		},
+		"distribution default withdraw address": {
+			request: types.QueryRequest{Distribution: &types.DistributionQuery{DelegatorWithdrawAddress: &types.DelegatorWithdrawAddressQuery{DelegatorAddress: "bob"}}},
+			exp:     `{"withdraw_address":"bob"}`,
+		},
+		"distribution total rewards": {
+			request: types.QueryRequest{Distribution: &types.DistributionQuery{DelegationTotalRewards: &types.DelegationTotalRewardsQuery{DelegatorAddress: "alice"}}},
+			exp:     `{"rewards":[{"reward":[{"amount":"1.5","denom":"stake"}],"validator_address":"val1"},{"reward":[{"amount":"2.75","denom":"stake"},{"amount":"0.1","denom":"ATOM"}],"validator_address":"val2"}],"total":[{"amount":"0.1","denom":"ATOM"},{"amount":"4.25","denom":"stake"}]}`,
+		},
+		"distribution delegator validators": {
+			request: types.QueryRequest{Distribution: &types.DistributionQuery{DelegatorValidators: &types.DelegatorValidatorsQuery{DelegatorAddress: "alice"}}},
+			exp:     `{"validators":["val1","val2"]}`,
+		},
+		"ibc port id": {
+			request: types.QueryRequest{IBC: &types.IBCQuery{PortID: &types.PortIDQuery{}}},
+			exp:     `{"port_id":"my_port"}`,
+		},
+		"ibc list channels of own port": {
+			request: types.QueryRequest{IBC: &types.IBCQuery{ListChannels: &types.ListChannelsQuery{}}},
+			exp:     `{"channels":[{"endpoint":{"port_id":"my_port","channel_id":"channel-1"},"counterparty_endpoint":{"port_id":"their_port","channel_id":"channel-7"},"order":"ORDER_ORDERED","version":"v1","connection_id":"connection-3"}]}`,
+		},
+		"ibc list channels of other port": {
+			request: types.QueryRequest{IBC: &types.IBCQuery{ListChannels: &types.ListChannelsQuery{PortID: "other"}}},
+			exp:     `{"channels":[]}`,
+		},
+		"ibc missing channel": {
+			request: types.QueryRequest{IBC: &types.IBCQuery{Channel: &types.ChannelQuery{ChannelID: "channel-2"}}},
+			exp:     `{}`,
+		},
+		"wasm smart": {
+			request: types.QueryRequest{Wasm: &types.WasmQuery{Smart: &types.SmartQuery{ContractAddr: "contract", Msg: []byte(`{}`)}}},
+			exp:     `"contract:{}"`,
+		},
+		"wasm raw": {
+			request: types.QueryRequest{Wasm: &types.WasmQuery{Raw: &types.RawQuery{ContractAddr: "contract", Key: []byte("foo")}}},
+			exp:     `"bar"`,
+		},
+		"wasm contract info": {
+			request: types.QueryRequest{Wasm: &types.WasmQuery{ContractInfo: &types.ContractInfoQuery{ContractAddr: "contract"}}},
+			exp:     `{"code_id":1,"creator":"alice","pinned":false}`,
+		},
+		"wasm missing contract": {
+			request: types.QueryRequest{Wasm: &types.WasmQuery{ContractInfo: &types.ContractInfoQuery{ContractAddr: "other"}}},
+			expErr:  types.NoSuchContract{Addr: "other"},
+		},
+		"wasm missing code": {
+			request: types.QueryRequest{Wasm: &types.WasmQuery{CodeInfo: &types.CodeInfoQuery{CodeID: 2}}},
+			expErr:  types.NoSuchCode{CodeID: 2},
+		},
+		"custom": {
+			request: types.QueryRequest{Custom: json.RawMessage(`{}`)},
+			expErr:  types.UnsupportedRequest{Kind: "custom"},
+		},
+		"stargate": {
+			request: types.QueryRequest{Stargate: &types.StargateQuery{Path: "/foo"}},
+			expErr:  types.UnsupportedRequest{Kind: "stargate"},
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			res, err := q.Query(spec.request, DefaultQuerierGasLimit)
+			if spec.expErr != nil {
+				require.Equal(t, spec.expErr, err)
+				return
+			}
+			require.NoError(t, err)
+			// raw and smart query results are not JSON
+			if spec.request.Wasm != nil && (spec.request.Wasm.Raw != nil || spec.request.Wasm.Smart != nil) {
+				bz, err := json.Marshal(string(res))
+				require.NoError(t, err)
+				res = bz
+			}
+			assert.JSONEq(t, spec.exp, string(res))
+		})
+	}
+	assert.NotZero(t, q.GasConsumed())
+}
+
+func TestBankQuerierDenomMetadata(t *testing.T) {
+	q := BankQuerier{DenomMetadata: map[string]types.DenomMetadata{
+		"uatom": {Base: "uatom", Display: "ATOM", DenomUnits: []types.DenomUnit{}},
+	}}
+
+	bz, err := q.Query(&types.BankQuery{DenomMetadata: &types.DenomMetadataQuery{Denom: "uatom"}})
+	require.NoError(t, err)
+	var resp types.DenomMetadataResponse
+	require.NoError(t, json.Unmarshal(bz, &resp))
+	assert.Equal(t, q.DenomMetadata["uatom"], resp.Metadata)
+
+	_, err = q.Query(&types.BankQuery{DenomMetadata: &types.DenomMetadataQuery{Denom: "uosmo"}})
+	require.EqualError(t, err, "denom metadata for uosmo not found")
+
+	bz, err = q.Query(&types.BankQuery{AllDenomMetadata: &types.AllDenomMetadataQuery{}})
+	require.NoError(t, err)
+	var all types.AllDenomMetadataResponse
+	require.NoError(t, json.Unmarshal(bz, &all))
+	assert.Equal(t, []types.DenomMetadata{q.DenomMetadata["uatom"]}, all.Metadata)
+	assert.Nil(t, all.NextKey)
+}
+
+func TestPaginate(t *testing.T) {
+	keys := []string{"a", "b", "c", "d", "e"}
+	specs := map[string]struct {
+		page    *types.PageRequest
+		exp     []string
+		expNext []byte
+	}{
+		"no pagination": {
+			exp: keys,
+		},
+		"limit": {
+			page:    &types.PageRequest{Limit: 2},
+			exp:     []string{"a", "b"},
+			expNext: []byte("c"),
+		},
+		"key": {
+			page:    &types.PageRequest{Key: []byte("c"), Limit: 2},
+			exp:     []string{"c", "d"},
+			expNext: []byte("e"),
+		},
+		"last page": {
+			page: &types.PageRequest{Key: []byte("d"), Limit: 2},
+			exp:  []string{"d", "e"},
+		},
+		"key between entries": {
+			page: &types.PageRequest{Key: []byte("bb")},
+			exp:  []string{"c", "d", "e"},
+		},
+		"reverse": {
+			page:    &types.PageRequest{Limit: 2, Reverse: true},
+			exp:     []string{"e", "d"},
+			expNext: []byte("c"),
+		},
+		"reverse with key": {
+			page: &types.PageRequest{Key: []byte("b"), Limit: 2, Reverse: true},
+			exp:  []string{"b", "a"},
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			page, next := paginate(keys, spec.page)
+			assert.Equal(t, spec.exp, page)
+			assert.Equal(t, spec.expNext, next)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+package testing
+
+import (
+	"github.com/CosmWasm/wasmvm/v2/internal/api/testdb"
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// MemDB is an in-memory database with the iterator semantics of types.KVStore.
+type MemDB = testdb.MemDB
+
+// MemDBSnapshot is an immutable point-in-time view of a MemDB as returned by MemDB.Snapshot.
+// It can be used to compare the state before and after a call.
+type MemDBSnapshot = testdb.Snapshot
+
+// NewMemDB creates a new, empty MemDB.
+func NewMemDB() *MemDB {
+	return testdb.NewMemDB()
+}
+
+// Much of this code is borrowed from Cosmos-SDK store/transient.go
+
+// Note: these gas prices are all in *wasmer gas* and (sdk gas * 100)
+//
+// We making simple values and non-clear multiples so it is easy to see their impact in test output
+// Also note we do not charge for each read on an iterator (out of simplicity and not needed for tests)
+const (
+	GetPrice    uint64 = 99000
+	SetPrice    uint64 = 187000
+	RemovePrice uint64 = 142000
+	RangePrice  uint64 = 261000
+)
+
+// Lookup is a types.KVStore backed by a MemDB that charges gas for every access.
+type Lookup struct {
+	db    *MemDB
+	meter MockGasMeter
+}
+
+var _ types.KVStore = (*Lookup)(nil)
+
+// NewLookup creates a Lookup on a new MemDB.
+func NewLookup(meter MockGasMeter) *Lookup {
+	return NewLookupWithDB(NewMemDB(), meter)
+}
+
+// NewLookupWithDB creates a Lookup on an existing MemDB.
+func NewLookupWithDB(db *MemDB, meter MockGasMeter) *Lookup {
+	return &Lookup{
+		db:    db,
+		meter: meter,
+	}
+}
+
+// DB returns the underlying database.
+func (l *Lookup) DB() *MemDB {
+	return l.db
+}
+
+func (l *Lookup) SetGasMeter(meter MockGasMeter) {
+	l.meter = meter
+}
+
+// WithGasMeter returns a Lookup on the same database using the given gas meter.
+func (l *Lookup) WithGasMeter(meter MockGasMeter) *Lookup {
+	return &Lookup{
+		db:    l.db,
+		meter: meter,
+	}
+}
+
+// Get wraps the underlying DB's Get method panicing on error.
+func (l Lookup) Get(key []byte) []byte {
+	l.meter.ConsumeGas(GetPrice, "get")
+	v, err := l.db.Get(key)
+	if err != nil {
+		panic(err)
+	}
+
+	return v
+}
+
+// Set wraps the underlying DB's Set method panicing on error.
+func (l Lookup) Set(key, value []byte) {
+	l.meter.ConsumeGas(SetPrice, "set")
+	if err := l.db.Set(key, value); err != nil {
+		panic(err)
+	}
+}
+
+// Delete wraps the underlying DB's Delete method panicing on error.
+func (l Lookup) Delete(key []byte) {
+	l.meter.ConsumeGas(RemovePrice, "remove")
+	if err := l.db.Delete(key); err != nil {
+		panic(err)
+	}
+}
+
+// Iterator wraps the underlying DB's Iterator method panicing on error.
+func (l Lookup) Iterator(start, end []byte) types.Iterator {
+	l.meter.ConsumeGas(RangePrice, "range")
+	iter, err := l.db.Iterator(start, end)
+	if err != nil {
+		panic(err)
+	}
+
+	return iter
+}
+
+// ReverseIterator wraps the underlying DB's ReverseIterator method panicing on error.
+func (l Lookup) ReverseIterator(start, end []byte) types.Iterator {
+	l.meter.ConsumeGas(RangePrice, "range")
+	iter, err := l.db.ReverseIterator(start, end)
+	if err != nil {
+		panic(err)
+	}
+
+	return iter
+}
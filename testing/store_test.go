@@ -0,0 +1,92 @@
+package testing
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookupChargesGas(t *testing.T) {
+	meter := NewMockGasMeter(GetPrice + SetPrice + RangePrice)
+	store := NewLookup(meter)
+
+	store.Set([]byte("foo"), []byte("bar"))
+	assert.Equal(t, SetPrice, meter.GasConsumed())
+	assert.Equal(t, []byte("bar"), store.Get([]byte("foo")))
+	assert.Equal(t, GetPrice+SetPrice, meter.GasConsumed())
+
+	it := store.Iterator(nil, nil)
+	require.True(t, it.Valid())
+	assert.Equal(t, []byte("foo"), it.Key())
+	require.NoError(t, it.Close())
+
+	// out of gas
+	assert.PanicsWithValue(t, ErrorOutOfGas{Descriptor: "remove"}, func() {
+		store.Delete([]byte("foo"))
+	})
+
+	// a store with a new gas meter shares the database
+	other := store.WithGasMeter(NewMockGasMeter(GetPrice))
+	assert.Equal(t, []byte("bar"), other.Get([]byte("foo")))
+	assert.Same(t, store.DB(), other.DB())
+}
+
+func TestMemDBSnapshot(t *testing.T) {
+	db := NewMemDB()
+	require.NoError(t, db.Set([]byte("a"), []byte("1")))
+	require.NoError(t, db.Set([]byte("b"), []byte("2")))
+
+	snapshot := db.Snapshot()
+	require.NoError(t, db.Set([]byte("a"), []byte("changed")))
+	require.NoError(t, db.Delete([]byte("b")))
+	require.NoError(t, db.Set([]byte("c"), []byte("3")))
+
+	v, err := snapshot.Get([]byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("1"), v)
+	has, err := snapshot.Has([]byte("c"))
+	require.NoError(t, err)
+	assert.False(t, has)
+	assert.Equal(t, 2, snapshot.Len())
+
+	it, err := snapshot.ReverseIterator(nil, nil)
+	require.NoError(t, err)
+	var keys []string
+	for ; it.Valid(); it.Next() {
+		keys = append(keys, string(it.Key())+"="+string(it.Value()))
+	}
+	require.NoError(t, it.Close())
+	assert.Equal(t, []string{"b=2", "a=1"}, keys)
+
+	v, err = db.Get([]byte("a"))
+	require.NoError(t, err)
+	assert.Equal(t, []byte("changed"), v)
+}
+
+func TestMemDBWriteWhileIterating(t *testing.T) {
+	store := NewLookup(NewMockGasMeter(1 << 62))
+	for _, k := range []string{"a", "b", "c"} {
+		store.Set([]byte(k), []byte("1"))
+	}
+
+	// this used to deadlock since the iterator held a read lock on the database
+	it := store.Iterator(nil, nil)
+	var keys []string
+	for ; it.Valid(); it.Next() {
+		store.Delete(it.Key())
+		store.Set(append([]byte("new-"), it.Key()...), []byte("2"))
+		keys = append(keys, string(it.Key()))
+	}
+	require.NoError(t, it.Close())
+	// the iterator does not see the changes
+	assert.Equal(t, []string{"a", "b", "c"}, keys)
+
+	it = store.Iterator(nil, nil)
+	keys = nil
+	for ; it.Valid(); it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+	require.NoError(t, it.Close())
+	assert.Equal(t, []string{"new-a", "new-b", "new-c"}, keys)
+}
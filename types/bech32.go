@@ -0,0 +1,153 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/CosmWasm/wasmvm/v2/internal/bech32"
+)
+
+// AddressCosts are the gas costs charged by the GoAPI returned by NewBech32GoAPI, in CosmWasm gas.
+type AddressCosts struct {
+	Humanize     Gas
+	Canonicalize Gas
+	Validate     Gas
+}
+
+// DefaultAddressCosts returns the address costs used in wasmd, i.e. 5 SDK gas for humanizing,
+// 4 SDK gas for canonicalizing and the sum of both for validating, converted with the default gas multiplier.
+func DefaultAddressCosts() AddressCosts {
+	return AddressCosts{
+		Humanize:     5 * Gas(DefaultGasMultiplier),
+		Canonicalize: 4 * Gas(DefaultGasMultiplier),
+		Validate:     9 * Gas(DefaultGasMultiplier),
+	}
+}
+
+// maxBech32CacheEntries limits the memory used by the cache of a bech32 GoAPI
+const maxBech32CacheEntries = 256
+
+// NewBech32GoAPI returns a GoAPI for bech32 addresses with the given prefix, e.g. "cosmos".
+//
+// Canonical addresses must have 20 or 32 bytes. Human readable addresses are accepted in
+// lowercase and uppercase by CanonicalizeAddress, but ValidateAddress only accepts the
+// normalized lowercase form, like addr_validate in cosmwasm-std.
+//
+// The functions of the returned GoAPI memoize their results. Create a new GoAPI for each
+// contract call to limit the cache to that call. The gas charged does not depend on the
+// cache, so it is deterministic.
+func NewBech32GoAPI(prefix string, costs AddressCosts) GoAPI {
+	a := &bech32API{
+		prefix: strings.ToLower(prefix),
+		costs:  costs,
+		canon:  make(map[string][]byte),
+		human:  make(map[string]string),
+	}
+	return GoAPI{
+		HumanizeAddress:     a.humanize,
+		CanonicalizeAddress: a.canonicalize,
+		ValidateAddress:     a.validate,
+	}
+}
+
+type bech32API struct {
+	prefix string
+	costs  AddressCosts
+
+	mu sync.Mutex
+	// canon caches canonical addresses by human address
+	canon map[string][]byte
+	// human caches human addresses by canonical address
+	human map[string]string
+}
+
+func (a *bech32API) humanize(canon []byte) (string, uint64, error) {
+	human, err := a.toHuman(canon)
+	return human, a.costs.Humanize, err
+}
+
+func (a *bech32API) canonicalize(human string) ([]byte, uint64, error) {
+	canon, err := a.toCanonical(human)
+	if err != nil {
+		return nil, a.costs.Canonicalize, err
+	}
+	// the caller owns the result
+	return append([]byte(nil), canon...), a.costs.Canonicalize, nil
+}
+
+func (a *bech32API) validate(human string) (uint64, error) {
+	canon, err := a.toCanonical(human)
+	if err != nil {
+		return a.costs.Validate, err
+	}
+	normalized, err := a.toHuman(canon)
+	if err != nil {
+		return a.costs.Validate, err
+	}
+	if normalized != human {
+		return a.costs.Validate, fmt.Errorf("address not normalized: expected %s", normalized)
+	}
+	return a.costs.Validate, nil
+}
+
+// toCanonical decodes human. The result must not be modified.
+func (a *bech32API) toCanonical(human string) ([]byte, error) {
+	a.mu.Lock()
+	canon, ok := a.canon[human]
+	a.mu.Unlock()
+	if ok {
+		return canon, nil
+	}
+
+	prefix, canon, err := bech32.Decode(human)
+	if err != nil {
+		return nil, fmt.Errorf("invalid bech32 address: %w", err)
+	}
+	if prefix != a.prefix {
+		return nil, fmt.Errorf("invalid bech32 prefix: expected %s, got %s", a.prefix, prefix)
+	}
+	if err := validateCanonicalLength(canon); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.canon) >= maxBech32CacheEntries {
+		clear(a.canon)
+	}
+	a.canon[human] = canon
+	return canon, nil
+}
+
+func (a *bech32API) toHuman(canon []byte) (string, error) {
+	if err := validateCanonicalLength(canon); err != nil {
+		return "", err
+	}
+	a.mu.Lock()
+	human, ok := a.human[string(canon)]
+	a.mu.Unlock()
+	if ok {
+		return human, nil
+	}
+
+	human, err := bech32.Encode(a.prefix, canon)
+	if err != nil {
+		return "", fmt.Errorf("cannot encode bech32 address: %w", err)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.human) >= maxBech32CacheEntries {
+		clear(a.human)
+	}
+	a.human[string(canon)] = human
+	return human, nil
+}
+
+func validateCanonicalLength(canon []byte) error {
+	if len(canon) != 20 && len(canon) != 32 {
+		return fmt.Errorf("invalid canonical address length %d: expected 20 or 32 bytes", len(canon))
+	}
+	return nil
+}
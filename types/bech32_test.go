@@ -0,0 +1,97 @@
+package types
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CosmWasm/wasmvm/v2/internal/bech32"
+)
+
+func TestBech32GoAPIRoundTrip(t *testing.T) {
+	costs := AddressCosts{Humanize: 1, Canonicalize: 2, Validate: 3}
+	api := NewBech32GoAPI("cosmos", costs)
+
+	specs := map[string]struct {
+		canon []byte
+		human string
+	}{
+		"20 bytes": {
+			canon: make([]byte, 20),
+			human: "cosmos1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqnrql8a",
+		},
+		"32 bytes": {
+			canon: bytes.Repeat([]byte{0xab}, 32),
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			human, cost, err := api.HumanizeAddress(spec.canon)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(1), cost)
+			assert.True(t, strings.HasPrefix(human, "cosmos1"))
+			if spec.human != "" {
+				assert.Equal(t, spec.human, human)
+			}
+
+			canon, cost, err := api.CanonicalizeAddress(human)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(2), cost)
+			assert.Equal(t, spec.canon, canon)
+
+			// uppercase is accepted for canonicalization
+			canon, _, err = api.CanonicalizeAddress(strings.ToUpper(human))
+			require.NoError(t, err)
+			assert.Equal(t, spec.canon, canon)
+
+			cost, err = api.ValidateAddress(human)
+			require.NoError(t, err)
+			assert.Equal(t, uint64(3), cost)
+		})
+	}
+}
+
+func TestBech32GoAPIErrors(t *testing.T) {
+	costs := DefaultAddressCosts()
+	api := NewBech32GoAPI("cosmos", costs)
+	valid := "cosmos1qqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqnrql8a"
+	osmo, err := bech32.Encode("osmo", make([]byte, 20))
+	require.NoError(t, err)
+	wrongLength, err := bech32.Encode("cosmos", make([]byte, 21))
+	require.NoError(t, err)
+
+	specs := map[string]struct {
+		human  string
+		expErr string
+	}{
+		"empty":        {human: "", expErr: "invalid bech32 address: string too short"},
+		"mock address": {human: "creator", expErr: "invalid bech32 address: string too short"},
+		"wrong prefix": {human: osmo, expErr: "invalid bech32 prefix: expected cosmos, got osmo"},
+		"bad checksum": {human: valid[:len(valid)-1] + "q", expErr: "invalid bech32 address: invalid checksum"},
+		"mixed case":   {human: "Cosmos" + valid[6:], expErr: "invalid bech32 address: string has mixed case"},
+		"wrong length": {human: wrongLength, expErr: "invalid canonical address length 21: expected 20 or 32 bytes"},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			_, cost, err := api.CanonicalizeAddress(spec.human)
+			require.EqualError(t, err, spec.expErr)
+			assert.Equal(t, costs.Canonicalize, cost)
+
+			cost, err = api.ValidateAddress(spec.human)
+			require.EqualError(t, err, spec.expErr)
+			assert.Equal(t, costs.Validate, cost)
+		})
+	}
+
+	// uppercase addresses are not normalized
+	cost, err := api.ValidateAddress(strings.ToUpper(valid))
+	require.EqualError(t, err, "address not normalized: expected "+valid)
+	assert.Equal(t, costs.Validate, cost)
+
+	_, cost, err = api.HumanizeAddress(make([]byte, 21))
+	require.EqualError(t, err, "invalid canonical address length 21: expected 20 or 32 bytes")
+	assert.Equal(t, costs.Humanize, cost)
+}
@@ -0,0 +1,418 @@
+package types
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// DecimalPlaces is the number of decimal places of Decimal and Decimal256.
+const DecimalPlaces = 18
+
+// decimalFractional is 10^DecimalPlaces, i.e. the atomics of 1
+var decimalFractional = new(big.Int).Exp(big.NewInt(10), big.NewInt(DecimalPlaces), nil)
+
+// Decimal is a fixed-point decimal with the semantics of cosmwasm_std::Decimal.
+// It is stored as a Uint128 of atomics with 18 decimal places, so its range is
+// 0 to 340282366920938463463.374607431768211455. Like in cosmwasm-std, it is encoded
+// as a decimal string such as "0.25" in JSON. The zero value is 0.
+type Decimal struct {
+	atomics Uint128
+}
+
+// NewDecimal creates a Decimal from its atomics, i.e. the value multiplied by 10^18.
+func NewDecimal(atomics Uint128) Decimal {
+	return Decimal{atomics: atomics}
+}
+
+// DecimalOne returns the Decimal 1.
+func DecimalOne() Decimal {
+	return DecimalPercent(100)
+}
+
+// DecimalPercent returns the Decimal x/100.
+func DecimalPercent(x uint64) Decimal {
+	var d Decimal
+	// cannot overflow since 10^16 * (2^64 - 1) < 2^128
+	_ = scaleLimbs(d.atomics.limbs[:], []uint64{x}, DecimalPlaces-2)
+	return d
+}
+
+// DecimalPermille returns the Decimal x/1000.
+func DecimalPermille(x uint64) Decimal {
+	var d Decimal
+	_ = scaleLimbs(d.atomics.limbs[:], []uint64{x}, DecimalPlaces-3)
+	return d
+}
+
+// MaxDecimal returns the largest Decimal.
+func MaxDecimal() Decimal {
+	return Decimal{atomics: MaxUint128()}
+}
+
+// DecimalFromAtomics creates a Decimal from atomics with the given number of decimal places,
+// e.g. (1234, 3) is 1.234. Like in cosmwasm-std, digits beyond 18 decimal places are truncated.
+// It returns an OverflowError if the value is too large.
+func DecimalFromAtomics(atomics Uint128, decimalPlaces uint32) (Decimal, error) {
+	var d Decimal
+	err := scaleLimbs(d.atomics.limbs[:], atomics.limbs[:], DecimalPlaces-int64(decimalPlaces))
+	return d, err
+}
+
+// DecimalFromRatio returns numerator/denominator, rounded down.
+// It returns a DivideByZeroError or an OverflowError.
+func DecimalFromRatio(numerator, denominator Uint128) (Decimal, error) {
+	var d Decimal
+	err := ratioLimbs(d.atomics.limbs[:], numerator.limbs[:], denominator.limbs[:])
+	return d, err
+}
+
+// ParseDecimal parses a decimal string like cosmwasm_std::Decimal::from_str, e.g. "1.25".
+// At most 18 fractional digits are accepted.
+func ParseDecimal(s string) (Decimal, error) {
+	var d Decimal
+	err := parseDecimalLimbs(s, d.atomics.limbs[:], func(part string, limbs []uint64) error {
+		u, err := ParseUint128(part)
+		copy(limbs, u.limbs[:])
+		return err
+	})
+	return d, err
+}
+
+// Atomics returns the value multiplied by 10^18.
+func (d Decimal) Atomics() Uint128 {
+	return d.atomics
+}
+
+func (d Decimal) IsZero() bool {
+	return d.atomics.IsZero()
+}
+
+// Cmp returns -1, 0 or +1 if d is less than, equal to or greater than o.
+func (d Decimal) Cmp(o Decimal) int {
+	return d.atomics.Cmp(o.atomics)
+}
+
+func (d Decimal) CheckedAdd(o Decimal) (Decimal, error) {
+	atomics, err := d.atomics.CheckedAdd(o.atomics)
+	return Decimal{atomics: atomics}, err
+}
+
+func (d Decimal) CheckedSub(o Decimal) (Decimal, error) {
+	atomics, err := d.atomics.CheckedSub(o.atomics)
+	return Decimal{atomics: atomics}, err
+}
+
+// CheckedMul returns d*o, rounded down.
+func (d Decimal) CheckedMul(o Decimal) (Decimal, error) {
+	var z Decimal
+	err := decimalMulLimbs(z.atomics.limbs[:], d.atomics.limbs[:], o.atomics.limbs[:])
+	return z, err
+}
+
+// CheckedDiv returns d/o, rounded down.
+func (d Decimal) CheckedDiv(o Decimal) (Decimal, error) {
+	var z Decimal
+	err := ratioLimbs(z.atomics.limbs[:], d.atomics.limbs[:], o.atomics.limbs[:])
+	return z, err
+}
+
+// Floor returns the largest integer not greater than d.
+func (d Decimal) Floor() Decimal {
+	var z Decimal
+	whole := d.ToUintFloor()
+	// cannot overflow since it is the integer part of a Decimal
+	_ = scaleLimbs(z.atomics.limbs[:], whole.limbs[:], DecimalPlaces)
+	return z
+}
+
+// ToUintFloor returns the integer part of d.
+func (d Decimal) ToUintFloor() Uint128 {
+	var u Uint128
+	floorLimbs(u.limbs[:], d.atomics.limbs[:])
+	return u
+}
+
+// ToUintCeil returns the smallest integer not less than d.
+func (d Decimal) ToUintCeil() Uint128 {
+	var u Uint128
+	ceilLimbs(u.limbs[:], d.atomics.limbs[:])
+	return u
+}
+
+func (d Decimal) String() string {
+	return formatDecimalLimbs(d.atomics.limbs[:])
+}
+
+func (d Decimal) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	s, err := unmarshalNumberString(data, "Decimal")
+	if err != nil {
+		return err
+	}
+	*d, err = ParseDecimal(s)
+	return err
+}
+
+// Decimal256 is a fixed-point decimal with the semantics of cosmwasm_std::Decimal256.
+// It is stored as a Uint256 of atomics with 18 decimal places. Like in cosmwasm-std, it is
+// encoded as a decimal string such as "0.25" in JSON. The zero value is 0.
+type Decimal256 struct {
+	atomics Uint256
+}
+
+// NewDecimal256 creates a Decimal256 from its atomics, i.e. the value multiplied by 10^18.
+func NewDecimal256(atomics Uint256) Decimal256 {
+	return Decimal256{atomics: atomics}
+}
+
+// Decimal256One returns the Decimal256 1.
+func Decimal256One() Decimal256 {
+	return Decimal256Percent(100)
+}
+
+// Decimal256Percent returns the Decimal256 x/100.
+func Decimal256Percent(x uint64) Decimal256 {
+	var d Decimal256
+	// cannot overflow since 10^16 * (2^64 - 1) < 2^256
+	_ = scaleLimbs(d.atomics.limbs[:], []uint64{x}, DecimalPlaces-2)
+	return d
+}
+
+// Decimal256Permille returns the Decimal256 x/1000.
+func Decimal256Permille(x uint64) Decimal256 {
+	var d Decimal256
+	_ = scaleLimbs(d.atomics.limbs[:], []uint64{x}, DecimalPlaces-3)
+	return d
+}
+
+// MaxDecimal256 returns the largest Decimal256.
+func MaxDecimal256() Decimal256 {
+	return Decimal256{atomics: MaxUint256()}
+}
+
+// Decimal256FromDecimal converts a Decimal to a Decimal256, which never overflows.
+func Decimal256FromDecimal(d Decimal) Decimal256 {
+	return Decimal256{atomics: Uint256FromUint128(d.atomics)}
+}
+
+// Decimal256FromAtomics creates a Decimal256 from atomics with the given number of decimal places,
+// e.g. (1234, 3) is 1.234. Like in cosmwasm-std, digits beyond 18 decimal places are truncated.
+// It returns an OverflowError if the value is too large.
+func Decimal256FromAtomics(atomics Uint256, decimalPlaces uint32) (Decimal256, error) {
+	var d Decimal256
+	err := scaleLimbs(d.atomics.limbs[:], atomics.limbs[:], DecimalPlaces-int64(decimalPlaces))
+	return d, err
+}
+
+// Decimal256FromRatio returns numerator/denominator, rounded down.
+// It returns a DivideByZeroError or an OverflowError.
+func Decimal256FromRatio(numerator, denominator Uint256) (Decimal256, error) {
+	var d Decimal256
+	err := ratioLimbs(d.atomics.limbs[:], numerator.limbs[:], denominator.limbs[:])
+	return d, err
+}
+
+// ParseDecimal256 parses a decimal string like cosmwasm_std::Decimal256::from_str, e.g. "1.25".
+// At most 18 fractional digits are accepted.
+func ParseDecimal256(s string) (Decimal256, error) {
+	var d Decimal256
+	err := parseDecimalLimbs(s, d.atomics.limbs[:], func(part string, limbs []uint64) error {
+		u, err := ParseUint256(part)
+		copy(limbs, u.limbs[:])
+		return err
+	})
+	return d, err
+}
+
+// Atomics returns the value multiplied by 10^18.
+func (d Decimal256) Atomics() Uint256 {
+	return d.atomics
+}
+
+// Decimal converts the value to a Decimal. It returns an OverflowError if it does not fit.
+func (d Decimal256) Decimal() (Decimal, error) {
+	atomics, err := d.atomics.Uint128()
+	return Decimal{atomics: atomics}, err
+}
+
+func (d Decimal256) IsZero() bool {
+	return d.atomics.IsZero()
+}
+
+// Cmp returns -1, 0 or +1 if d is less than, equal to or greater than o.
+func (d Decimal256) Cmp(o Decimal256) int {
+	return d.atomics.Cmp(o.atomics)
+}
+
+func (d Decimal256) CheckedAdd(o Decimal256) (Decimal256, error) {
+	atomics, err := d.atomics.CheckedAdd(o.atomics)
+	return Decimal256{atomics: atomics}, err
+}
+
+func (d Decimal256) CheckedSub(o Decimal256) (Decimal256, error) {
+	atomics, err := d.atomics.CheckedSub(o.atomics)
+	return Decimal256{atomics: atomics}, err
+}
+
+// CheckedMul returns d*o, rounded down.
+func (d Decimal256) CheckedMul(o Decimal256) (Decimal256, error) {
+	var z Decimal256
+	err := decimalMulLimbs(z.atomics.limbs[:], d.atomics.limbs[:], o.atomics.limbs[:])
+	return z, err
+}
+
+// CheckedDiv returns d/o, rounded down.
+func (d Decimal256) CheckedDiv(o Decimal256) (Decimal256, error) {
+	var z Decimal256
+	err := ratioLimbs(z.atomics.limbs[:], d.atomics.limbs[:], o.atomics.limbs[:])
+	return z, err
+}
+
+// Floor returns the largest integer not greater than d.
+func (d Decimal256) Floor() Decimal256 {
+	var z Decimal256
+	whole := d.ToUintFloor()
+	// cannot overflow since it is the integer part of a Decimal256
+	_ = scaleLimbs(z.atomics.limbs[:], whole.limbs[:], DecimalPlaces)
+	return z
+}
+
+// ToUintFloor returns the integer part of d.
+func (d Decimal256) ToUintFloor() Uint256 {
+	var u Uint256
+	floorLimbs(u.limbs[:], d.atomics.limbs[:])
+	return u
+}
+
+// ToUintCeil returns the smallest integer not less than d.
+func (d Decimal256) ToUintCeil() Uint256 {
+	var u Uint256
+	ceilLimbs(u.limbs[:], d.atomics.limbs[:])
+	return u
+}
+
+func (d Decimal256) String() string {
+	return formatDecimalLimbs(d.atomics.limbs[:])
+}
+
+func (d Decimal256) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.String())
+}
+
+func (d *Decimal256) UnmarshalJSON(data []byte) error {
+	s, err := unmarshalNumberString(data, "Decimal256")
+	if err != nil {
+		return err
+	}
+	*d, err = ParseDecimal256(s)
+	return err
+}
+
+// parseDecimalLimbs implements the parsing of cosmwasm_std::Decimal::from_str. The whole and
+// fractional parts are parsed with parseUint, which writes a value of the same size as atomics.
+func parseDecimalLimbs(s string, atomics []uint64, parseUint func(string, []uint64) error) error {
+	parts := strings.Split(s, ".")
+	part := make([]uint64, len(atomics))
+	if err := parseUint(parts[0], part); err != nil {
+		return errors.New("Error parsing whole")
+	}
+	if err := scaleLimbs(atomics, part, DecimalPlaces); err != nil {
+		return errors.New("Value too big")
+	}
+
+	if len(parts) > 1 {
+		fractionalPart := parts[1]
+		if err := parseUint(fractionalPart, part); err != nil {
+			return errors.New("Error parsing fractional")
+		}
+		if len(fractionalPart) > DecimalPlaces {
+			return fmt.Errorf("Cannot parse more than %d fractional digits", DecimalPlaces)
+		}
+		// cannot overflow since the fractional part is less than 10^18
+		_ = scaleLimbs(part, part, int64(DecimalPlaces-len(fractionalPart)))
+		if addLimbs(atomics, atomics, part) != 0 {
+			return errors.New("Value too big")
+		}
+	}
+
+	// checked last in cosmwasm-std, so the first two parts are validated before
+	if len(parts) > 2 {
+		return errors.New("Unexpected number of dots")
+	}
+	return nil
+}
+
+// formatDecimalLimbs formats atomics like cosmwasm_std::Decimal, i.e. without trailing zeros
+// in the fractional part and without a fractional part for integers.
+func formatDecimalLimbs(atomics []uint64) string {
+	whole, fractional := new(big.Int).QuoRem(limbsToBig(atomics), decimalFractional, new(big.Int))
+	if fractional.Sign() == 0 {
+		return whole.String()
+	}
+	fractionalStr := fmt.Sprintf("%0*s", DecimalPlaces, fractional.String())
+	return whole.String() + "." + strings.TrimRight(fractionalStr, "0")
+}
+
+// scaleLimbs sets z to x * 10^exp, rounding down for negative exponents.
+// It returns an OverflowError if the result does not fit into z.
+func scaleLimbs(z, x []uint64, exp int64) error {
+	v := limbsToBig(x)
+	switch {
+	case exp >= 0:
+		v.Mul(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(exp), nil))
+	case -exp > 20*int64(len(x)):
+		// 10^(-exp) is larger than any value of x
+		clear(z)
+		return nil
+	default:
+		v.Quo(v, new(big.Int).Exp(big.NewInt(10), big.NewInt(-exp), nil))
+	}
+	if !bigToLimbs(v, z) {
+		return OverflowError{Operation: "Mul"}
+	}
+	return nil
+}
+
+// decimalMulLimbs multiplies two decimals given by their atomics, rounding down.
+func decimalMulLimbs(z, x, y []uint64) error {
+	v := new(big.Int).Mul(limbsToBig(x), limbsToBig(y))
+	v.Quo(v, decimalFractional)
+	if !bigToLimbs(v, z) {
+		return OverflowError{Operation: "Mul"}
+	}
+	return nil
+}
+
+// ratioLimbs sets z to the atomics of numerator/denominator, rounding down.
+func ratioLimbs(z, numerator, denominator []uint64) error {
+	d := limbsToBig(denominator)
+	if d.Sign() == 0 {
+		return DivideByZeroError{}
+	}
+	v := new(big.Int).Mul(limbsToBig(numerator), decimalFractional)
+	v.Quo(v, d)
+	if !bigToLimbs(v, z) {
+		return OverflowError{Operation: "Div"}
+	}
+	return nil
+}
+
+func floorLimbs(z, atomics []uint64) {
+	// never overflows since the result is smaller than atomics
+	bigToLimbs(new(big.Int).Quo(limbsToBig(atomics), decimalFractional), z)
+}
+
+func ceilLimbs(z, atomics []uint64) {
+	whole, fractional := new(big.Int).QuoRem(limbsToBig(atomics), decimalFractional, new(big.Int))
+	if fractional.Sign() != 0 {
+		whole.Add(whole, big.NewInt(1))
+	}
+	// never overflows since the whole part of the maximum is much smaller than the maximum
+	bigToLimbs(whole, z)
+}
@@ -0,0 +1,220 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseDecimal(t *testing.T) {
+	specs := map[string]struct {
+		input  string
+		exp    string
+		expErr string
+	}{
+		"integer":               {input: "42", exp: "42"},
+		"fraction":              {input: "1.5", exp: "1.5"},
+		"trailing zeros":        {input: "1.500", exp: "1.5"},
+		"zero fraction":         {input: "7.0", exp: "7"},
+		"smallest":              {input: "0.000000000000000001", exp: "0.000000000000000001"},
+		"leading zeros":         {input: "007.25", exp: "7.25"},
+		"plus sign":             {input: "+0.25", exp: "0.25"},
+		"max":                   {input: "340282366920938463463.374607431768211455", exp: "340282366920938463463.374607431768211455"},
+		"too many digits":       {input: "1.0000000000000000001", expErr: "Cannot parse more than 18 fractional digits"},
+		"whole too big":         {input: "340282366920938463464", expErr: "Value too big"},
+		"sum too big":           {input: "340282366920938463463.374607431768211456", expErr: "Value too big"},
+		"empty":                 {input: "", expErr: "Error parsing whole"},
+		"missing whole":         {input: ".5", expErr: "Error parsing whole"},
+		"missing fractional":    {input: "1.", expErr: "Error parsing fractional"},
+		"negative":              {input: "-1", expErr: "Error parsing whole"},
+		"exponent":              {input: "1e5", expErr: "Error parsing whole"},
+		"multiple dots":         {input: "1.2.3", expErr: "Unexpected number of dots"},
+		"invalid after two dot": {input: "1.x.3", expErr: "Error parsing fractional"},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseDecimal(spec.input)
+			if spec.expErr != "" {
+				require.EqualError(t, err, spec.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, spec.exp, got.String())
+		})
+	}
+}
+
+func TestParseDecimal256(t *testing.T) {
+	const max = "115792089237316195423570985008687907853269984665640564039457.584007913129639935"
+	got, err := ParseDecimal256(max)
+	require.NoError(t, err)
+	assert.Equal(t, MaxDecimal256(), got)
+	assert.Equal(t, max, got.String())
+
+	_, err = ParseDecimal256("115792089237316195423570985008687907853269984665640564039457.584007913129639936")
+	require.EqualError(t, err, "Value too big")
+	// beyond the range of Decimal
+	got, err = ParseDecimal256("340282366920938463464")
+	require.NoError(t, err)
+	_, err = got.Decimal()
+	require.Equal(t, OverflowError{Operation: "Conversion"}, err)
+}
+
+func TestDecimalConstructors(t *testing.T) {
+	assert.Equal(t, "1", DecimalOne().String())
+	assert.Equal(t, "0.25", DecimalPercent(25).String())
+	assert.Equal(t, "0.025", DecimalPermille(25).String())
+	assert.Equal(t, "340282366920938463463.374607431768211455", MaxDecimal().String())
+	assert.Equal(t, NewUint128(1_000_000_000_000_000_000), DecimalOne().Atomics())
+	assert.Equal(t, "0.000000000000000123", NewDecimal(NewUint128(123)).String())
+	assert.Equal(t, "1", Decimal256One().String())
+	assert.Equal(t, "2.5", Decimal256Percent(250).String())
+	assert.Equal(t, "0.001", Decimal256Permille(1).String())
+	assert.Equal(t, "0.25", Decimal256FromDecimal(DecimalPercent(25)).String())
+
+	specs := map[string]struct {
+		atomics       uint64
+		decimalPlaces uint32
+		exp           string
+	}{
+		"integer":          {atomics: 1234, decimalPlaces: 0, exp: "1234"},
+		"fraction":         {atomics: 1234, decimalPlaces: 3, exp: "1.234"},
+		"18 places":        {atomics: 1, decimalPlaces: 18, exp: "0.000000000000000001"},
+		"truncated":        {atomics: 1234, decimalPlaces: 20, exp: "0.000000000000000012"},
+		"truncated to 0":   {atomics: 1234, decimalPlaces: 22, exp: "0"},
+		"huge places":      {atomics: 1234, decimalPlaces: 4_000_000_000, exp: "0"},
+		"zero with places": {atomics: 0, decimalPlaces: 5, exp: "0"},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			got, err := DecimalFromAtomics(NewUint128(spec.atomics), spec.decimalPlaces)
+			require.NoError(t, err)
+			assert.Equal(t, spec.exp, got.String())
+			got256, err := Decimal256FromAtomics(NewUint256(spec.atomics), spec.decimalPlaces)
+			require.NoError(t, err)
+			assert.Equal(t, spec.exp, got256.String())
+		})
+	}
+	_, err := DecimalFromAtomics(MaxUint128(), 17)
+	require.Equal(t, OverflowError{Operation: "Mul"}, err)
+
+	third, err := DecimalFromRatio(NewUint128(1), NewUint128(3))
+	require.NoError(t, err)
+	assert.Equal(t, "0.333333333333333333", third.String())
+	_, err = DecimalFromRatio(NewUint128(1), NewUint128(0))
+	require.Equal(t, DivideByZeroError{}, err)
+	_, err = DecimalFromRatio(MaxUint128(), NewUint128(1))
+	require.Equal(t, OverflowError{Operation: "Div"}, err)
+	third256, err := Decimal256FromRatio(NewUint256(1), NewUint256(3))
+	require.NoError(t, err)
+	assert.Equal(t, "0.333333333333333333", third256.String())
+}
+
+func mustParseDecimal(t *testing.T, s string) Decimal {
+	t.Helper()
+	d, err := ParseDecimal(s)
+	require.NoError(t, err)
+	return d
+}
+
+func TestDecimalArithmetic(t *testing.T) {
+	a := mustParseDecimal(t, "1.5")
+	b := mustParseDecimal(t, "0.25")
+
+	sum, err := a.CheckedAdd(b)
+	require.NoError(t, err)
+	assert.Equal(t, "1.75", sum.String())
+	_, err = MaxDecimal().CheckedAdd(NewDecimal(NewUint128(1)))
+	require.Equal(t, OverflowError{Operation: "Add"}, err)
+
+	diff, err := a.CheckedSub(b)
+	require.NoError(t, err)
+	assert.Equal(t, "1.25", diff.String())
+	_, err = b.CheckedSub(a)
+	require.Equal(t, OverflowError{Operation: "Sub"}, err)
+
+	product, err := a.CheckedMul(b)
+	require.NoError(t, err)
+	assert.Equal(t, "0.375", product.String())
+	// rounds down
+	product, err = mustParseDecimal(t, "0.000000000000000001").CheckedMul(b)
+	require.NoError(t, err)
+	assert.True(t, product.IsZero())
+	// uses a 256-bit intermediate
+	product, err = MaxDecimal().CheckedMul(DecimalOne())
+	require.NoError(t, err)
+	assert.Equal(t, MaxDecimal(), product)
+	_, err = MaxDecimal().CheckedMul(DecimalPercent(101))
+	require.Equal(t, OverflowError{Operation: "Mul"}, err)
+
+	quo, err := a.CheckedDiv(b)
+	require.NoError(t, err)
+	assert.Equal(t, "6", quo.String())
+	_, err = a.CheckedDiv(Decimal{})
+	require.Equal(t, DivideByZeroError{}, err)
+
+	assert.Equal(t, 1, a.Cmp(b))
+	assert.Equal(t, -1, b.Cmp(a))
+	assert.Equal(t, 0, a.Cmp(mustParseDecimal(t, "1.50")))
+
+	assert.Equal(t, "1", a.Floor().String())
+	assert.Equal(t, NewUint128(1), a.ToUintFloor())
+	assert.Equal(t, NewUint128(2), a.ToUintCeil())
+	assert.Equal(t, NewUint128(1), DecimalOne().ToUintCeil())
+	assert.Equal(t, "340282366920938463463", MaxDecimal().Floor().String())
+	assert.Equal(t, "340282366920938463464", MaxDecimal().ToUintCeil().String())
+
+	a256 := Decimal256FromDecimal(a)
+	product256, err := a256.CheckedMul(Decimal256FromDecimal(b))
+	require.NoError(t, err)
+	assert.Equal(t, "0.375", product256.String())
+	_, err = MaxDecimal256().CheckedMul(Decimal256Percent(101))
+	require.Equal(t, OverflowError{Operation: "Mul"}, err)
+	assert.Equal(t, "115792089237316195423570985008687907853269984665640564039458", MaxDecimal256().ToUintCeil().String())
+}
+
+func TestDecimalJSON(t *testing.T) {
+	type rates struct {
+		Rate   Decimal    `json:"rate"`
+		Amount Decimal256 `json:"amount"`
+		Zero   Decimal    `json:"zero"`
+	}
+	const doc = `{"rate":"0.05","amount":"1234.5","zero":"0"}`
+
+	var got rates
+	require.NoError(t, json.Unmarshal([]byte(doc), &got))
+	assert.Equal(t, "0.05", got.Rate.String())
+	assert.Equal(t, "1234.5", got.Amount.String())
+
+	bz, err := json.Marshal(got)
+	require.NoError(t, err)
+	assert.Equal(t, doc, string(bz))
+
+	err = json.Unmarshal([]byte(`{"rate":0.05}`), &got)
+	require.ErrorContains(t, err, "invalid Decimal: expected a string")
+	err = json.Unmarshal([]byte(`{"amount":"1.2.3"}`), &got)
+	require.EqualError(t, err, "Unexpected number of dots")
+}
+
+func TestDecimalFieldAccessors(t *testing.T) {
+	amount, err := DecCoin{Amount: "340282366920938463464.5", Denom: "atoken"}.ParseAmount()
+	require.NoError(t, err)
+	assert.Equal(t, "340282366920938463464.5", amount.String())
+
+	weight, err := WeightedVoteOption{Option: Yes, Weight: "0.25"}.ParseWeight()
+	require.NoError(t, err)
+	assert.Equal(t, DecimalPercent(25), weight)
+
+	validator := Validator{Commission: "0.05", MaxCommission: "0.2", MaxChangeRate: "0.01"}
+	commission, err := validator.ParseCommission()
+	require.NoError(t, err)
+	assert.Equal(t, DecimalPercent(5), commission)
+	maxCommission, err := validator.ParseMaxCommission()
+	require.NoError(t, err)
+	assert.Equal(t, DecimalPercent(20), maxCommission)
+	maxChangeRate, err := validator.ParseMaxChangeRate()
+	require.NoError(t, err)
+	assert.Equal(t, DecimalPercent(1), maxChangeRate)
+}
@@ -41,3 +41,40 @@ type MessageInfo struct {
 	// Amount of funds send to the contract along with this message
 	Funds Array[Coin] `json:"funds"`
 }
+
+// MarshalJSON encodes Env without reflection. Env is serialized for every contract call,
+// so this is on the hot path. The output is identical to the default encoding/json output.
+func (e Env) MarshalJSON() ([]byte, error) {
+	return e.appendJSON(make([]byte, 0, 256)), nil
+}
+
+func (e Env) appendJSON(dst []byte) []byte {
+	dst = append(dst, `{"block":{"height":`...)
+	dst = appendJSONUint64(dst, e.Block.Height)
+	dst = append(dst, `,"time":`...)
+	dst = appendJSONQuotedUint64(dst, uint64(e.Block.Time))
+	dst = append(dst, `,"chain_id":`...)
+	dst = appendJSONString(dst, e.Block.ChainID)
+	dst = append(dst, `},"transaction":`...)
+	if e.Transaction == nil {
+		dst = append(dst, "null"...)
+	} else {
+		dst = append(dst, `{"index":`...)
+		dst = appendJSONUint64(dst, uint64(e.Transaction.Index))
+		dst = append(dst, '}')
+	}
+	dst = append(dst, `,"contract":{"address":`...)
+	dst = appendJSONString(dst, e.Contract.Address)
+	return append(dst, "}}"...)
+}
+
+// MarshalJSON encodes MessageInfo without reflection. The output is identical to the
+// default encoding/json output.
+func (m MessageInfo) MarshalJSON() ([]byte, error) {
+	dst := make([]byte, 0, 64+len(m.Funds)*64)
+	dst = append(dst, `{"sender":`...)
+	dst = appendJSONString(dst, m.Sender)
+	dst = append(dst, `,"funds":`...)
+	dst = appendJSONCoins(dst, m.Funds)
+	return append(dst, '}'), nil
+}
@@ -98,3 +98,105 @@ func TestBlockInfoDeserialization(t *testing.T) {
 	err = json.Unmarshal([]byte(`{"height":0,"time":"","chain_id":""}`), &block)
 	require.ErrorContains(t, err, "cannot unmarshal \"\" into Uint64, failed to parse integer")
 }
+
+// plainEnv and plainMessageInfo have the same fields as Env and MessageInfo but no
+// methods, such that encoding/json uses its reflection based encoder.
+type (
+	plainEnv         Env
+	plainMessageInfo MessageInfo
+)
+
+func TestEnvMarshalJSONMatchesReflection(t *testing.T) {
+	specs := map[string]Env{
+		"empty": {},
+		"no transaction": {
+			Block:    BlockInfo{Height: 123, Time: 1578939743_987654321, ChainID: "foobar"},
+			Contract: ContractInfo{Address: "cosmos1qyqszqgpqyqszqgpqyqszqgpqyqszqgpjnp7du"},
+		},
+		"with transaction": {
+			Block:       BlockInfo{Height: 18446744073709551615, Time: 18446744073709551615, ChainID: "foobar"},
+			Transaction: &TransactionInfo{Index: 4294967295},
+			Contract:    ContractInfo{Address: "contract"},
+		},
+		"escaping": {
+			Block:    BlockInfo{ChainID: "a\"b\\c\n\t\x00<>&  "},
+			Contract: ContractInfo{Address: "ü€😀\xff"},
+		},
+	}
+	for name, env := range specs {
+		t.Run(name, func(t *testing.T) {
+			expected, err := json.Marshal(plainEnv(env))
+			require.NoError(t, err)
+			bz, err := env.MarshalJSON()
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(bz))
+			// json.Marshal uses the custom encoder too
+			bz, err = json.Marshal(env)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(bz))
+		})
+	}
+}
+
+func TestMessageInfoMarshalJSONMatchesReflection(t *testing.T) {
+	specs := map[string]MessageInfo{
+		"empty":    {},
+		"no funds": {Sender: "creator", Funds: []Coin{}},
+		"funds": {
+			Sender: "creator",
+			Funds:  []Coin{{Denom: "peth", Amount: "12345"}, {Denom: "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2", Amount: "1"}},
+		},
+		"escaping": {
+			Sender: "<script>",
+			Funds:  []Coin{{Denom: "a\"b", Amount: "é"}},
+		},
+	}
+	for name, info := range specs {
+		t.Run(name, func(t *testing.T) {
+			expected, err := json.Marshal(plainMessageInfo(info))
+			require.NoError(t, err)
+			bz, err := info.MarshalJSON()
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), string(bz))
+		})
+	}
+}
+
+func BenchmarkEnvMarshalJSON(b *testing.B) {
+	env := Env{
+		Block:       BlockInfo{Height: 1337, Time: 1578939743_987654321, ChainID: "testing"},
+		Transaction: &TransactionInfo{Index: 4},
+		Contract:    ContractInfo{Address: "cosmos1qyqszqgpqyqszqgpqyqszqgpqyqszqgpjnp7du"},
+	}
+	b.Run("reflection", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = json.Marshal(plainEnv(env))
+		}
+	})
+	b.Run("MarshalJSON", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = env.MarshalJSON()
+		}
+	})
+}
+
+func BenchmarkMessageInfoMarshalJSON(b *testing.B) {
+	info := MessageInfo{
+		Sender: "cosmos1qyqszqgpqyqszqgpqyqszqgpqyqszqgpjnp7du",
+		Funds:  []Coin{{Denom: "uatom", Amount: "12345"}, {Denom: "ustake", Amount: "789876"}},
+	}
+	b.Run("reflection", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = json.Marshal(plainMessageInfo(info))
+		}
+	})
+	b.Run("MarshalJSON", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			_, _ = info.MarshalJSON()
+		}
+	})
+}
@@ -1,27 +1,123 @@
 package types
 
+import (
+	"math"
+	"math/bits"
+)
+
+// ZeroDenominatorError is returned by the checked fraction operations when the denominator is zero.
+type ZeroDenominatorError struct{}
+
+var _ error = ZeroDenominatorError{}
+
+func (e ZeroDenominatorError) Error() string {
+	return "Fraction has a zero denominator"
+}
+
+// FractionOverflowError is returned by the checked fraction operations when the result does not fit into 64 bits.
+type FractionOverflowError struct{}
+
+var _ error = FractionOverflowError{}
+
+func (e FractionOverflowError) Error() string {
+	return "Fraction overflow"
+}
+
 type Fraction struct {
 	Numerator   int64
 	Denominator int64
 }
 
+// Mul multiplies the numerator by m without overflow checks. Use CheckedMul for untrusted inputs.
 func (f *Fraction) Mul(m int64) Fraction {
 	return Fraction{f.Numerator * m, f.Denominator}
 }
 
+// Floor divides the numerator by the denominator and panics if it is zero. Use CheckedFloor for untrusted inputs.
 func (f Fraction) Floor() int64 {
 	return f.Numerator / f.Denominator
 }
 
+// CheckedMul multiplies the numerator by m. It returns a FractionOverflowError if the numerator overflows.
+func (f Fraction) CheckedMul(m int64) (Fraction, error) {
+	if f.Numerator == 0 || m == 0 {
+		return Fraction{0, f.Denominator}, nil
+	}
+	product := f.Numerator * m
+	// product/m wraps around as well for MinInt64 * -1
+	if product/m != f.Numerator || (m == -1 && f.Numerator == math.MinInt64) {
+		return Fraction{}, FractionOverflowError{}
+	}
+	return Fraction{product, f.Denominator}, nil
+}
+
+// CheckedFloor divides the numerator by the denominator, rounding towards zero like Floor.
+// It returns a ZeroDenominatorError or a FractionOverflowError instead of panicking or wrapping around.
+func (f Fraction) CheckedFloor() (int64, error) {
+	if f.Denominator == 0 {
+		return 0, ZeroDenominatorError{}
+	}
+	if f.Numerator == math.MinInt64 && f.Denominator == -1 {
+		return 0, FractionOverflowError{}
+	}
+	return f.Numerator / f.Denominator, nil
+}
+
 type UFraction struct {
 	Numerator   uint64
 	Denominator uint64
 }
 
+// Mul multiplies the numerator by m without overflow checks. Use MulFloor for untrusted inputs.
 func (f *UFraction) Mul(m uint64) UFraction {
 	return UFraction{f.Numerator * m, f.Denominator}
 }
 
+// Floor divides the numerator by the denominator and panics if it is zero. Use CheckedFloor for untrusted inputs.
 func (f UFraction) Floor() uint64 {
 	return f.Numerator / f.Denominator
 }
+
+// CheckedMul multiplies the numerator by m. It returns a FractionOverflowError if the numerator overflows.
+// Note that the product may overflow even if the result of a subsequent Floor would not; MulFloor avoids this.
+func (f UFraction) CheckedMul(m uint64) (UFraction, error) {
+	hi, lo := bits.Mul64(f.Numerator, m)
+	if hi != 0 {
+		return UFraction{}, FractionOverflowError{}
+	}
+	return UFraction{lo, f.Denominator}, nil
+}
+
+// CheckedFloor divides the numerator by the denominator.
+// It returns a ZeroDenominatorError instead of panicking.
+func (f UFraction) CheckedFloor() (uint64, error) {
+	if f.Denominator == 0 {
+		return 0, ZeroDenominatorError{}
+	}
+	return f.Numerator / f.Denominator, nil
+}
+
+// MulFloor computes floor(m * Numerator / Denominator) using a 128-bit intermediate product,
+// so it only fails if the final result does not fit into 64 bits.
+// It returns a ZeroDenominatorError or a FractionOverflowError.
+func (f UFraction) MulFloor(m uint64) (uint64, error) {
+	if f.Denominator == 0 {
+		return 0, ZeroDenominatorError{}
+	}
+	hi, lo := bits.Mul64(f.Numerator, m)
+	if hi >= f.Denominator {
+		return 0, FractionOverflowError{}
+	}
+	quo, _ := bits.Div64(hi, lo, f.Denominator)
+	return quo, nil
+}
+
+// SaturatingMulFloor is like MulFloor but returns math.MaxUint64 if the result does not fit into 64 bits.
+// It still returns a ZeroDenominatorError if the denominator is zero.
+func (f UFraction) SaturatingMulFloor(m uint64) (uint64, error) {
+	res, err := f.MulFloor(m)
+	if _, overflow := err.(FractionOverflowError); overflow {
+		return math.MaxUint64, nil
+	}
+	return res, err
+}
@@ -0,0 +1,157 @@
+package types
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUFractionMulFloor(t *testing.T) {
+	specs := map[string]struct {
+		fraction UFraction
+		m        uint64
+		exp      uint64
+		expErr   error
+	}{
+		"one": {
+			fraction: UFraction{Numerator: 1, Denominator: 1},
+			m:        123,
+			exp:      123,
+		},
+		"rounds down": {
+			fraction: UFraction{Numerator: 1, Denominator: 3},
+			m:        5,
+			exp:      1,
+		},
+		"zero numerator": {
+			fraction: UFraction{Numerator: 0, Denominator: 7},
+			m:        math.MaxUint64,
+			exp:      0,
+		},
+		"128-bit intermediate": {
+			fraction: UFraction{Numerator: math.MaxUint64, Denominator: math.MaxUint64},
+			m:        math.MaxUint64,
+			exp:      math.MaxUint64,
+		},
+		"large product with large denominator": {
+			fraction: UFraction{Numerator: 1 << 40, Denominator: 1 << 50},
+			m:        1 << 40,
+			exp:      1 << 30,
+		},
+		"overflow": {
+			fraction: UFraction{Numerator: 2, Denominator: 1},
+			m:        math.MaxUint64/2 + 1,
+			expErr:   FractionOverflowError{},
+		},
+		"zero denominator": {
+			fraction: UFraction{Numerator: 1, Denominator: 0},
+			m:        1,
+			expErr:   ZeroDenominatorError{},
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			got, err := spec.fraction.MulFloor(spec.m)
+			if spec.expErr != nil {
+				require.Equal(t, spec.expErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, spec.exp, got)
+		})
+	}
+}
+
+func TestUFractionSaturatingMulFloor(t *testing.T) {
+	got, err := UFraction{Numerator: math.MaxUint64, Denominator: 2}.SaturatingMulFloor(3)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(math.MaxUint64), got)
+
+	got, err = UFraction{Numerator: 3, Denominator: 2}.SaturatingMulFloor(3)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(4), got)
+
+	_, err = UFraction{Numerator: 3}.SaturatingMulFloor(3)
+	require.Equal(t, ZeroDenominatorError{}, err)
+}
+
+func TestUFractionChecked(t *testing.T) {
+	f, err := UFraction{Numerator: 3, Denominator: 2}.CheckedMul(5)
+	require.NoError(t, err)
+	assert.Equal(t, UFraction{Numerator: 15, Denominator: 2}, f)
+	floor, err := f.CheckedFloor()
+	require.NoError(t, err)
+	assert.Equal(t, uint64(7), floor)
+
+	_, err = UFraction{Numerator: math.MaxUint64, Denominator: 2}.CheckedMul(2)
+	require.Equal(t, FractionOverflowError{}, err)
+	_, err = UFraction{Numerator: 1}.CheckedFloor()
+	require.Equal(t, ZeroDenominatorError{}, err)
+}
+
+func TestFractionChecked(t *testing.T) {
+	specs := map[string]struct {
+		fraction Fraction
+		m        int64
+		exp      int64
+		expErr   error
+	}{
+		"positive": {
+			fraction: Fraction{Numerator: 3, Denominator: 2},
+			m:        5,
+			exp:      7,
+		},
+		"negative rounds towards zero": {
+			fraction: Fraction{Numerator: -3, Denominator: 2},
+			m:        5,
+			exp:      -7,
+		},
+		"zero": {
+			fraction: Fraction{Numerator: 0, Denominator: 2},
+			m:        math.MinInt64,
+			exp:      0,
+		},
+		"overflow": {
+			fraction: Fraction{Numerator: math.MaxInt64, Denominator: 1},
+			m:        2,
+			expErr:   FractionOverflowError{},
+		},
+		"min times minus one": {
+			fraction: Fraction{Numerator: math.MinInt64, Denominator: 1},
+			m:        -1,
+			expErr:   FractionOverflowError{},
+		},
+		"minus one times min": {
+			fraction: Fraction{Numerator: -1, Denominator: 1},
+			m:        math.MinInt64,
+			expErr:   FractionOverflowError{},
+		},
+		"min divided by minus one": {
+			fraction: Fraction{Numerator: math.MinInt64, Denominator: -1},
+			m:        1,
+			expErr:   FractionOverflowError{},
+		},
+		"zero denominator": {
+			fraction: Fraction{Numerator: 1, Denominator: 0},
+			m:        1,
+			expErr:   ZeroDenominatorError{},
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			f, err := spec.fraction.CheckedMul(spec.m)
+			var got int64
+			if err == nil {
+				got, err = f.CheckedFloor()
+			}
+			if spec.expErr != nil {
+				require.Equal(t, spec.expErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, spec.exp, got)
+		})
+	}
+}
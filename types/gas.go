@@ -1,5 +1,12 @@
 package types
 
+import (
+	"fmt"
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
 type Gas = uint64
 
 // GasMeter is a read-only version of the sdk gas meter
@@ -8,3 +15,225 @@ type Gas = uint64
 type GasMeter interface {
 	GasConsumed() Gas
 }
+
+// GasConfig is the gas schedule of a VM. All costs are measured in CosmWasm gas,
+// i.e. the gas unit of the Wasm execution, not in SDK gas.
+//
+// Since these costs determine the gas consumption of contract calls,
+// all nodes of a chain must use the same configuration.
+type GasConfig struct {
+	// CompileCostBase is charged once for every Wasm code stored
+	CompileCostBase Gas
+	// CompileCostPerByte is charged per byte of Wasm code stored
+	CompileCostPerByte Gas
+	// DeserializationCost is the cost of deserializing one byte of a contract result.
+	// It is used for all calls that do not pass a deserialization cost of their own.
+	DeserializationCost UFraction
+	// EntrypointBaseCosts are charged once per call of a contract entrypoint, before the contract is executed.
+	// The keys are the names of the entrypoints as exported by contracts, e.g. "execute" or "ibc_packet_receive".
+	// Entrypoints without an entry are not charged any base cost.
+	EntrypointBaseCosts map[string]Gas
+	// HumanizeAddressCost is charged for every call of GoAPI.HumanizeAddress on top of the cost it reports
+	HumanizeAddressCost Gas
+	// CanonicalizeAddressCost is charged for every call of GoAPI.CanonicalizeAddress on top of the cost it reports
+	CanonicalizeAddressCost Gas
+	// ValidateAddressCost is charged for every call of GoAPI.ValidateAddress on top of the cost it reports
+	ValidateAddressCost Gas
+	// QueryCost is charged for every query a contract makes on top of the gas consumed by the Querier
+	QueryCost Gas
+	// GasMultiplier converts the gas consumed by the GasMeter and the Querier passed to contract calls
+	// from Cosmos SDK gas to CosmWasm gas. If it is zero, they are expected to report CosmWasm gas already.
+	GasMultiplier GasMultiplier
+}
+
+// DefaultGasConfig returns the gas schedule used by a VM unless configured otherwise.
+func DefaultGasConfig() GasConfig {
+	return GasConfig{
+		// Benchmarks and numbers (in SDK Gas) were discussed in:
+		// https://github.com/CosmWasm/wasmd/pull/634#issuecomment-938056803
+		CompileCostPerByte: 3 * 140_000,
+		// 1 SDK gas per byte with the default gas multiplier
+		DeserializationCost: UFraction{Numerator: uint64(DefaultGasMultiplier), Denominator: 1},
+	}
+}
+
+// CompileCost returns the gas cost of storing Wasm code with the given size.
+// The cost saturates at math.MaxUint64 instead of wrapping around.
+func (c GasConfig) CompileCost(codeSize int) Gas {
+	hi, perByte := bits.Mul64(c.CompileCostPerByte, uint64(codeSize))
+	cost, carry := bits.Add64(c.CompileCostBase, perByte, 0)
+	if hi != 0 || carry != 0 {
+		return math.MaxUint64
+	}
+	return cost
+}
+
+// EntrypointBaseCost returns the base cost of calling the given entrypoint.
+func (c GasConfig) EntrypointBaseCost(entrypoint string) Gas {
+	return c.EntrypointBaseCosts[entrypoint]
+}
+
+// GoAPI returns a GoAPI that adds the address costs of this configuration to the costs reported by api.
+// If none of these costs are set, api is returned unchanged.
+func (c GasConfig) GoAPI(api GoAPI) GoAPI {
+	if c.HumanizeAddressCost == 0 && c.CanonicalizeAddressCost == 0 && c.ValidateAddressCost == 0 {
+		return api
+	}
+	wrapped := api
+	if api.HumanizeAddress != nil {
+		wrapped.HumanizeAddress = func(canon []byte) (string, uint64, error) {
+			human, cost, err := api.HumanizeAddress(canon)
+			return human, cost + c.HumanizeAddressCost, err
+		}
+	}
+	if api.CanonicalizeAddress != nil {
+		wrapped.CanonicalizeAddress = func(human string) ([]byte, uint64, error) {
+			canon, cost, err := api.CanonicalizeAddress(human)
+			return canon, cost + c.CanonicalizeAddressCost, err
+		}
+	}
+	if api.ValidateAddress != nil {
+		wrapped.ValidateAddress = func(human string) (uint64, error) {
+			cost, err := api.ValidateAddress(human)
+			return cost + c.ValidateAddressCost, err
+		}
+	}
+	return wrapped
+}
+
+// GasMeter returns a GasMeter that reports the gas consumed by meter in CosmWasm gas.
+// If no gas multiplier is set, meter is returned unchanged.
+func (c GasConfig) GasMeter(meter GasMeter) GasMeter {
+	if c.GasMultiplier == 0 || meter == nil {
+		return meter
+	}
+	return NewMultipliedGasMeter(meter, c.GasMultiplier)
+}
+
+// Querier returns a Querier that reports the gas consumed by querier in CosmWasm gas
+// and adds the query cost of this configuration to it.
+// If neither a gas multiplier nor a query cost is set, querier is returned unchanged.
+func (c GasConfig) Querier(querier Querier) Querier {
+	if querier == nil {
+		return nil
+	}
+	if c.GasMultiplier != 0 {
+		querier = NewMultipliedQuerier(querier, c.GasMultiplier)
+	}
+	if c.QueryCost != 0 {
+		querier = &costQuerier{Querier: querier, cost: c.QueryCost}
+	}
+	return querier
+}
+
+// costQuerier charges a fixed cost per query by adding it to the gas consumed.
+// This works because wasmvm uses the difference of GasConsumed before and after a query as its gas usage.
+type costQuerier struct {
+	Querier
+	cost    Gas
+	queries atomic.Uint64
+}
+
+func (q *costQuerier) Query(request QueryRequest, gasLimit uint64) ([]byte, error) {
+	q.queries.Add(1)
+	return q.Querier.Query(request, gasLimit)
+}
+
+func (q *costQuerier) GasConsumed() uint64 {
+	return q.Querier.GasConsumed() + q.queries.Load()*q.cost
+}
+
+// GasMultiplier is the number of CosmWasm gas units per Cosmos SDK gas unit.
+//
+// Gas limits passed to contract calls and queries as well as GasReport are measured in CosmWasm gas,
+// while SubMsg.GasLimit, Reply.GasUsed and the gas meters of the SDK are measured in SDK gas.
+// The zero value converts 1:1.
+type GasMultiplier uint64
+
+// DefaultGasMultiplier is the gas multiplier used in wasmd (keeper.DefaultGasMultiplier).
+const DefaultGasMultiplier GasMultiplier = 140_000_000
+
+// GasOverflowError is returned when an amount of SDK gas does not fit into a uint64 in CosmWasm gas.
+type GasOverflowError struct {
+	SDKGas     Gas
+	Multiplier GasMultiplier
+}
+
+var _ error = GasOverflowError{}
+
+func (e GasOverflowError) Error() string {
+	return fmt.Sprintf("Gas overflow converting %d SDK gas with multiplier %d", e.SDKGas, e.Multiplier)
+}
+
+// ToWasmVMGas converts SDK gas to CosmWasm gas.
+func (m GasMultiplier) ToWasmVMGas(sdkGas Gas) (Gas, error) {
+	if m == 0 {
+		return sdkGas, nil
+	}
+	if sdkGas > math.MaxUint64/uint64(m) {
+		return 0, GasOverflowError{SDKGas: sdkGas, Multiplier: m}
+	}
+	return sdkGas * uint64(m), nil
+}
+
+// ToWasmVMGasSaturating is like ToWasmVMGas but returns math.MaxUint64 instead of an error on overflow.
+func (m GasMultiplier) ToWasmVMGasSaturating(sdkGas Gas) Gas {
+	wasmGas, err := m.ToWasmVMGas(sdkGas)
+	if err != nil {
+		return math.MaxUint64
+	}
+	return wasmGas
+}
+
+// FromWasmVMGas converts CosmWasm gas to SDK gas, rounding down.
+func (m GasMultiplier) FromWasmVMGas(wasmGas Gas) Gas {
+	if m == 0 {
+		return wasmGas
+	}
+	return wasmGas / uint64(m)
+}
+
+// FromWasmVMGasReport converts all fields of a GasReport to SDK gas, rounding down.
+func (m GasMultiplier) FromWasmVMGasReport(report GasReport) GasReport {
+	return GasReport{
+		Limit:          m.FromWasmVMGas(report.Limit),
+		Remaining:      m.FromWasmVMGas(report.Remaining),
+		UsedExternally: m.FromWasmVMGas(report.UsedExternally),
+		UsedInternally: m.FromWasmVMGas(report.UsedInternally),
+	}
+}
+
+// NewMultipliedGasMeter returns a GasMeter reporting the SDK gas consumed by meter in CosmWasm gas.
+// The consumed gas saturates at math.MaxUint64.
+func NewMultipliedGasMeter(meter GasMeter, multiplier GasMultiplier) GasMeter {
+	return multipliedGasMeter{meter: meter, multiplier: multiplier}
+}
+
+type multipliedGasMeter struct {
+	meter      GasMeter
+	multiplier GasMultiplier
+}
+
+func (m multipliedGasMeter) GasConsumed() Gas {
+	return m.multiplier.ToWasmVMGasSaturating(m.meter.GasConsumed())
+}
+
+// NewMultipliedQuerier returns a Querier for a querier measuring gas in SDK gas.
+// The gas limit of queries is converted to SDK gas and the consumed gas is reported in CosmWasm gas,
+// saturating at math.MaxUint64.
+func NewMultipliedQuerier(querier Querier, multiplier GasMultiplier) Querier {
+	return multipliedQuerier{querier: querier, multiplier: multiplier}
+}
+
+type multipliedQuerier struct {
+	querier    Querier
+	multiplier GasMultiplier
+}
+
+func (q multipliedQuerier) Query(request QueryRequest, gasLimit uint64) ([]byte, error) {
+	return q.querier.Query(request, q.multiplier.FromWasmVMGas(gasLimit))
+}
+
+func (q multipliedQuerier) GasConsumed() uint64 {
+	return q.multiplier.ToWasmVMGasSaturating(q.querier.GasConsumed())
+}
@@ -0,0 +1,241 @@
+package types
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGasConfigCompileCost(t *testing.T) {
+	specs := map[string]struct {
+		config   GasConfig
+		codeSize int
+		exp      Gas
+	}{
+		"default": {
+			config:   DefaultGasConfig(),
+			codeSize: 100,
+			exp:      100 * 3 * 140_000,
+		},
+		"with base cost": {
+			config:   GasConfig{CompileCostBase: 1000, CompileCostPerByte: 2},
+			codeSize: 100,
+			exp:      1200,
+		},
+		"empty code": {
+			config:   GasConfig{CompileCostBase: 1000, CompileCostPerByte: 2},
+			codeSize: 0,
+			exp:      1000,
+		},
+		"per byte cost overflows": {
+			config:   GasConfig{CompileCostPerByte: math.MaxUint64 / 2},
+			codeSize: 3,
+			exp:      math.MaxUint64,
+		},
+		"base cost overflows": {
+			config:   GasConfig{CompileCostBase: math.MaxUint64 - 100, CompileCostPerByte: 2},
+			codeSize: 51,
+			exp:      math.MaxUint64,
+		},
+		"max without overflow": {
+			config:   GasConfig{CompileCostBase: math.MaxUint64 - 100, CompileCostPerByte: 2},
+			codeSize: 50,
+			exp:      math.MaxUint64,
+		},
+		"just below max": {
+			config:   GasConfig{CompileCostBase: math.MaxUint64 - 100, CompileCostPerByte: 2},
+			codeSize: 49,
+			exp:      math.MaxUint64 - 2,
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			assert.Equal(t, spec.exp, spec.config.CompileCost(spec.codeSize))
+		})
+	}
+}
+
+func TestGasConfigEntrypointBaseCost(t *testing.T) {
+	config := GasConfig{EntrypointBaseCosts: map[string]Gas{"execute": 10}}
+	assert.Equal(t, Gas(10), config.EntrypointBaseCost("execute"))
+	assert.Equal(t, Gas(0), config.EntrypointBaseCost("query"))
+	assert.Equal(t, Gas(0), DefaultGasConfig().EntrypointBaseCost("execute"))
+}
+
+func TestGasConfigGoAPI(t *testing.T) {
+	errInvalid := errors.New("invalid")
+	api := GoAPI{
+		HumanizeAddress: func(canon []byte) (string, uint64, error) {
+			return string(canon), 1, nil
+		},
+		CanonicalizeAddress: func(human string) ([]byte, uint64, error) {
+			return []byte(human), 2, nil
+		},
+		ValidateAddress: func(human string) (uint64, error) {
+			return 3, errInvalid
+		},
+	}
+
+	// no address costs: unchanged
+	unchanged := DefaultGasConfig().GoAPI(api)
+	_, cost, err := unchanged.HumanizeAddress([]byte("foo"))
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), cost)
+
+	wrapped := GasConfig{HumanizeAddressCost: 10, CanonicalizeAddressCost: 20, ValidateAddressCost: 30}.GoAPI(api)
+	human, cost, err := wrapped.HumanizeAddress([]byte("foo"))
+	require.NoError(t, err)
+	assert.Equal(t, "foo", human)
+	assert.Equal(t, uint64(11), cost)
+	canon, cost, err := wrapped.CanonicalizeAddress("bar")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("bar"), canon)
+	assert.Equal(t, uint64(22), cost)
+	cost, err = wrapped.ValidateAddress("baz")
+	require.ErrorIs(t, err, errInvalid)
+	assert.Equal(t, uint64(33), cost)
+
+	// missing functions stay missing
+	partial := GasConfig{ValidateAddressCost: 30}.GoAPI(GoAPI{})
+	assert.Nil(t, partial.HumanizeAddress)
+	assert.Nil(t, partial.ValidateAddress)
+}
+
+type countingQuerier struct {
+	gas uint64
+}
+
+func (q *countingQuerier) Query(request QueryRequest, gasLimit uint64) ([]byte, error) {
+	q.gas += 5
+	return []byte("{}"), nil
+}
+
+func (q *countingQuerier) GasConsumed() uint64 {
+	return q.gas
+}
+
+func TestGasConfigQuerier(t *testing.T) {
+	inner := &countingQuerier{}
+	assert.Same(t, inner, DefaultGasConfig().Querier(inner))
+	assert.Nil(t, GasConfig{QueryCost: 100}.Querier(nil))
+
+	querier := GasConfig{QueryCost: 100}.Querier(inner)
+	before := querier.GasConsumed()
+	_, err := querier.Query(QueryRequest{}, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(105), querier.GasConsumed()-before)
+	_, err = querier.Query(QueryRequest{}, 1000)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(210), querier.GasConsumed()-before)
+}
+
+func TestGasMultiplierToWasmVMGas(t *testing.T) {
+	specs := map[string]struct {
+		multiplier GasMultiplier
+		sdkGas     Gas
+		exp        Gas
+		expErr     error
+	}{
+		"default": {
+			multiplier: DefaultGasMultiplier,
+			sdkGas:     1000,
+			exp:        140_000_000_000,
+		},
+		"zero converts 1:1": {
+			multiplier: 0,
+			sdkGas:     1000,
+			exp:        1000,
+		},
+		"max without overflow": {
+			multiplier: 2,
+			sdkGas:     math.MaxUint64 / 2,
+			exp:        math.MaxUint64 - 1,
+		},
+		"overflow": {
+			multiplier: 2,
+			sdkGas:     math.MaxUint64/2 + 1,
+			expErr:     GasOverflowError{SDKGas: math.MaxUint64/2 + 1, Multiplier: 2},
+		},
+		"default overflow": {
+			multiplier: DefaultGasMultiplier,
+			sdkGas:     math.MaxUint64/140_000_000 + 1,
+			expErr:     GasOverflowError{SDKGas: math.MaxUint64/140_000_000 + 1, Multiplier: DefaultGasMultiplier},
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			got, err := spec.multiplier.ToWasmVMGas(spec.sdkGas)
+			if spec.expErr != nil {
+				require.Equal(t, spec.expErr, err)
+				assert.Equal(t, Gas(math.MaxUint64), spec.multiplier.ToWasmVMGasSaturating(spec.sdkGas))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, spec.exp, got)
+			assert.Equal(t, spec.exp, spec.multiplier.ToWasmVMGasSaturating(spec.sdkGas))
+			assert.Equal(t, spec.sdkGas, spec.multiplier.FromWasmVMGas(got))
+		})
+	}
+}
+
+func TestGasMultiplierFromWasmVMGas(t *testing.T) {
+	assert.Equal(t, Gas(1), DefaultGasMultiplier.FromWasmVMGas(279_999_999))
+	assert.Equal(t, Gas(0), DefaultGasMultiplier.FromWasmVMGas(139_999_999))
+	assert.Equal(t, Gas(139), GasMultiplier(0).FromWasmVMGas(139))
+}
+
+func TestGasMultiplierFromWasmVMGasReport(t *testing.T) {
+	report := GasReport{
+		Limit:          1_400_000_000,
+		Remaining:      700_000_000,
+		UsedExternally: 279_999_999,
+		UsedInternally: 420_000_001,
+	}
+	assert.Equal(t, GasReport{Limit: 10, Remaining: 5, UsedExternally: 1, UsedInternally: 3}, DefaultGasMultiplier.FromWasmVMGasReport(report))
+	assert.Equal(t, report, GasMultiplier(0).FromWasmVMGasReport(report))
+}
+
+type fixedGasMeter Gas
+
+func (m fixedGasMeter) GasConsumed() Gas {
+	return Gas(m)
+}
+
+func TestMultipliedGasMeter(t *testing.T) {
+	assert.Equal(t, Gas(1_400_000_000), NewMultipliedGasMeter(fixedGasMeter(10), DefaultGasMultiplier).GasConsumed())
+	assert.Equal(t, Gas(math.MaxUint64), NewMultipliedGasMeter(fixedGasMeter(math.MaxUint64/10), DefaultGasMultiplier).GasConsumed())
+
+	meter := fixedGasMeter(10)
+	assert.Equal(t, GasMeter(meter), DefaultGasConfig().GasMeter(meter))
+	assert.Equal(t, Gas(1_400_000_000), GasConfig{GasMultiplier: DefaultGasMultiplier}.GasMeter(meter).GasConsumed())
+}
+
+type limitQuerier struct {
+	lastLimit uint64
+}
+
+func (q *limitQuerier) Query(request QueryRequest, gasLimit uint64) ([]byte, error) {
+	q.lastLimit = gasLimit
+	return nil, nil
+}
+
+func (q *limitQuerier) GasConsumed() uint64 {
+	return 7
+}
+
+func TestMultipliedQuerier(t *testing.T) {
+	inner := &limitQuerier{}
+	querier := GasConfig{GasMultiplier: 100, QueryCost: 50}.Querier(inner)
+	before := querier.GasConsumed()
+	assert.Equal(t, uint64(700), before)
+
+	_, err := querier.Query(QueryRequest{}, 12_345)
+	require.NoError(t, err)
+	// the limit is passed in SDK gas
+	assert.Equal(t, uint64(123), inner.lastLimit)
+	// the query cost is added in CosmWasm gas
+	assert.Equal(t, uint64(750), querier.GasConsumed())
+}
@@ -0,0 +1,415 @@
+package types
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"unicode/utf8"
+)
+
+// The helpers in this file are used by the hand-written JSON encoders of types that
+// are serialized for every contract call (see Env and MessageInfo). They produce
+// exactly the same bytes as encoding/json does for the equivalent struct definitions.
+// The decoder for ContractResult at the end of the file is their counterpart for the
+// result of every instantiate/execute/migrate/sudo/reply call.
+
+// appendJSONString appends the JSON encoding of s to dst.
+//
+// Strings that consist only of printable ASCII characters that encoding/json does not
+// escape are copied directly. Everything else is rare in practice and delegated to
+// encoding/json to guarantee identical escaping.
+func appendJSONString(dst []byte, s string) []byte {
+	for i := 0; i < len(s); i++ {
+		if !isPlainJSONChar(s[i]) {
+			bz, err := json.Marshal(s)
+			if err != nil {
+				// encoding a string cannot fail
+				panic(err)
+			}
+			return append(dst, bz...)
+		}
+	}
+	dst = append(dst, '"')
+	dst = append(dst, s...)
+	return append(dst, '"')
+}
+
+// isPlainJSONChar returns true for bytes that encoding/json copies into a string literal unchanged
+// (with HTML escaping enabled, which is the default of json.Marshal).
+func isPlainJSONChar(b byte) bool {
+	return b >= 0x20 && b < 0x80 && b != '"' && b != '\\' && b != '<' && b != '>' && b != '&'
+}
+
+// appendJSONUint64 appends a JSON number.
+func appendJSONUint64(dst []byte, v uint64) []byte {
+	return strconv.AppendUint(dst, v, 10)
+}
+
+// appendJSONQuotedUint64 appends a string-encoded integer as done by Uint64.MarshalJSON.
+func appendJSONQuotedUint64(dst []byte, v uint64) []byte {
+	dst = append(dst, '"')
+	dst = strconv.AppendUint(dst, v, 10)
+	return append(dst, '"')
+}
+
+// appendJSONCoins appends a list of coins. A nil list is encoded as "[]" like Array does.
+func appendJSONCoins(dst []byte, coins []Coin) []byte {
+	dst = append(dst, '[')
+	for i, c := range coins {
+		if i > 0 {
+			dst = append(dst, ',')
+		}
+		dst = append(dst, `{"denom":`...)
+		dst = appendJSONString(dst, c.Denom)
+		dst = append(dst, `,"amount":`...)
+		dst = appendJSONString(dst, c.Amount)
+		dst = append(dst, '}')
+	}
+	return append(dst, ']')
+}
+
+// UnmarshalContractResult decodes data into r without using reflection for the
+// ContractResult/Response/SubMsg/Event structure. The messages of the sub-messages are
+// decoded by CosmosMsg.UnmarshalJSON.
+//
+// Only the canonical encoding produced by cosmwasm-std is decoded directly. Everything
+// else, e.g. keys in a different case, unknown or duplicate keys, escaped strings or
+// invalid data, is delegated to encoding/json, so the result (and error) is always the
+// same as for json.Unmarshal(data, r).
+func UnmarshalContractResult(data []byte, r *ContractResult) error {
+	if *r == (ContractResult{}) {
+		dec := jsonDecoder{data: data}
+		var res ContractResult
+		if dec.contractResult(&res) && dec.end() {
+			*r = res
+			return nil
+		}
+	}
+	return json.Unmarshal(data, r)
+}
+
+// jsonDecoder is a minimal JSON decoder for the canonical encoding of a ContractResult.
+// All methods return false if the input is not in the expected form.
+type jsonDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *jsonDecoder) skipWhitespace() {
+	for d.pos < len(d.data) {
+		switch d.data[d.pos] {
+		case ' ', '\t', '\n', '\r':
+			d.pos++
+		default:
+			return
+		}
+	}
+}
+
+// next skips whitespace and consumes c if it is the next byte.
+func (d *jsonDecoder) next(c byte) bool {
+	d.skipWhitespace()
+	if d.pos < len(d.data) && d.data[d.pos] == c {
+		d.pos++
+		return true
+	}
+	return false
+}
+
+// null consumes a null literal if it is the next value.
+func (d *jsonDecoder) null() bool {
+	d.skipWhitespace()
+	if bytes.HasPrefix(d.data[d.pos:], []byte("null")) {
+		d.pos += len("null")
+		return true
+	}
+	return false
+}
+
+func (d *jsonDecoder) end() bool {
+	d.skipWhitespace()
+	return d.pos == len(d.data)
+}
+
+// rawString returns the content of a string without escape sequences.
+func (d *jsonDecoder) rawString() ([]byte, bool) {
+	if !d.next('"') {
+		return nil, false
+	}
+	start := d.pos
+	for d.pos < len(d.data) {
+		switch c := d.data[d.pos]; {
+		case c == '"':
+			s := d.data[start:d.pos]
+			d.pos++
+			// encoding/json replaces invalid UTF-8, so leave it to encoding/json
+			return s, utf8.Valid(s)
+		case c == '\\' || c < 0x20:
+			return nil, false
+		}
+		d.pos++
+	}
+	return nil, false
+}
+
+func (d *jsonDecoder) string() (string, bool) {
+	s, ok := d.rawString()
+	return string(s), ok
+}
+
+// bytes decodes a base64 string like encoding/json does for []byte.
+func (d *jsonDecoder) bytes() ([]byte, bool) {
+	s, ok := d.rawString()
+	if !ok {
+		return nil, false
+	}
+	b := make([]byte, base64.StdEncoding.DecodedLen(len(s)))
+	n, err := base64.StdEncoding.Decode(b, s)
+	if err != nil {
+		return nil, false
+	}
+	return b[:n], true
+}
+
+func (d *jsonDecoder) uint64() (uint64, bool) {
+	d.skipWhitespace()
+	start := d.pos
+	for d.pos < len(d.data) && d.data[d.pos] >= '0' && d.data[d.pos] <= '9' {
+		d.pos++
+	}
+	digits := d.data[start:d.pos]
+	// leading zeros are invalid JSON
+	if len(digits) == 0 || (len(digits) > 1 && digits[0] == '0') {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(string(digits), 10, 64)
+	return v, err == nil
+}
+
+// rawObject returns the raw bytes of the next value if it is an object. The object is
+// only checked to be balanced, so the caller must decode it with encoding/json.
+func (d *jsonDecoder) rawObject() ([]byte, bool) {
+	d.skipWhitespace()
+	if d.pos >= len(d.data) || d.data[d.pos] != '{' {
+		return nil, false
+	}
+	start := d.pos
+	depth := 0
+	for ; d.pos < len(d.data); d.pos++ {
+		switch d.data[d.pos] {
+		case '"':
+			for d.pos++; d.pos < len(d.data) && d.data[d.pos] != '"'; d.pos++ {
+				if d.data[d.pos] == '\\' {
+					d.pos++
+				}
+			}
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+			if depth == 0 {
+				d.pos++
+				return d.data[start:d.pos], true
+			}
+		}
+	}
+	return nil, false
+}
+
+// object decodes an object. field is called for every key and must decode the value.
+// Keys must be one of keys and may appear only once.
+func (d *jsonDecoder) object(keys []string, field func(key int) bool) bool {
+	if !d.next('{') {
+		return false
+	}
+	if d.next('}') {
+		return true
+	}
+	var seen uint
+	for {
+		key, ok := d.rawString()
+		if !ok || !d.next(':') {
+			return false
+		}
+		i := 0
+		for i < len(keys) && keys[i] != string(key) {
+			i++
+		}
+		if i == len(keys) || seen&(1<<i) != 0 {
+			return false
+		}
+		seen |= 1 << i
+		if !field(i) {
+			return false
+		}
+		if d.next('}') {
+			return true
+		}
+		if !d.next(',') {
+			return false
+		}
+	}
+}
+
+// array decodes an array. elem is called for every element and must decode it.
+func (d *jsonDecoder) array(elem func() bool) bool {
+	if !d.next('[') {
+		return false
+	}
+	if d.next(']') {
+		return true
+	}
+	for {
+		if !elem() {
+			return false
+		}
+		if d.next(']') {
+			return true
+		}
+		if !d.next(',') {
+			return false
+		}
+	}
+}
+
+var (
+	contractResultKeys = []string{"ok", "error"}
+	responseKeys       = []string{"messages", "data", "attributes", "events"}
+	subMsgKeys         = []string{"id", "msg", "payload", "gas_limit", "reply_on"}
+	eventKeys          = []string{"type", "attributes"}
+	eventAttributeKeys = []string{"key", "value"}
+)
+
+func (d *jsonDecoder) contractResult(r *ContractResult) bool {
+	return d.object(contractResultKeys, func(key int) bool {
+		var ok bool
+		switch key {
+		case 0:
+			if d.null() {
+				r.Ok = nil
+				return true
+			}
+			r.Ok = &Response{}
+			return d.response(r.Ok)
+		default:
+			r.Err, ok = d.string()
+			return ok
+		}
+	})
+}
+
+func (d *jsonDecoder) response(r *Response) bool {
+	return d.object(responseKeys, func(key int) bool {
+		var ok bool
+		switch key {
+		case 0:
+			if d.null() {
+				r.Messages = nil
+				return true
+			}
+			r.Messages = []SubMsg{}
+			return d.array(func() bool {
+				r.Messages = append(r.Messages, SubMsg{})
+				return d.subMsg(&r.Messages[len(r.Messages)-1])
+			})
+		case 1:
+			if d.null() {
+				r.Data = nil
+				return true
+			}
+			r.Data, ok = d.bytes()
+			return ok
+		case 2:
+			if d.null() {
+				r.Attributes = nil
+				return true
+			}
+			r.Attributes = []EventAttribute{}
+			return d.array(func() bool {
+				r.Attributes = append(r.Attributes, EventAttribute{})
+				return d.eventAttribute(&r.Attributes[len(r.Attributes)-1])
+			})
+		default:
+			if d.null() {
+				r.Events = nil
+				return true
+			}
+			r.Events = []Event{}
+			return d.array(func() bool {
+				r.Events = append(r.Events, Event{})
+				return d.event(&r.Events[len(r.Events)-1])
+			})
+		}
+	})
+}
+
+func (d *jsonDecoder) subMsg(m *SubMsg) bool {
+	return d.object(subMsgKeys, func(key int) bool {
+		var ok bool
+		switch key {
+		case 0:
+			m.ID, ok = d.uint64()
+			return ok
+		case 1:
+			raw, ok := d.rawObject()
+			return ok && m.Msg.UnmarshalJSON(raw) == nil
+		case 2:
+			if d.null() {
+				m.Payload = nil
+				return true
+			}
+			m.Payload, ok = d.bytes()
+			return ok
+		case 3:
+			if d.null() {
+				m.GasLimit = nil
+				return true
+			}
+			limit, ok := d.uint64()
+			m.GasLimit = &limit
+			return ok
+		default:
+			s, ok := d.rawString()
+			if !ok {
+				return false
+			}
+			m.ReplyOn, ok = toReplyOn[string(s)]
+			return ok
+		}
+	})
+}
+
+func (d *jsonDecoder) event(e *Event) bool {
+	return d.object(eventKeys, func(key int) bool {
+		var ok bool
+		switch key {
+		case 0:
+			e.Type, ok = d.string()
+			return ok
+		default:
+			// Array decodes null and [] to an empty slice
+			e.Attributes = Array[EventAttribute]{}
+			if d.null() {
+				return true
+			}
+			return d.array(func() bool {
+				e.Attributes = append(e.Attributes, EventAttribute{})
+				return d.eventAttribute(&e.Attributes[len(e.Attributes)-1])
+			})
+		}
+	})
+}
+
+func (d *jsonDecoder) eventAttribute(a *EventAttribute) bool {
+	return d.object(eventAttributeKeys, func(key int) bool {
+		var ok bool
+		switch key {
+		case 0:
+			a.Key, ok = d.string()
+		default:
+			a.Value, ok = d.string()
+		}
+		return ok
+	})
+}
@@ -0,0 +1,166 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnmarshalContractResultMatchesReflection(t *testing.T) {
+	specs := map[string]struct {
+		data string
+		// fast is true if the input is decoded without encoding/json
+		fast bool
+	}{
+		"full response": {
+			data: `{"ok":{"messages":[{"id":0,"msg":{"bank":{"send":{"to_address":"bob","amount":[{"denom":"ATOM","amount":"250"}]}}},"reply_on":"never"},{"id":18446744073709551615,"msg":{"wasm":{"execute":{"contract_addr":"contract","msg":"eyJyZWxlYXNlIjp7fX0=","funds":[]}}},"payload":"AQI=","gas_limit":12345,"reply_on":"success"}],"data":"8Auq","attributes":[{"key":"action","value":"release"}],"events":[{"type":"hackatom","attributes":[{"key":"action","value":"release"}]}]}}`,
+			fast: true,
+		},
+		"empty response": {
+			data: `{"ok":{"messages":[],"attributes":[],"events":[]}}`,
+			fast: true,
+		},
+		"null fields": {
+			data: `{"ok":{"messages":null,"data":null,"attributes":null,"events":[{"type":"a","attributes":null}]}}`,
+			fast: true,
+		},
+		"missing fields": {
+			data: `{"ok":{"events":[{"type":"a"}],"messages":[{"id":1,"msg":{"custom":{"x":"}]"}},"payload":null,"gas_limit":null}]}}`,
+			fast: true,
+		},
+		"empty data": {
+			data: `{"ok":{"data":"","messages":[{"id":1,"msg":{"gov":{"vote":{"proposal_id":1,"option":"yes"}}},"payload":"","reply_on":"always"}]}}`,
+			fast: true,
+		},
+		"whitespace": {
+			data: " {\n\t\"ok\" : { \"attributes\" : [ { \"key\" : \"a\" , \"value\" : \"ü\" } ] } }\r\n",
+			fast: true,
+		},
+		"error": {
+			data: `{"error":"Generic error: something went wrong"}`,
+			fast: true,
+		},
+		"ok null": {
+			data: `{"ok":null}`,
+			fast: true,
+		},
+		"empty object": {
+			data: `{}`,
+			fast: true,
+		},
+		"stargate message": {
+			data: `{"ok":{"messages":[{"id":1,"msg":{"stargate":{"type_url":"/a.B","value":""}},"reply_on":"error"}]}}`,
+			fast: true,
+		},
+		"mixed case keys": {
+			data: `{"OK":{"Messages":[{"ID":5,"Msg":{"bank":{"burn":{"amount":[]}}},"Reply_On":"never"}],"DATA":"AQ==","Events":[{"Type":"a","ATTRIBUTES":[{"KEY":"k","Value":"v"}]}]}}`,
+		},
+		"unknown keys": {
+			data: `{"ok":{"messages":[],"foo":{"bar":[1,2]},"events":[]},"baz":null}`,
+		},
+		"duplicate keys": {
+			data: `{"ok":{"attributes":[{"key":"a","value":"b"}],"attributes":[{"key":"c","value":"d"}]}}`,
+		},
+		"duplicate ok merges": {
+			data: `{"ok":{"data":"AQ=="},"ok":{"attributes":[]}}`,
+		},
+		"escaped strings": {
+			data: `{"ok":{"attributes":[{"key":"a\"b","value":"ü\n"}]}}`,
+		},
+		"invalid utf8": {
+			data: "{\"ok\":{\"attributes\":[{\"key\":\"a\xffb\",\"value\":\"\"}]}}",
+		},
+		"error null": {
+			data: `{"error":null}`,
+		},
+		"msg null": {
+			data: `{"ok":{"messages":[{"id":1,"msg":null,"reply_on":"never"}]}}`,
+		},
+		"float id": {
+			data: `{"ok":{"messages":[{"id":1.0,"msg":{},"reply_on":"never"}]}}`,
+		},
+		"negative id": {
+			data: `{"ok":{"messages":[{"id":-1,"msg":{},"reply_on":"never"}]}}`,
+		},
+		"id overflow": {
+			data: `{"ok":{"messages":[{"id":18446744073709551616,"msg":{},"reply_on":"never"}]}}`,
+		},
+		"leading zero": {
+			data: `{"ok":{"messages":[{"id":01,"msg":{},"reply_on":"never"}]}}`,
+		},
+		"invalid reply_on": {
+			data: `{"ok":{"messages":[{"id":1,"msg":{},"reply_on":"sometimes"}]}}`,
+		},
+		"invalid base64": {
+			data: `{"ok":{"data":"8Au"}}`,
+		},
+		"base64 with newline": {
+			data: `{"ok":{"data":"8A\nuq"}}`,
+		},
+		"invalid message": {
+			data: `{"ok":{"messages":[{"id":1,"msg":{"any":{},"stargate":{}},"reply_on":"never"}]}}`,
+		},
+		"wrong type": {
+			data: `{"ok":{"messages":{}}}`,
+		},
+		"wrong error type": {
+			data: `{"error":5}`,
+		},
+		"null": {
+			data: `null`,
+		},
+		"array": {
+			data: `[]`,
+		},
+		"trailing data": {
+			data: `{"ok":{}}x`,
+		},
+		"trailing comma": {
+			data: `{"ok":{"messages":[],}}`,
+		},
+		"truncated": {
+			data: `{"ok":{"messages":[{"id":1,"msg":{"bank":{`,
+		},
+		"unbalanced message": {
+			data: `{"ok":{"messages":[{"id":1,"msg":{"custom":]}},"reply_on":"never"}]}}`,
+		},
+		"empty": {
+			data: ``,
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			data := []byte(spec.data)
+
+			var exp ContractResult
+			expErr := json.Unmarshal(data, &exp)
+
+			var got ContractResult
+			gotErr := UnmarshalContractResult(data, &got)
+			if expErr != nil {
+				require.EqualError(t, gotErr, expErr.Error())
+			} else {
+				require.NoError(t, gotErr)
+			}
+			assert.Equal(t, exp, got)
+
+			var res ContractResult
+			dec := jsonDecoder{data: data}
+			assert.Equal(t, spec.fast, dec.contractResult(&res) && dec.end())
+		})
+	}
+}
+
+func TestUnmarshalContractResultMerges(t *testing.T) {
+	// encoding/json keeps existing values that are not overwritten
+	data := []byte(`{"ok":{"attributes":[]}}`)
+
+	exp := ContractResult{Err: "previous", Ok: &Response{Data: []byte{1}}}
+	require.NoError(t, json.Unmarshal(data, &exp))
+
+	got := ContractResult{Err: "previous", Ok: &Response{Data: []byte{1}}}
+	require.NoError(t, UnmarshalContractResult(data, &got))
+	assert.Equal(t, exp, got)
+}
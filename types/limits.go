@@ -0,0 +1,254 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// ResponseLimits restricts the size and structure of contract responses.
+// The limits are checked on the serialized response before it is decoded.
+// A zero value for any of the fields means unlimited.
+type ResponseLimits struct {
+	// MaxSubMessages is the maximum number of sub-messages in a response
+	MaxSubMessages int
+	// MaxEvents is the maximum number of custom events in a response
+	MaxEvents int
+	// MaxAttributes is the maximum total number of attributes in a response,
+	// i.e. the attributes of the response plus the attributes of all events.
+	MaxAttributes int
+	// MaxAttributeKeyLen is the maximum length of an attribute key in bytes
+	MaxAttributeKeyLen int
+	// MaxAttributeValueLen is the maximum length of an attribute value in bytes
+	MaxAttributeValueLen int
+	// MaxDataLen is the maximum length of the response's data field in bytes (after base64 decoding)
+	MaxDataLen int
+	// MaxDepth is the maximum nesting depth of JSON objects and arrays in the entire response
+	MaxDepth int
+}
+
+// ResponseLimitError is returned when a contract response violates a ResponseLimits value.
+type ResponseLimitError struct {
+	// Limit is a description of the limit, e.g. "submessages"
+	Limit string
+	// Max is the configured maximum
+	Max int
+	// Path locates the offending value, e.g. "ok.events[1].attributes[0].value"
+	Path string
+}
+
+var _ error = ResponseLimitError{}
+
+func (e ResponseLimitError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("response limit exceeded: %s > %d", e.Limit, e.Max)
+	}
+	return fmt.Sprintf("response limit exceeded: %s > %d at %s", e.Limit, e.Max, e.Path)
+}
+
+// IsZero returns true if no limit is set.
+func (l ResponseLimits) IsZero() bool {
+	return l == ResponseLimits{}
+}
+
+// Check verifies that the serialized contract result (ContractResult, IBCBasicResult or IBCReceiveResult)
+// does not exceed the limits. It does not decode the response and only looks at the
+// fields the limits refer to. Syntax errors are left to the decoder and not reported here.
+func (l ResponseLimits) Check(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	c := limitChecker{dec: dec, limits: l}
+	err := c.result()
+	if _, ok := err.(ResponseLimitError); ok {
+		return err
+	}
+	// syntax errors are reported by the decoder
+	return nil
+}
+
+type limitChecker struct {
+	dec        *json.Decoder
+	limits     ResponseLimits
+	depth      int
+	attributes int
+}
+
+// token reads the next token and keeps track of the nesting depth
+func (c *limitChecker) token() (json.Token, error) {
+	tok, err := c.dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	if d, ok := tok.(json.Delim); ok {
+		switch d {
+		case '{', '[':
+			c.depth++
+			if c.limits.MaxDepth > 0 && c.depth > c.limits.MaxDepth {
+				return nil, ResponseLimitError{Limit: "nesting depth", Max: c.limits.MaxDepth}
+			}
+		default:
+			c.depth--
+		}
+	}
+	return tok, nil
+}
+
+// skip consumes a value (or the remainder of the object or array if tok opened one)
+func (c *limitChecker) skip(tok json.Token) error {
+	d, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+	if d != '{' && d != '[' {
+		return nil
+	}
+	target := c.depth - 1
+	for c.depth > target {
+		if _, err := c.token(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// object calls field for every key of an object. Values that are not objects are skipped.
+func (c *limitChecker) object(field func(key string) error) error {
+	tok, err := c.token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('{') {
+		return c.skip(tok)
+	}
+	for c.dec.More() {
+		key, err := c.token()
+		if err != nil {
+			return err
+		}
+		if err := field(key.(string)); err != nil {
+			return err
+		}
+	}
+	_, err = c.token()
+	return err
+}
+
+// array calls elem for every element of an array and ensures it has at most maxLen elements.
+// Values that are not arrays are skipped.
+func (c *limitChecker) array(path, limit string, maxLen int, elem func(path string) error) error {
+	tok, err := c.token()
+	if err != nil {
+		return err
+	}
+	if tok != json.Delim('[') {
+		return c.skip(tok)
+	}
+	for i := 0; c.dec.More(); i++ {
+		if maxLen > 0 && i >= maxLen {
+			return ResponseLimitError{Limit: limit, Max: maxLen, Path: path}
+		}
+		if err := elem(fmt.Sprintf("%s[%d]", path, i)); err != nil {
+			return err
+		}
+	}
+	_, err = c.token()
+	return err
+}
+
+func (c *limitChecker) skipValue() error {
+	tok, err := c.token()
+	if err != nil {
+		return err
+	}
+	return c.skip(tok)
+}
+
+func (c *limitChecker) result() error {
+	return c.object(func(key string) error {
+		if strings.EqualFold(key, "ok") {
+			return c.response(key)
+		}
+		return c.skipValue()
+	})
+}
+
+func (c *limitChecker) response(path string) error {
+	return c.object(func(key string) error {
+		fieldPath := path + "." + key
+		switch canonicalKey(key, "messages", "attributes", "events", "data") {
+		case "messages":
+			return c.array(fieldPath, "submessages", c.limits.MaxSubMessages, func(string) error {
+				return c.skipValue()
+			})
+		case "attributes":
+			return c.array(fieldPath, "attributes", 0, c.attribute)
+		case "events":
+			return c.array(fieldPath, "events", c.limits.MaxEvents, c.event)
+		case "data":
+			tok, err := c.token()
+			if err != nil {
+				return err
+			}
+			if s, ok := tok.(string); ok && c.limits.MaxDataLen > 0 && base64DecodedLen(s) > c.limits.MaxDataLen {
+				return ResponseLimitError{Limit: "data length", Max: c.limits.MaxDataLen, Path: fieldPath}
+			}
+			return c.skip(tok)
+		default:
+			return c.skipValue()
+		}
+	})
+}
+
+func (c *limitChecker) event(path string) error {
+	return c.object(func(key string) error {
+		if strings.EqualFold(key, "attributes") {
+			return c.array(path+"."+key, "attributes", 0, c.attribute)
+		}
+		return c.skipValue()
+	})
+}
+
+func (c *limitChecker) attribute(path string) error {
+	c.attributes++
+	if c.limits.MaxAttributes > 0 && c.attributes > c.limits.MaxAttributes {
+		return ResponseLimitError{Limit: "attributes", Max: c.limits.MaxAttributes, Path: path}
+	}
+	return c.object(func(key string) error {
+		var limit string
+		var maxLen int
+		switch canonicalKey(key, "key", "value") {
+		case "key":
+			limit, maxLen = "attribute key length", c.limits.MaxAttributeKeyLen
+		case "value":
+			limit, maxLen = "attribute value length", c.limits.MaxAttributeValueLen
+		default:
+			return c.skipValue()
+		}
+		tok, err := c.token()
+		if err != nil {
+			return err
+		}
+		if s, ok := tok.(string); ok && maxLen > 0 && len(s) > maxLen {
+			return ResponseLimitError{Limit: limit, Max: maxLen, Path: path + "." + key}
+		}
+		return c.skip(tok)
+	})
+}
+
+// canonicalKey returns the name in names that matches key case-insensitively like
+// encoding/json matches keys to struct fields, or key if none matches.
+func canonicalKey(key string, names ...string) string {
+	for _, name := range names {
+		if strings.EqualFold(key, name) {
+			return name
+		}
+	}
+	return key
+}
+
+// base64DecodedLen returns the number of bytes encoded in the padded standard base64 string s
+func base64DecodedLen(s string) int {
+	s = strings.TrimRight(s, "=")
+	return len(s) * 6 / 8
+}
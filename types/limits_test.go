@@ -0,0 +1,122 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseLimitsCheck(t *testing.T) {
+	const response = `{"ok":{"messages":[{"id":0,"msg":{"bank":{"send":{"to_address":"bob","amount":[{"denom":"ATOM","amount":"250"}]}}},"reply_on":"never"},{"id":1,"msg":{"custom":{}},"reply_on":"never"}],"data":"AAECAwQ=","attributes":[{"key":"action","value":"release"}],"events":[{"type":"foo","attributes":[{"key":"a","value":"b"},{"key":"c","value":"dddd"}]}]}}`
+
+	specs := map[string]struct {
+		limits ResponseLimits
+		expErr string
+	}{
+		"no limits": {},
+		"all limits reached exactly": {
+			limits: ResponseLimits{
+				MaxSubMessages:       2,
+				MaxEvents:            1,
+				MaxAttributes:        3,
+				MaxAttributeKeyLen:   6,
+				MaxAttributeValueLen: 7,
+				MaxDataLen:           5,
+				MaxDepth:             9,
+			},
+		},
+		"submessages": {
+			limits: ResponseLimits{MaxSubMessages: 1},
+			expErr: "response limit exceeded: submessages > 1 at ok.messages",
+		},
+		"attributes including events": {
+			limits: ResponseLimits{MaxAttributes: 2},
+			expErr: "response limit exceeded: attributes > 2 at ok.events[0].attributes[1]",
+		},
+		"attribute key": {
+			limits: ResponseLimits{MaxAttributeKeyLen: 5},
+			expErr: "response limit exceeded: attribute key length > 5 at ok.attributes[0].key",
+		},
+		"attribute value": {
+			limits: ResponseLimits{MaxAttributeValueLen: 3},
+			expErr: "response limit exceeded: attribute value length > 3 at ok.attributes[0].value",
+		},
+		"data": {
+			limits: ResponseLimits{MaxDataLen: 4},
+			expErr: "response limit exceeded: data length > 4 at ok.data",
+		},
+		"depth": {
+			limits: ResponseLimits{MaxDepth: 8},
+			expErr: "response limit exceeded: nesting depth > 8",
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			err := spec.limits.Check([]byte(response))
+			if spec.expErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, spec.expErr)
+			assert.IsType(t, ResponseLimitError{}, err)
+		})
+	}
+
+	// events are counted separately
+	err := ResponseLimits{MaxEvents: 1}.Check([]byte(`{"ok":{"events":[{"type":"a","attributes":[]},{"type":"b","attributes":[]}]}}`))
+	require.EqualError(t, err, "response limit exceeded: events > 1 at ok.events")
+
+	// syntax errors are left to the decoder
+	require.NoError(t, ResponseLimits{MaxEvents: 1}.Check([]byte(`{"ok":{"events":[`)))
+}
+
+func TestResponseLimitsCheckMixedCaseKeys(t *testing.T) {
+	// encoding/json matches keys case-insensitively, so the limits must do the same
+	const response = `{"OK":{"Messages":[{"id":0,"msg":{"custom":{}},"reply_on":"never"},{"id":1,"msg":{"custom":{}},"reply_on":"never"}],"DATA":"AAECAwQ=","Attributes":[{"KEY":"action","Value":"release"}],"eVeNtS":[{"type":"foo","ATTRIBUTES":[{"key":"a","value":"b"}]},{"type":"bar","attributes":[]}]}}`
+
+	var result ContractResult
+	require.NoError(t, json.Unmarshal([]byte(response), &result))
+	require.NotNil(t, result.Ok)
+	require.Len(t, result.Ok.Messages, 2)
+	require.Len(t, result.Ok.Events, 2)
+	require.Equal(t, []EventAttribute{{Key: "action", Value: "release"}}, result.Ok.Attributes)
+
+	specs := map[string]struct {
+		limits ResponseLimits
+		expErr string
+	}{
+		"submessages": {
+			limits: ResponseLimits{MaxSubMessages: 1},
+			expErr: "response limit exceeded: submessages > 1 at OK.Messages",
+		},
+		"events": {
+			limits: ResponseLimits{MaxEvents: 1},
+			expErr: "response limit exceeded: events > 1 at OK.eVeNtS",
+		},
+		"attributes including events": {
+			limits: ResponseLimits{MaxAttributes: 1},
+			expErr: "response limit exceeded: attributes > 1 at OK.eVeNtS[0].ATTRIBUTES[0]",
+		},
+		"attribute key": {
+			limits: ResponseLimits{MaxAttributeKeyLen: 5},
+			expErr: "response limit exceeded: attribute key length > 5 at OK.Attributes[0].KEY",
+		},
+		"attribute value": {
+			limits: ResponseLimits{MaxAttributeValueLen: 3},
+			expErr: "response limit exceeded: attribute value length > 3 at OK.Attributes[0].Value",
+		},
+		"data": {
+			limits: ResponseLimits{MaxDataLen: 4},
+			expErr: "response limit exceeded: data length > 4 at OK.DATA",
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			err := spec.limits.Check([]byte(response))
+			require.EqualError(t, err, spec.expErr)
+			assert.IsType(t, ResponseLimitError{}, err)
+		})
+	}
+}
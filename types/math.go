@@ -0,0 +1,388 @@
+package types
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"math/bits"
+	"strconv"
+)
+
+// OverflowError is returned by checked arithmetic when the result does not fit into the type.
+type OverflowError struct {
+	// Operation is the name of the operation as in cosmwasm-std, e.g. "Add" or "Mul"
+	Operation string
+}
+
+var _ error = OverflowError{}
+
+func (e OverflowError) Error() string {
+	return fmt.Sprintf("Cannot %s with given operands", e.Operation)
+}
+
+// DivideByZeroError is returned by checked arithmetic when dividing by zero.
+type DivideByZeroError struct{}
+
+var _ error = DivideByZeroError{}
+
+func (e DivideByZeroError) Error() string {
+	return "Cannot divide by zero"
+}
+
+// Uint128 is an unsigned 128-bit integer with the semantics of cosmwasm_std::Uint128.
+// Like there, it is encoded as a decimal string in JSON. The zero value is 0.
+type Uint128 struct {
+	// limbs are in little-endian order
+	limbs [2]uint64
+}
+
+// NewUint128 creates a Uint128 from a uint64.
+func NewUint128(v uint64) Uint128 {
+	return Uint128{limbs: [2]uint64{v, 0}}
+}
+
+// MaxUint128 returns the largest Uint128, i.e. 2^128 - 1.
+func MaxUint128() Uint128 {
+	return Uint128{limbs: [2]uint64{^uint64(0), ^uint64(0)}}
+}
+
+// ParseUint128 parses a decimal string like cosmwasm_std::Uint128::from_str,
+// i.e. an optional "+" followed by at least one digit.
+func ParseUint128(s string) (Uint128, error) {
+	var u Uint128
+	err := parseLimbs(s, u.limbs[:], "u128")
+	return u, err
+}
+
+// Uint128FromBig converts a big.Int to a Uint128. It returns an OverflowError if b is negative or too large.
+func Uint128FromBig(b *big.Int) (Uint128, error) {
+	var u Uint128
+	if !bigToLimbs(b, u.limbs[:]) {
+		return Uint128{}, OverflowError{Operation: "Conversion"}
+	}
+	return u, nil
+}
+
+// BigInt returns the value as a big.Int.
+func (u Uint128) BigInt() *big.Int {
+	return limbsToBig(u.limbs[:])
+}
+
+// IsUint64 reports whether the value can be represented as a uint64.
+func (u Uint128) IsUint64() bool {
+	return u.limbs[1] == 0
+}
+
+// Uint64 returns the low 64 bits of the value. Use IsUint64 to check whether it fits.
+func (u Uint128) Uint64() uint64 {
+	return u.limbs[0]
+}
+
+func (u Uint128) IsZero() bool {
+	return u == Uint128{}
+}
+
+// Cmp returns -1, 0 or +1 if u is less than, equal to or greater than o.
+func (u Uint128) Cmp(o Uint128) int {
+	return cmpLimbs(u.limbs[:], o.limbs[:])
+}
+
+func (u Uint128) CheckedAdd(o Uint128) (Uint128, error) {
+	var z Uint128
+	if addLimbs(z.limbs[:], u.limbs[:], o.limbs[:]) != 0 {
+		return Uint128{}, OverflowError{Operation: "Add"}
+	}
+	return z, nil
+}
+
+func (u Uint128) CheckedSub(o Uint128) (Uint128, error) {
+	var z Uint128
+	if subLimbs(z.limbs[:], u.limbs[:], o.limbs[:]) != 0 {
+		return Uint128{}, OverflowError{Operation: "Sub"}
+	}
+	return z, nil
+}
+
+func (u Uint128) CheckedMul(o Uint128) (Uint128, error) {
+	var z Uint128
+	err := mulLimbs(z.limbs[:], u.limbs[:], o.limbs[:])
+	return z, err
+}
+
+func (u Uint128) CheckedDiv(o Uint128) (Uint128, error) {
+	var z Uint128
+	err := quoLimbs(z.limbs[:], u.limbs[:], o.limbs[:], false)
+	return z, err
+}
+
+func (u Uint128) CheckedRem(o Uint128) (Uint128, error) {
+	var z Uint128
+	err := quoLimbs(z.limbs[:], u.limbs[:], o.limbs[:], true)
+	return z, err
+}
+
+func (u Uint128) String() string {
+	return formatLimbs(u.limbs[:])
+}
+
+func (u Uint128) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.String())
+}
+
+func (u *Uint128) UnmarshalJSON(data []byte) error {
+	s, err := unmarshalNumberString(data, "Uint128")
+	if err != nil {
+		return err
+	}
+	*u, err = ParseUint128(s)
+	return err
+}
+
+// Uint256 is an unsigned 256-bit integer with the semantics of cosmwasm_std::Uint256.
+// Like there, it is encoded as a decimal string in JSON. The zero value is 0.
+type Uint256 struct {
+	// limbs are in little-endian order
+	limbs [4]uint64
+}
+
+// NewUint256 creates a Uint256 from a uint64.
+func NewUint256(v uint64) Uint256 {
+	return Uint256{limbs: [4]uint64{v, 0, 0, 0}}
+}
+
+// MaxUint256 returns the largest Uint256, i.e. 2^256 - 1.
+func MaxUint256() Uint256 {
+	return Uint256{limbs: [4]uint64{^uint64(0), ^uint64(0), ^uint64(0), ^uint64(0)}}
+}
+
+// ParseUint256 parses a decimal string like cosmwasm_std::Uint256::from_str,
+// i.e. an optional "+" followed by at least one digit.
+func ParseUint256(s string) (Uint256, error) {
+	var u Uint256
+	err := parseLimbs(s, u.limbs[:], "u256")
+	return u, err
+}
+
+// Uint256FromBig converts a big.Int to a Uint256. It returns an OverflowError if b is negative or too large.
+func Uint256FromBig(b *big.Int) (Uint256, error) {
+	var u Uint256
+	if !bigToLimbs(b, u.limbs[:]) {
+		return Uint256{}, OverflowError{Operation: "Conversion"}
+	}
+	return u, nil
+}
+
+// Uint256FromUint128 converts a Uint128 to a Uint256, which never overflows.
+func Uint256FromUint128(v Uint128) Uint256 {
+	return Uint256{limbs: [4]uint64{v.limbs[0], v.limbs[1], 0, 0}}
+}
+
+// BigInt returns the value as a big.Int.
+func (u Uint256) BigInt() *big.Int {
+	return limbsToBig(u.limbs[:])
+}
+
+// IsUint64 reports whether the value can be represented as a uint64.
+func (u Uint256) IsUint64() bool {
+	return u.limbs[1] == 0 && u.limbs[2] == 0 && u.limbs[3] == 0
+}
+
+// Uint64 returns the low 64 bits of the value. Use IsUint64 to check whether it fits.
+func (u Uint256) Uint64() uint64 {
+	return u.limbs[0]
+}
+
+// Uint128 converts the value to a Uint128. It returns an OverflowError if it does not fit.
+func (u Uint256) Uint128() (Uint128, error) {
+	if u.limbs[2] != 0 || u.limbs[3] != 0 {
+		return Uint128{}, OverflowError{Operation: "Conversion"}
+	}
+	return Uint128{limbs: [2]uint64{u.limbs[0], u.limbs[1]}}, nil
+}
+
+func (u Uint256) IsZero() bool {
+	return u == Uint256{}
+}
+
+// Cmp returns -1, 0 or +1 if u is less than, equal to or greater than o.
+func (u Uint256) Cmp(o Uint256) int {
+	return cmpLimbs(u.limbs[:], o.limbs[:])
+}
+
+func (u Uint256) CheckedAdd(o Uint256) (Uint256, error) {
+	var z Uint256
+	if addLimbs(z.limbs[:], u.limbs[:], o.limbs[:]) != 0 {
+		return Uint256{}, OverflowError{Operation: "Add"}
+	}
+	return z, nil
+}
+
+func (u Uint256) CheckedSub(o Uint256) (Uint256, error) {
+	var z Uint256
+	if subLimbs(z.limbs[:], u.limbs[:], o.limbs[:]) != 0 {
+		return Uint256{}, OverflowError{Operation: "Sub"}
+	}
+	return z, nil
+}
+
+func (u Uint256) CheckedMul(o Uint256) (Uint256, error) {
+	var z Uint256
+	err := mulLimbs(z.limbs[:], u.limbs[:], o.limbs[:])
+	return z, err
+}
+
+func (u Uint256) CheckedDiv(o Uint256) (Uint256, error) {
+	var z Uint256
+	err := quoLimbs(z.limbs[:], u.limbs[:], o.limbs[:], false)
+	return z, err
+}
+
+func (u Uint256) CheckedRem(o Uint256) (Uint256, error) {
+	var z Uint256
+	err := quoLimbs(z.limbs[:], u.limbs[:], o.limbs[:], true)
+	return z, err
+}
+
+func (u Uint256) String() string {
+	return formatLimbs(u.limbs[:])
+}
+
+func (u Uint256) MarshalJSON() ([]byte, error) {
+	return json.Marshal(u.String())
+}
+
+func (u *Uint256) UnmarshalJSON(data []byte) error {
+	s, err := unmarshalNumberString(data, "Uint256")
+	if err != nil {
+		return err
+	}
+	*u, err = ParseUint256(s)
+	return err
+}
+
+// unmarshalNumberString decodes the JSON string of a number type. Like in cosmwasm-std,
+// JSON numbers are not accepted since they cannot represent the full range.
+func unmarshalNumberString(data []byte, typeName string) (string, error) {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return "", fmt.Errorf("invalid %s: expected a string: %w", typeName, err)
+	}
+	return s, nil
+}
+
+// parseLimbs parses a decimal string like Rust's integer from_str into limbs.
+func parseLimbs(s string, limbs []uint64, name string) error {
+	if s == "" {
+		return fmt.Errorf("Parsing %s: cannot parse integer from empty string", name)
+	}
+	digits := s
+	if digits[0] == '+' {
+		digits = digits[1:]
+	}
+	if digits == "" {
+		return fmt.Errorf("Parsing %s: invalid digit found in string", name)
+	}
+	for i := 0; i < len(digits); i++ {
+		if digits[i] < '0' || digits[i] > '9' {
+			return fmt.Errorf("Parsing %s: invalid digit found in string", name)
+		}
+	}
+	// fast path for values that fit into a uint64
+	if len(digits) <= 19 {
+		v, err := strconv.ParseUint(digits, 10, 64)
+		if err != nil {
+			return fmt.Errorf("Parsing %s: %w", name, err)
+		}
+		clear(limbs)
+		limbs[0] = v
+		return nil
+	}
+	b, ok := new(big.Int).SetString(digits, 10)
+	if !ok {
+		return fmt.Errorf("Parsing %s: invalid digit found in string", name)
+	}
+	if !bigToLimbs(b, limbs) {
+		return fmt.Errorf("Parsing %s: number too large to fit in target type", name)
+	}
+	return nil
+}
+
+func formatLimbs(limbs []uint64) string {
+	for _, l := range limbs[1:] {
+		if l != 0 {
+			return limbsToBig(limbs).String()
+		}
+	}
+	return strconv.FormatUint(limbs[0], 10)
+}
+
+func limbsToBig(limbs []uint64) *big.Int {
+	buf := make([]byte, 8*len(limbs))
+	for i, l := range limbs {
+		binary.BigEndian.PutUint64(buf[len(buf)-8*(i+1):], l)
+	}
+	return new(big.Int).SetBytes(buf)
+}
+
+// bigToLimbs writes b into limbs and reports whether it fits.
+func bigToLimbs(b *big.Int, limbs []uint64) bool {
+	if b.Sign() < 0 || b.BitLen() > 64*len(limbs) {
+		return false
+	}
+	buf := make([]byte, 8*len(limbs))
+	b.FillBytes(buf)
+	for i := range limbs {
+		limbs[i] = binary.BigEndian.Uint64(buf[len(buf)-8*(i+1):])
+	}
+	return true
+}
+
+func cmpLimbs(x, y []uint64) int {
+	for i := len(x) - 1; i >= 0; i-- {
+		switch {
+		case x[i] < y[i]:
+			return -1
+		case x[i] > y[i]:
+			return 1
+		}
+	}
+	return 0
+}
+
+func addLimbs(z, x, y []uint64) (carry uint64) {
+	for i := range z {
+		z[i], carry = bits.Add64(x[i], y[i], carry)
+	}
+	return carry
+}
+
+func subLimbs(z, x, y []uint64) (borrow uint64) {
+	for i := range z {
+		z[i], borrow = bits.Sub64(x[i], y[i], borrow)
+	}
+	return borrow
+}
+
+func mulLimbs(z, x, y []uint64) error {
+	if !bigToLimbs(new(big.Int).Mul(limbsToBig(x), limbsToBig(y)), z) {
+		return OverflowError{Operation: "Mul"}
+	}
+	return nil
+}
+
+func quoLimbs(z, x, y []uint64, rem bool) error {
+	divisor := limbsToBig(y)
+	if divisor.Sign() == 0 {
+		return DivideByZeroError{}
+	}
+	var res *big.Int
+	if rem {
+		res = new(big.Int).Rem(limbsToBig(x), divisor)
+	} else {
+		res = new(big.Int).Quo(limbsToBig(x), divisor)
+	}
+	// the result is never larger than x
+	bigToLimbs(res, z)
+	return nil
+}
@@ -0,0 +1,185 @@
+package types
+
+import (
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseUint128(t *testing.T) {
+	specs := map[string]struct {
+		input  string
+		exp    string
+		expErr string
+	}{
+		"zero":             {input: "0", exp: "0"},
+		"uint64":           {input: "18446744073709551615", exp: "18446744073709551615"},
+		"above uint64":     {input: "18446744073709551616", exp: "18446744073709551616"},
+		"max":              {input: "340282366920938463463374607431768211455", exp: "340282366920938463463374607431768211455"},
+		"leading zeros":    {input: "000000000000000000000000042", exp: "42"},
+		"plus sign":        {input: "+42", exp: "42"},
+		"overflow":         {input: "340282366920938463463374607431768211456", expErr: "Parsing u128: number too large to fit in target type"},
+		"empty":            {input: "", expErr: "Parsing u128: cannot parse integer from empty string"},
+		"only plus":        {input: "+", expErr: "Parsing u128: invalid digit found in string"},
+		"negative":         {input: "-1", expErr: "Parsing u128: invalid digit found in string"},
+		"decimal":          {input: "1.5", expErr: "Parsing u128: invalid digit found in string"},
+		"whitespace":       {input: " 1", expErr: "Parsing u128: invalid digit found in string"},
+		"underscores":      {input: "1_000", expErr: "Parsing u128: invalid digit found in string"},
+		"hex":              {input: "0x10", expErr: "Parsing u128: invalid digit found in string"},
+		"double plus sign": {input: "++1", expErr: "Parsing u128: invalid digit found in string"},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			got, err := ParseUint128(spec.input)
+			if spec.expErr != "" {
+				require.EqualError(t, err, spec.expErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, spec.exp, got.String())
+		})
+	}
+}
+
+func TestParseUint256(t *testing.T) {
+	const max = "115792089237316195423570985008687907853269984665640564039457584007913129639935"
+	got, err := ParseUint256(max)
+	require.NoError(t, err)
+	assert.Equal(t, MaxUint256(), got)
+	assert.Equal(t, max, got.String())
+
+	_, err = ParseUint256("115792089237316195423570985008687907853269984665640564039457584007913129639936")
+	require.EqualError(t, err, "Parsing u256: number too large to fit in target type")
+	_, err = ParseUint256("")
+	require.EqualError(t, err, "Parsing u256: cannot parse integer from empty string")
+}
+
+func TestUint128Arithmetic(t *testing.T) {
+	max := MaxUint128()
+	one := NewUint128(1)
+
+	sum, err := NewUint128(math.MaxUint64).CheckedAdd(one)
+	require.NoError(t, err)
+	assert.Equal(t, "18446744073709551616", sum.String())
+	assert.False(t, sum.IsUint64())
+	_, err = max.CheckedAdd(one)
+	require.Equal(t, OverflowError{Operation: "Add"}, err)
+
+	diff, err := sum.CheckedSub(one)
+	require.NoError(t, err)
+	assert.Equal(t, NewUint128(math.MaxUint64), diff)
+	assert.True(t, diff.IsUint64())
+	assert.Equal(t, uint64(math.MaxUint64), diff.Uint64())
+	_, err = one.CheckedSub(NewUint128(2))
+	require.Equal(t, OverflowError{Operation: "Sub"}, err)
+
+	product, err := NewUint128(math.MaxUint64).CheckedMul(NewUint128(math.MaxUint64))
+	require.NoError(t, err)
+	assert.Equal(t, "340282366920938463426481119284349108225", product.String())
+	_, err = max.CheckedMul(NewUint128(2))
+	require.Equal(t, OverflowError{Operation: "Mul"}, err)
+
+	quo, err := max.CheckedDiv(NewUint128(math.MaxUint64))
+	require.NoError(t, err)
+	assert.Equal(t, "18446744073709551617", quo.String())
+	rem, err := NewUint128(10).CheckedRem(NewUint128(3))
+	require.NoError(t, err)
+	assert.Equal(t, one, rem)
+	_, err = one.CheckedDiv(Uint128{})
+	require.Equal(t, DivideByZeroError{}, err)
+	_, err = one.CheckedRem(Uint128{})
+	require.Equal(t, DivideByZeroError{}, err)
+
+	assert.Equal(t, -1, one.Cmp(max))
+	assert.Equal(t, 1, max.Cmp(one))
+	assert.Equal(t, 0, sum.Cmp(Uint128{limbs: [2]uint64{0, 1}}))
+	assert.True(t, Uint128{}.IsZero())
+	assert.False(t, one.IsZero())
+}
+
+func TestUint256Arithmetic(t *testing.T) {
+	max := MaxUint256()
+	one := NewUint256(1)
+
+	_, err := max.CheckedAdd(one)
+	require.Equal(t, OverflowError{Operation: "Add"}, err)
+	_, err = NewUint256(0).CheckedSub(one)
+	require.Equal(t, OverflowError{Operation: "Sub"}, err)
+	_, err = max.CheckedMul(NewUint256(2))
+	require.Equal(t, OverflowError{Operation: "Mul"}, err)
+	_, err = max.CheckedDiv(Uint256{})
+	require.Equal(t, DivideByZeroError{}, err)
+
+	u128 := Uint256FromUint128(MaxUint128())
+	product, err := u128.CheckedMul(u128)
+	require.NoError(t, err)
+	assert.Equal(t, "115792089237316195423570985008687907852589419931798687112530834793049593217025", product.String())
+	quo, err := product.CheckedDiv(u128)
+	require.NoError(t, err)
+	assert.Equal(t, u128, quo)
+
+	back, err := quo.Uint128()
+	require.NoError(t, err)
+	assert.Equal(t, MaxUint128(), back)
+	_, err = product.Uint128()
+	require.Equal(t, OverflowError{Operation: "Conversion"}, err)
+}
+
+func TestUintBigConversion(t *testing.T) {
+	b, ok := new(big.Int).SetString("340282366920938463463374607431768211455", 10)
+	require.True(t, ok)
+	u, err := Uint128FromBig(b)
+	require.NoError(t, err)
+	assert.Equal(t, MaxUint128(), u)
+	assert.Equal(t, 0, b.Cmp(u.BigInt()))
+
+	_, err = Uint128FromBig(new(big.Int).Add(b, big.NewInt(1)))
+	require.Equal(t, OverflowError{Operation: "Conversion"}, err)
+	_, err = Uint128FromBig(big.NewInt(-1))
+	require.Equal(t, OverflowError{Operation: "Conversion"}, err)
+
+	u256, err := Uint256FromBig(new(big.Int).Add(b, big.NewInt(1)))
+	require.NoError(t, err)
+	assert.Equal(t, "340282366920938463463374607431768211456", u256.String())
+}
+
+func TestUintJSON(t *testing.T) {
+	type amounts struct {
+		Small Uint128 `json:"small"`
+		Large Uint256 `json:"large"`
+	}
+	const doc = `{"small":"340282366920938463463374607431768211455","large":"1"}`
+
+	var got amounts
+	require.NoError(t, json.Unmarshal([]byte(doc), &got))
+	assert.Equal(t, amounts{Small: MaxUint128(), Large: NewUint256(1)}, got)
+
+	bz, err := json.Marshal(got)
+	require.NoError(t, err)
+	assert.Equal(t, doc, string(bz))
+
+	// numbers are not accepted, like in cosmwasm-std
+	err = json.Unmarshal([]byte(`{"small":1}`), &got)
+	require.ErrorContains(t, err, "invalid Uint128: expected a string")
+	err = json.Unmarshal([]byte(`{"large":"-1"}`), &got)
+	require.EqualError(t, err, "Parsing u256: invalid digit found in string")
+}
+
+func TestCoinParseAmount(t *testing.T) {
+	amount, err := NewCoin(250, "ATOM").ParseAmount()
+	require.NoError(t, err)
+	assert.Equal(t, NewUint128(250), amount)
+
+	coin := NewCoinFromUint128(MaxUint128(), "uatom")
+	assert.Equal(t, Coin{Denom: "uatom", Amount: "340282366920938463463374607431768211455"}, coin)
+	amount, err = coin.ParseAmount()
+	require.NoError(t, err)
+	assert.Equal(t, MaxUint128(), amount)
+
+	_, err = Coin{Denom: "uatom", Amount: "12.5"}.ParseAmount()
+	require.Error(t, err)
+}
@@ -183,6 +183,11 @@ type WeightedVoteOption struct {
 	Weight string `json:"weight"`
 }
 
+// ParseWeight parses the weight as a Decimal.
+func (o WeightedVoteOption) ParseWeight() (Decimal, error) {
+	return ParseDecimal(o.Weight)
+}
+
 const (
 	UnsetVoteOption voteOption = iota // The default value. We never return this in any valid instance (see toVoteOption).
 	Yes
@@ -241,6 +241,21 @@ type Validator struct {
 	MaxChangeRate string `json:"max_change_rate"`
 }
 
+// ParseCommission parses the commission as a Decimal.
+func (v Validator) ParseCommission() (Decimal, error) {
+	return ParseDecimal(v.Commission)
+}
+
+// ParseMaxCommission parses the maximum commission as a Decimal.
+func (v Validator) ParseMaxCommission() (Decimal, error) {
+	return ParseDecimal(v.MaxCommission)
+}
+
+// ParseMaxChangeRate parses the maximum change rate as a Decimal.
+func (v Validator) ParseMaxChangeRate() (Decimal, error) {
+	return ParseDecimal(v.MaxChangeRate)
+}
+
 type AllDelegationsQuery struct {
 	Delegator string `json:"delegator"`
 }
@@ -72,3 +72,14 @@ type Iterator interface {
 	// Close closes the iterator, releasing any allocated resources.
 	Close() error
 }
+
+// MeteredKVStore is an optional extension of KVStore for stores that charge gas for access.
+// It allows iterators to read records ahead in batches without charging gas for them (see
+// VM.SetIteratorBatchSize). The gas of a record is charged by stepping an iterator of the
+// metered store once the contract reads the record.
+type MeteredKVStore interface {
+	KVStore
+
+	// Unmetered returns a view of the same data that does not charge gas.
+	Unmetered() KVStore
+}
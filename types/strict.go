@@ -0,0 +1,230 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// UnknownFieldError is returned by CheckUnknownFields if the JSON contains a key
+// that does not correspond to a field of the Go type it is decoded into.
+type UnknownFieldError struct {
+	// Type is the name of the Go type, e.g. "CosmosMsg"
+	Type string
+	// Path locates the object containing the unknown key, e.g. "messages[0].msg".
+	// It is empty for the top level object.
+	Path string
+	// Field is the unrecognised JSON key
+	Field string
+	// Variant is true if Type is an enum-like type, i.e. Field is an unknown variant.
+	Variant bool
+}
+
+var _ error = UnknownFieldError{}
+
+func (e UnknownFieldError) Error() string {
+	kind := "field"
+	if e.Variant {
+		kind = "variant"
+	}
+	if e.Path == "" {
+		return fmt.Sprintf("unknown %s %q in %s", kind, e.Field, e.Type)
+	}
+	return fmt.Sprintf("unknown %s %q in %s at %s", kind, e.Field, e.Type, e.Path)
+}
+
+// enumTypes are the types in which exactly one field must be set (see validate.go).
+// Unknown keys in those are reported as unknown variants.
+var enumTypes = map[reflect.Type]bool{
+	reflect.TypeOf(CosmosMsg{}):            true,
+	reflect.TypeOf(BankMsg{}):              true,
+	reflect.TypeOf(IBCMsg{}):               true,
+	reflect.TypeOf(GovMsg{}):               true,
+	reflect.TypeOf(StakingMsg{}):           true,
+	reflect.TypeOf(DistributionMsg{}):      true,
+	reflect.TypeOf(WasmMsg{}):              true,
+	reflect.TypeOf(QueryRequest{}):         true,
+	reflect.TypeOf(BankQuery{}):            true,
+	reflect.TypeOf(IBCQuery{}):             true,
+	reflect.TypeOf(StakingQuery{}):         true,
+	reflect.TypeOf(DistributionQuery{}):    true,
+	reflect.TypeOf(WasmQuery{}):            true,
+	reflect.TypeOf(SystemError{}):          true,
+	reflect.TypeOf(IBCChannelOpenMsg{}):    true,
+	reflect.TypeOf(IBCChannelConnectMsg{}): true,
+	reflect.TypeOf(IBCChannelCloseMsg{}):   true,
+	reflect.TypeOf(IBCSourceCallbackMsg{}): true,
+	reflect.TypeOf(ContractResult{}):       true,
+	reflect.TypeOf(SubMsgResult{}):         true,
+	reflect.TypeOf(IBCChannelOpenResult{}): true,
+	reflect.TypeOf(IBCBasicResult{}):       true,
+	reflect.TypeOf(IBCReceiveResult{}):     true,
+	reflect.TypeOf(QuerierResult{}):        true,
+}
+
+// fieldAliases lists additional keys accepted by custom UnmarshalJSON implementations.
+var fieldAliases = map[reflect.Type]map[string]reflect.Type{
+	reflect.TypeOf(CosmosMsg{}): {"stargate": reflect.TypeOf(&AnyMsg{})},
+	reflect.TypeOf(VoteMsg{}):   {"vote": reflect.TypeOf(UnsetVoteOption)},
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// fieldCache maps a struct type to a map from JSON key to field type
+var fieldCache sync.Map
+
+func jsonFields(t reflect.Type) map[string]reflect.Type {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.(map[string]reflect.Type)
+	}
+	fields := make(map[string]reflect.Type)
+	collectJSONFields(t, fields)
+	for name, typ := range fieldAliases[t] {
+		fields[name] = typ
+	}
+	fieldCache.Store(t, fields)
+	return fields
+}
+
+func collectJSONFields(t reflect.Type, fields map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, _, _ := strings.Cut(tag, ",")
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				collectJSONFields(ft, fields)
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		fields[name] = f.Type
+	}
+}
+
+// isOpaque returns true for types whose JSON representation is not checked because
+// they are decoded by a custom unmarshaler (e.g. Uint64 or json.RawMessage).
+// Structs with aliases and slices of non-bytes (e.g. Array) are still checked.
+func isOpaque(t reflect.Type) bool {
+	if !reflect.PointerTo(t).Implements(unmarshalerType) {
+		return false
+	}
+	if _, ok := fieldAliases[t]; ok {
+		return false
+	}
+	return t.Kind() != reflect.Slice || t.Elem().Kind() == reflect.Uint8
+}
+
+// CheckUnknownFields returns an UnknownFieldError if data contains an object key
+// that does not match a field of the type of v (or of a nested type).
+// Keys are matched case-sensitively, unlike encoding/json does.
+// data must be valid JSON, i.e. it should be decoded into v before calling this.
+func CheckUnknownFields(data []byte, v any) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return checkValue(dec, reflect.TypeOf(v), "")
+}
+
+func checkValue(dec *json.Decoder, t reflect.Type, path string) error {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	delim, ok := tok.(json.Delim)
+	if !ok {
+		return nil
+	}
+	if t == nil || isOpaque(t) {
+		return skipRest(dec)
+	}
+	switch delim {
+	case '{':
+		switch t.Kind() {
+		case reflect.Struct:
+			fields := jsonFields(t)
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				key := keyTok.(string)
+				ft, ok := fields[key]
+				if !ok {
+					return UnknownFieldError{Type: typeName(t), Path: path, Field: key, Variant: enumTypes[t]}
+				}
+				if err := checkValue(dec, ft, joinPath(path, key)); err != nil {
+					return err
+				}
+			}
+		case reflect.Map:
+			for dec.More() {
+				keyTok, err := dec.Token()
+				if err != nil {
+					return err
+				}
+				if err := checkValue(dec, t.Elem(), joinPath(path, keyTok.(string))); err != nil {
+					return err
+				}
+			}
+		default:
+			return skipRest(dec)
+		}
+	case '[':
+		if t.Kind() != reflect.Slice && t.Kind() != reflect.Array {
+			return skipRest(dec)
+		}
+		for i := 0; dec.More(); i++ {
+			if err := checkValue(dec, t.Elem(), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	// consume closing delimiter
+	_, err = dec.Token()
+	return err
+}
+
+// skipRest consumes the remainder of an object or array whose opening delimiter was already read
+func skipRest(dec *json.Decoder) error {
+	depth := 1
+	for depth > 0 {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); ok {
+			switch d {
+			case '{', '[':
+				depth++
+			default:
+				depth--
+			}
+		}
+	}
+	return nil
+}
+
+func typeName(t reflect.Type) string {
+	if t.Name() != "" {
+		return t.Name()
+	}
+	return t.String()
+}
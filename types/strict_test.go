@@ -0,0 +1,62 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckUnknownFields(t *testing.T) {
+	specs := map[string]struct {
+		json   string
+		expErr string
+	}{
+		"valid": {
+			json: `{"ok":{"messages":[{"id":1,"msg":{"wasm":{"execute":{"contract_addr":"a","msg":"e30=","funds":[{"denom":"a","amount":"1"}]}}},"reply_on":"never","gas_limit":5}],"data":null,"attributes":[{"key":"a","value":"b"}],"events":[{"type":"t","attributes":[]}]}}`,
+		},
+		"custom message is not checked": {
+			json: `{"ok":{"messages":[{"id":1,"msg":{"custom":{"foo":{"bar":[1,{"x":2}]}}},"reply_on":"never"}],"attributes":[],"events":[]}}`,
+		},
+		"stargate alias": {
+			json: `{"ok":{"messages":[{"id":1,"msg":{"stargate":{"type_url":"/foo","value":""}},"reply_on":"never"}],"attributes":[],"events":[]}}`,
+		},
+		"vote alias": {
+			json: `{"ok":{"messages":[{"id":1,"msg":{"gov":{"vote":{"proposal_id":1,"vote":"yes"}}},"reply_on":"never"}],"attributes":[],"events":[]}}`,
+		},
+		"unknown top level": {
+			json:   `{"ok":{"messages":[],"attributes":[],"events":[]},"foo":1}`,
+			expErr: `unknown variant "foo" in ContractResult`,
+		},
+		"unknown response field": {
+			json:   `{"ok":{"messages":[],"attributes":[],"events":[],"foo":{"bar":1}}}`,
+			expErr: `unknown field "foo" in Response at ok`,
+		},
+		"unknown msg variant": {
+			json:   `{"ok":{"messages":[{"id":1,"msg":{"bank":{"burn":{"amount":[]}}},"reply_on":"never"},{"id":1,"msg":{"new_module":{}},"reply_on":"never"}],"attributes":[],"events":[]}}`,
+			expErr: `unknown variant "new_module" in CosmosMsg at ok.messages[1].msg`,
+		},
+		"unknown nested field": {
+			json:   `{"ok":{"messages":[{"id":1,"msg":{"bank":{"send":{"to_address":"a","amount":[{"denom":"a","amount":"1","extra":true}]}}},"reply_on":"never"}],"attributes":[],"events":[]}}`,
+			expErr: `unknown field "extra" in Coin at ok.messages[0].msg.bank.send.amount[0]`,
+		},
+		"case sensitive": {
+			json:   `{"ok":{"Messages":[],"attributes":[],"events":[]}}`,
+			expErr: `unknown field "Messages" in Response at ok`,
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			var res ContractResult
+			require.NoError(t, json.Unmarshal([]byte(spec.json), &res))
+			err := CheckUnknownFields([]byte(spec.json), &res)
+			if spec.expErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, spec.expErr)
+			assert.IsType(t, UnknownFieldError{}, err)
+		})
+	}
+}
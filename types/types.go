@@ -57,7 +57,7 @@ type CanonicalAddress = []byte
 // Coin is a string representation of the sdk.Coin type (more portable than sdk.Int)
 type Coin struct {
 	Denom  string `json:"denom"`  // type, eg. "ATOM"
-	Amount string `json:"amount"` // string encoding of decimal value, eg. "12.3456"
+	Amount string `json:"amount"` // string encoding of a Uint128, eg. "12345" (see ParseAmount)
 }
 
 func NewCoin(amount uint64, denom string) Coin {
@@ -67,6 +67,19 @@ func NewCoin(amount uint64, denom string) Coin {
 	}
 }
 
+// NewCoinFromUint128 creates a Coin with an amount that may exceed the range of a uint64.
+func NewCoinFromUint128(amount Uint128, denom string) Coin {
+	return Coin{
+		Denom:  denom,
+		Amount: amount.String(),
+	}
+}
+
+// ParseAmount parses the amount like cosmwasm_std::Coin, which stores it as a Uint128.
+func (c Coin) ParseAmount() (Uint128, error) {
+	return ParseUint128(c.Amount)
+}
+
 // Replicating the cosmos-sdk bank module Metadata type
 type DenomMetadata struct {
 	Description string `json:"description"`
@@ -128,6 +141,11 @@ type DecCoin struct {
 	Denom  string `json:"denom"`
 }
 
+// ParseAmount parses the amount like cosmwasm_std::DecCoin, which stores it as a Decimal256.
+func (c DecCoin) ParseAmount() (Decimal256, error) {
+	return ParseDecimal256(c.Amount)
+}
+
 // Simplified version of the cosmos-sdk PageRequest type
 type PageRequest struct {
 	// Key is a value returned in PageResponse.next_key to begin
@@ -149,6 +167,18 @@ func (o OutOfGasError) Error() string {
 	return "Out of gas"
 }
 
+// IteratorLimitError is returned when a contract call fails because it tried to open
+// more iterators than the configured iterator limit.
+type IteratorLimitError struct {
+	Limit int
+}
+
+var _ error = IteratorLimitError{}
+
+func (e IteratorLimitError) Error() string {
+	return fmt.Sprintf("Reached iterator limit (%d)", e.Limit)
+}
+
 type GasReport struct {
 	Limit          uint64
 	Remaining      uint64
@@ -209,6 +239,19 @@ func (pm *PinnedMetrics) UnmarshalMessagePack(data []byte) error {
 	return msgpack.UnmarshalAsArray(data, pm)
 }
 
+// IteratorMetrics are metrics about the storage iterators of contract calls.
+// They are collected for all VMs of the process.
+type IteratorMetrics struct {
+	// OpenFrames is the number of currently running contract calls that opened iterators
+	OpenFrames uint64
+	// OpenIterators is the number of iterators of all currently running contract calls
+	OpenIterators uint64
+	// PeakIteratorsPerCall is the highest number of iterators a single contract call opened
+	PeakIteratorsPerCall uint64
+	// LimitErrors is the number of times a contract call reached its iterator limit
+	LimitErrors uint64
+}
+
 // Array is a wrapper around a slice that ensures that we get "[]" JSON for nil values.
 // When unmarshaling, we get an empty slice for "[]" and "null".
 //
@@ -0,0 +1,517 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError is returned by the Validate methods of enum-like types
+// (structs of which exactly one field must be set) and result types.
+type ValidationError struct {
+	// Type is the name of the Go type that failed validation, e.g. "BankMsg"
+	Type string
+	// Path locates the invalid value inside the validated object, e.g. "messages[2].msg.bank".
+	// It is empty if the validated object itself is invalid.
+	Path string
+	// Reason describes the problem
+	Reason string
+}
+
+var _ error = ValidationError{}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("invalid %s: %s", e.Type, e.Reason)
+	}
+	return fmt.Sprintf("invalid %s at %s: %s", e.Type, e.Path, e.Reason)
+}
+
+// variant is one field of an enum-like struct
+type variant struct {
+	name string
+	set  bool
+}
+
+// checkVariants ensures that exactly one of the variants is set.
+func checkVariants(typ, path string, variants ...variant) error {
+	var set []string
+	for _, v := range variants {
+		if v.set {
+			set = append(set, v.name)
+		}
+	}
+	switch len(set) {
+	case 1:
+		return nil
+	case 0:
+		return ValidationError{Type: typ, Path: path, Reason: "no variant set"}
+	default:
+		return ValidationError{Type: typ, Path: path, Reason: "multiple variants set: " + strings.Join(set, ", ")}
+	}
+}
+
+// checkResult ensures that a result does not have both the ok and the error case set.
+// Having none set is allowed since the ok case may be empty.
+func checkResult(typ, path string, okSet, errSet bool) error {
+	if okSet && errSet {
+		return ValidationError{Type: typ, Path: path, Reason: "both ok and error set"}
+	}
+	return nil
+}
+
+func joinPath(path, elem string) string {
+	if path == "" {
+		return elem
+	}
+	return path + "." + elem
+}
+
+func validateSubMessages(path string, msgs []SubMsg) error {
+	for i, m := range msgs {
+		if err := m.validate(joinPath(path, fmt.Sprintf("messages[%d]", i))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//------- Results / Msgs -------------
+
+// Validate returns an error if both Ok and Err are set or if the response
+// contains an invalid enum value.
+func (r ContractResult) Validate() error {
+	if err := checkResult("ContractResult", "", r.Ok != nil, r.Err != ""); err != nil {
+		return err
+	}
+	if r.Ok != nil {
+		return r.Ok.Validate()
+	}
+	return nil
+}
+
+// Validate checks all sub-messages of the response.
+func (r Response) Validate() error {
+	return validateSubMessages("", r.Messages)
+}
+
+func (m SubMsg) validate(path string) error {
+	return m.Msg.validate(joinPath(path, "msg"))
+}
+
+// Validate returns an error unless exactly one variant is set. The selected variant
+// is validated recursively.
+func (m CosmosMsg) Validate() error {
+	return m.validate("")
+}
+
+func (m CosmosMsg) validate(path string) error {
+	err := checkVariants("CosmosMsg", path, m.variants()...)
+	if err != nil {
+		return err
+	}
+	switch {
+	case m.Bank != nil:
+		return m.Bank.validate(joinPath(path, "bank"))
+	case m.Distribution != nil:
+		return m.Distribution.validate(joinPath(path, "distribution"))
+	case m.Gov != nil:
+		return m.Gov.validate(joinPath(path, "gov"))
+	case m.IBC != nil:
+		return m.IBC.validate(joinPath(path, "ibc"))
+	case m.Staking != nil:
+		return m.Staking.validate(joinPath(path, "staking"))
+	case m.Wasm != nil:
+		return m.Wasm.validate(joinPath(path, "wasm"))
+	}
+	return nil
+}
+
+func (m CosmosMsg) variants() []variant {
+	return []variant{
+		{"bank", m.Bank != nil},
+		{"custom", len(m.Custom) != 0},
+		{"distribution", m.Distribution != nil},
+		{"gov", m.Gov != nil},
+		{"ibc", m.IBC != nil},
+		{"staking", m.Staking != nil},
+		{"any", m.Any != nil},
+		{"wasm", m.Wasm != nil},
+	}
+}
+
+// Validate returns an error unless exactly one variant is set.
+func (m BankMsg) Validate() error {
+	return m.validate("")
+}
+
+func (m BankMsg) validate(path string) error {
+	return checkVariants("BankMsg", path, m.variants()...)
+}
+
+func (m BankMsg) variants() []variant {
+	return []variant{
+		{"send", m.Send != nil},
+		{"burn", m.Burn != nil},
+	}
+}
+
+// Validate returns an error unless exactly one variant is set.
+func (m IBCMsg) Validate() error {
+	return m.validate("")
+}
+
+func (m IBCMsg) validate(path string) error {
+	return checkVariants("IBCMsg", path, m.variants()...)
+}
+
+func (m IBCMsg) variants() []variant {
+	return []variant{
+		{"transfer", m.Transfer != nil},
+		{"send_packet", m.SendPacket != nil},
+		{"write_acknowledgement", m.WriteAcknowledgement != nil},
+		{"close_channel", m.CloseChannel != nil},
+	}
+}
+
+// Validate returns an error unless exactly one variant is set.
+func (m GovMsg) Validate() error {
+	return m.validate("")
+}
+
+func (m GovMsg) validate(path string) error {
+	return checkVariants("GovMsg", path, m.variants()...)
+}
+
+func (m GovMsg) variants() []variant {
+	return []variant{
+		{"vote", m.Vote != nil},
+		{"vote_weighted", m.VoteWeighted != nil},
+	}
+}
+
+// Validate returns an error unless exactly one variant is set.
+func (m StakingMsg) Validate() error {
+	return m.validate("")
+}
+
+func (m StakingMsg) validate(path string) error {
+	return checkVariants("StakingMsg", path, m.variants()...)
+}
+
+func (m StakingMsg) variants() []variant {
+	return []variant{
+		{"delegate", m.Delegate != nil},
+		{"undelegate", m.Undelegate != nil},
+		{"redelegate", m.Redelegate != nil},
+	}
+}
+
+// Validate returns an error unless exactly one variant is set.
+func (m DistributionMsg) Validate() error {
+	return m.validate("")
+}
+
+func (m DistributionMsg) validate(path string) error {
+	return checkVariants("DistributionMsg", path, m.variants()...)
+}
+
+func (m DistributionMsg) variants() []variant {
+	return []variant{
+		{"set_withdraw_address", m.SetWithdrawAddress != nil},
+		{"withdraw_delegator_reward", m.WithdrawDelegatorReward != nil},
+		{"fund_community_pool", m.FundCommunityPool != nil},
+	}
+}
+
+// Validate returns an error unless exactly one variant is set.
+func (m WasmMsg) Validate() error {
+	return m.validate("")
+}
+
+func (m WasmMsg) validate(path string) error {
+	return checkVariants("WasmMsg", path, m.variants()...)
+}
+
+func (m WasmMsg) variants() []variant {
+	return []variant{
+		{"execute", m.Execute != nil},
+		{"instantiate", m.Instantiate != nil},
+		{"instantiate2", m.Instantiate2 != nil},
+		{"migrate", m.Migrate != nil},
+		{"update_admin", m.UpdateAdmin != nil},
+		{"clear_admin", m.ClearAdmin != nil},
+	}
+}
+
+// Validate returns an error if both Ok and Err are set.
+func (r SubMsgResult) Validate() error {
+	return checkResult("SubMsgResult", "", r.Ok != nil, r.Err != "")
+}
+
+//-------- Queries --------
+
+// Validate returns an error if both Ok and Err are set.
+func (q QueryResult) Validate() error {
+	return checkResult("QueryResult", "", len(q.Ok) != 0, q.Err != "")
+}
+
+// Validate returns an error unless exactly one of Ok and Err is set.
+func (q QuerierResult) Validate() error {
+	err := checkVariants("QuerierResult", "",
+		variant{"ok", q.Ok != nil},
+		variant{"error", q.Err != nil},
+	)
+	if err != nil {
+		return err
+	}
+	if q.Ok != nil {
+		if err := checkResult("QueryResult", "ok", len(q.Ok.Ok) != 0, q.Ok.Err != ""); err != nil {
+			return err
+		}
+	}
+	if q.Err != nil {
+		return q.Err.validate("error")
+	}
+	return nil
+}
+
+// Validate returns an error unless exactly one variant is set. The selected variant
+// is validated recursively.
+func (q QueryRequest) Validate() error {
+	return q.validate("")
+}
+
+func (q QueryRequest) validate(path string) error {
+	err := checkVariants("QueryRequest", path, q.variants()...)
+	if err != nil {
+		return err
+	}
+	switch {
+	case q.Bank != nil:
+		return q.Bank.validate(joinPath(path, "bank"))
+	case q.IBC != nil:
+		return q.IBC.validate(joinPath(path, "ibc"))
+	case q.Staking != nil:
+		return q.Staking.validate(joinPath(path, "staking"))
+	case q.Distribution != nil:
+		return q.Distribution.validate(joinPath(path, "distribution"))
+	case q.Wasm != nil:
+		return q.Wasm.validate(joinPath(path, "wasm"))
+	}
+	return nil
+}
+
+func (q QueryRequest) variants() []variant {
+	return []variant{
+		{"bank", q.Bank != nil},
+		{"custom", len(q.Custom) != 0},
+		{"ibc", q.IBC != nil},
+		{"staking", q.Staking != nil},
+		{"distribution", q.Distribution != nil},
+		{"stargate", q.Stargate != nil},
+		{"grpc", q.Grpc != nil},
+		{"wasm", q.Wasm != nil},
+	}
+}
+
+// Validate returns an error unless exactly one variant is set.
+func (q BankQuery) Validate() error {
+	return q.validate("")
+}
+
+func (q BankQuery) validate(path string) error {
+	return checkVariants("BankQuery", path, q.variants()...)
+}
+
+func (q BankQuery) variants() []variant {
+	return []variant{
+		{"supply", q.Supply != nil},
+		{"balance", q.Balance != nil},
+		{"all_balances", q.AllBalances != nil},
+		{"denom_metadata", q.DenomMetadata != nil},
+		{"all_denom_metadata", q.AllDenomMetadata != nil},
+	}
+}
+
+// Validate returns an error unless exactly one variant is set.
+func (q IBCQuery) Validate() error {
+	return q.validate("")
+}
+
+func (q IBCQuery) validate(path string) error {
+	return checkVariants("IBCQuery", path, q.variants()...)
+}
+
+func (q IBCQuery) variants() []variant {
+	return []variant{
+		{"port_id", q.PortID != nil},
+		{"list_channels", q.ListChannels != nil},
+		{"channel", q.Channel != nil},
+	}
+}
+
+// Validate returns an error unless exactly one variant is set.
+func (q StakingQuery) Validate() error {
+	return q.validate("")
+}
+
+func (q StakingQuery) validate(path string) error {
+	return checkVariants("StakingQuery", path, q.variants()...)
+}
+
+func (q StakingQuery) variants() []variant {
+	return []variant{
+		{"all_validators", q.AllValidators != nil},
+		{"validator", q.Validator != nil},
+		{"all_delegations", q.AllDelegations != nil},
+		{"delegation", q.Delegation != nil},
+		{"bonded_denom", q.BondedDenom != nil},
+	}
+}
+
+// Validate returns an error unless exactly one variant is set.
+func (q DistributionQuery) Validate() error {
+	return q.validate("")
+}
+
+func (q DistributionQuery) validate(path string) error {
+	return checkVariants("DistributionQuery", path, q.variants()...)
+}
+
+func (q DistributionQuery) variants() []variant {
+	return []variant{
+		{"delegator_withdraw_address", q.DelegatorWithdrawAddress != nil},
+		{"delegation_rewards", q.DelegationRewards != nil},
+		{"delegation_total_rewards", q.DelegationTotalRewards != nil},
+		{"delegator_validators", q.DelegatorValidators != nil},
+	}
+}
+
+// Validate returns an error unless exactly one variant is set.
+func (q WasmQuery) Validate() error {
+	return q.validate("")
+}
+
+func (q WasmQuery) validate(path string) error {
+	return checkVariants("WasmQuery", path, q.variants()...)
+}
+
+func (q WasmQuery) variants() []variant {
+	return []variant{
+		{"smart", q.Smart != nil},
+		{"raw", q.Raw != nil},
+		{"contract_info", q.ContractInfo != nil},
+		{"code_info", q.CodeInfo != nil},
+	}
+}
+
+//-------- Errors --------
+
+// Validate returns an error unless exactly one variant is set.
+func (a SystemError) Validate() error {
+	return a.validate("")
+}
+
+func (a SystemError) validate(path string) error {
+	return checkVariants("SystemError", path, a.variants()...)
+}
+
+func (a SystemError) variants() []variant {
+	return []variant{
+		{"invalid_request", a.InvalidRequest != nil},
+		{"invalid_response", a.InvalidResponse != nil},
+		{"no_such_contract", a.NoSuchContract != nil},
+		{"no_such_code", a.NoSuchCode != nil},
+		{"unknown", a.Unknown != nil},
+		{"unsupported_request", a.UnsupportedRequest != nil},
+	}
+}
+
+//-------- IBC --------
+
+// Validate returns an error unless exactly one variant is set.
+func (msg IBCChannelOpenMsg) Validate() error {
+	return checkVariants("IBCChannelOpenMsg", "", msg.variants()...)
+}
+
+func (msg IBCChannelOpenMsg) variants() []variant {
+	return []variant{
+		{"open_init", msg.OpenInit != nil},
+		{"open_try", msg.OpenTry != nil},
+	}
+}
+
+// Validate returns an error unless exactly one variant is set.
+func (msg IBCChannelConnectMsg) Validate() error {
+	return checkVariants("IBCChannelConnectMsg", "", msg.variants()...)
+}
+
+func (msg IBCChannelConnectMsg) variants() []variant {
+	return []variant{
+		{"open_ack", msg.OpenAck != nil},
+		{"open_confirm", msg.OpenConfirm != nil},
+	}
+}
+
+// Validate returns an error unless exactly one variant is set.
+func (msg IBCChannelCloseMsg) Validate() error {
+	return checkVariants("IBCChannelCloseMsg", "", msg.variants()...)
+}
+
+func (msg IBCChannelCloseMsg) variants() []variant {
+	return []variant{
+		{"close_init", msg.CloseInit != nil},
+		{"close_confirm", msg.CloseConfirm != nil},
+	}
+}
+
+// Validate returns an error unless exactly one variant is set.
+func (msg IBCSourceCallbackMsg) Validate() error {
+	return checkVariants("IBCSourceCallbackMsg", "", msg.variants()...)
+}
+
+func (msg IBCSourceCallbackMsg) variants() []variant {
+	return []variant{
+		{"acknowledgement", msg.Acknowledgement != nil},
+		{"timeout", msg.Timeout != nil},
+	}
+}
+
+// Validate returns an error if both Ok and Err are set.
+func (r IBCChannelOpenResult) Validate() error {
+	return checkResult("IBCChannelOpenResult", "", r.Ok != nil, r.Err != "")
+}
+
+// Validate returns an error if both Ok and Err are set or if the response
+// contains an invalid enum value.
+func (r IBCBasicResult) Validate() error {
+	if err := checkResult("IBCBasicResult", "", r.Ok != nil, r.Err != ""); err != nil {
+		return err
+	}
+	if r.Ok != nil {
+		return r.Ok.Validate()
+	}
+	return nil
+}
+
+// Validate checks all sub-messages of the response.
+func (r IBCBasicResponse) Validate() error {
+	return validateSubMessages("", r.Messages)
+}
+
+// Validate returns an error if both Ok and Err are set or if the response
+// contains an invalid enum value.
+func (r IBCReceiveResult) Validate() error {
+	if err := checkResult("IBCReceiveResult", "", r.Ok != nil, r.Err != ""); err != nil {
+		return err
+	}
+	if r.Ok != nil {
+		return r.Ok.Validate()
+	}
+	return nil
+}
+
+// Validate checks all sub-messages of the response.
+func (r IBCReceiveResponse) Validate() error {
+	return validateSubMessages("", r.Messages)
+}
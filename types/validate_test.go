@@ -0,0 +1,103 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContractResultValidate(t *testing.T) {
+	specs := map[string]struct {
+		json   string
+		expErr string
+	}{
+		"valid ok": {
+			json: `{"ok":{"messages":[{"id":1,"msg":{"bank":{"send":{"to_address":"bob","amount":[]}}},"reply_on":"never"}],"attributes":[],"events":[]}}`,
+		},
+		"valid error": {
+			json: `{"error":"foo"}`,
+		},
+		"ok and error": {
+			json:   `{"ok":{"messages":[],"attributes":[],"events":[]},"error":"foo"}`,
+			expErr: "invalid ContractResult: both ok and error set",
+		},
+		"no msg variant": {
+			json:   `{"ok":{"messages":[{"id":1,"msg":{},"reply_on":"never"}],"attributes":[],"events":[]}}`,
+			expErr: "invalid CosmosMsg at messages[0].msg: no variant set",
+		},
+		"multiple msg variants": {
+			json:   `{"ok":{"messages":[{"id":1,"msg":{"bank":{"burn":{"amount":[]}},"custom":{}},"reply_on":"never"}],"attributes":[],"events":[]}}`,
+			expErr: "invalid CosmosMsg at messages[0].msg: multiple variants set: bank, custom",
+		},
+		"nested enum": {
+			json:   `{"ok":{"messages":[{"id":1,"msg":{"custom":{}},"reply_on":"never"},{"id":2,"msg":{"custom":{}},"reply_on":"never"},{"id":3,"msg":{"bank":{}},"reply_on":"never"}],"attributes":[],"events":[]}}`,
+			expErr: "invalid BankMsg at messages[2].msg.bank: no variant set",
+		},
+		"nested multiple": {
+			json:   `{"ok":{"messages":[{"id":1,"msg":{"wasm":{"clear_admin":{"contract_addr":"a"},"update_admin":{"contract_addr":"a","admin":"b"}}},"reply_on":"never"}],"attributes":[],"events":[]}}`,
+			expErr: "invalid WasmMsg at messages[0].msg.wasm: multiple variants set: update_admin, clear_admin",
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			var res ContractResult
+			require.NoError(t, json.Unmarshal([]byte(spec.json), &res))
+			err := res.Validate()
+			if spec.expErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, spec.expErr)
+			assert.IsType(t, ValidationError{}, err)
+		})
+	}
+}
+
+func TestQueryRequestValidate(t *testing.T) {
+	specs := map[string]struct {
+		req    QueryRequest
+		expErr string
+	}{
+		"valid": {
+			req: QueryRequest{Staking: &StakingQuery{BondedDenom: &struct{}{}}},
+		},
+		"empty": {
+			req:    QueryRequest{},
+			expErr: "invalid QueryRequest: no variant set",
+		},
+		"nested empty": {
+			req:    QueryRequest{Wasm: &WasmQuery{}},
+			expErr: "invalid WasmQuery at wasm: no variant set",
+		},
+		"nested multiple": {
+			req:    QueryRequest{Bank: &BankQuery{Supply: &SupplyQuery{}, Balance: &BalanceQuery{}}},
+			expErr: "invalid BankQuery at bank: multiple variants set: supply, balance",
+		},
+	}
+	for name, spec := range specs {
+		t.Run(name, func(t *testing.T) {
+			err := spec.req.Validate()
+			if spec.expErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, spec.expErr)
+		})
+	}
+}
+
+func TestIBCResultsValidate(t *testing.T) {
+	require.NoError(t, IBCChannelOpenResult{}.Validate())
+	require.Error(t, IBCChannelOpenResult{Ok: &IBC3ChannelOpenResponse{}, Err: "foo"}.Validate())
+
+	res := IBCReceiveResult{Ok: &IBCReceiveResponse{Messages: []SubMsg{{Msg: CosmosMsg{IBC: &IBCMsg{}}}}}}
+	require.EqualError(t, res.Validate(), "invalid IBCMsg at messages[0].msg.ibc: no variant set")
+
+	require.NoError(t, (&IBCCloseInit{}).ToMsg().Validate())
+	require.EqualError(t, IBCChannelCloseMsg{}.Validate(), "invalid IBCChannelCloseMsg: no variant set")
+
+	require.NoError(t, QuerierResult{Err: &SystemError{Unknown: &Unknown{}}}.Validate())
+	require.EqualError(t, QuerierResult{Err: &SystemError{}}.Validate(), "invalid SystemError at error: no variant set")
+}
@@ -0,0 +1,493 @@
+package types
+
+import "encoding/json"
+
+// This file contains visitor interfaces for the enum-like types, i.e. the structs of which
+// exactly one field must be set. A visitor must implement one method per variant, so adding
+// a variant to an enum causes a compile error in all visitors instead of being silently ignored.
+//
+// Visit returns a ValidationError if not exactly one variant is set. Otherwise it returns
+// the result of the visitor method for the variant that is set.
+
+// CosmosMsgVisitor is implemented by handlers of all CosmosMsg variants.
+type CosmosMsgVisitor interface {
+	VisitBank(*BankMsg) error
+	VisitCustom(json.RawMessage) error
+	VisitDistribution(*DistributionMsg) error
+	VisitGov(*GovMsg) error
+	VisitIBC(*IBCMsg) error
+	VisitStaking(*StakingMsg) error
+	VisitAny(*AnyMsg) error
+	VisitWasm(*WasmMsg) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (m CosmosMsg) Visit(v CosmosMsgVisitor) error {
+	if err := checkVariants("CosmosMsg", "", m.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case m.Bank != nil:
+		return v.VisitBank(m.Bank)
+	case len(m.Custom) != 0:
+		return v.VisitCustom(m.Custom)
+	case m.Distribution != nil:
+		return v.VisitDistribution(m.Distribution)
+	case m.Gov != nil:
+		return v.VisitGov(m.Gov)
+	case m.IBC != nil:
+		return v.VisitIBC(m.IBC)
+	case m.Staking != nil:
+		return v.VisitStaking(m.Staking)
+	case m.Any != nil:
+		return v.VisitAny(m.Any)
+	case m.Wasm != nil:
+		return v.VisitWasm(m.Wasm)
+	default:
+		panic("unreachable")
+	}
+}
+
+// BankMsgVisitor is implemented by handlers of all BankMsg variants.
+type BankMsgVisitor interface {
+	VisitSend(*SendMsg) error
+	VisitBurn(*BurnMsg) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (m BankMsg) Visit(v BankMsgVisitor) error {
+	if err := checkVariants("BankMsg", "", m.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case m.Send != nil:
+		return v.VisitSend(m.Send)
+	case m.Burn != nil:
+		return v.VisitBurn(m.Burn)
+	default:
+		panic("unreachable")
+	}
+}
+
+// IBCMsgVisitor is implemented by handlers of all IBCMsg variants.
+type IBCMsgVisitor interface {
+	VisitTransfer(*TransferMsg) error
+	VisitSendPacket(*SendPacketMsg) error
+	VisitWriteAcknowledgement(*WriteAcknowledgementMsg) error
+	VisitCloseChannel(*CloseChannelMsg) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (m IBCMsg) Visit(v IBCMsgVisitor) error {
+	if err := checkVariants("IBCMsg", "", m.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case m.Transfer != nil:
+		return v.VisitTransfer(m.Transfer)
+	case m.SendPacket != nil:
+		return v.VisitSendPacket(m.SendPacket)
+	case m.WriteAcknowledgement != nil:
+		return v.VisitWriteAcknowledgement(m.WriteAcknowledgement)
+	case m.CloseChannel != nil:
+		return v.VisitCloseChannel(m.CloseChannel)
+	default:
+		panic("unreachable")
+	}
+}
+
+// GovMsgVisitor is implemented by handlers of all GovMsg variants.
+type GovMsgVisitor interface {
+	VisitVote(*VoteMsg) error
+	VisitVoteWeighted(*VoteWeightedMsg) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (m GovMsg) Visit(v GovMsgVisitor) error {
+	if err := checkVariants("GovMsg", "", m.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case m.Vote != nil:
+		return v.VisitVote(m.Vote)
+	case m.VoteWeighted != nil:
+		return v.VisitVoteWeighted(m.VoteWeighted)
+	default:
+		panic("unreachable")
+	}
+}
+
+// StakingMsgVisitor is implemented by handlers of all StakingMsg variants.
+type StakingMsgVisitor interface {
+	VisitDelegate(*DelegateMsg) error
+	VisitUndelegate(*UndelegateMsg) error
+	VisitRedelegate(*RedelegateMsg) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (m StakingMsg) Visit(v StakingMsgVisitor) error {
+	if err := checkVariants("StakingMsg", "", m.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case m.Delegate != nil:
+		return v.VisitDelegate(m.Delegate)
+	case m.Undelegate != nil:
+		return v.VisitUndelegate(m.Undelegate)
+	case m.Redelegate != nil:
+		return v.VisitRedelegate(m.Redelegate)
+	default:
+		panic("unreachable")
+	}
+}
+
+// DistributionMsgVisitor is implemented by handlers of all DistributionMsg variants.
+type DistributionMsgVisitor interface {
+	VisitSetWithdrawAddress(*SetWithdrawAddressMsg) error
+	VisitWithdrawDelegatorReward(*WithdrawDelegatorRewardMsg) error
+	VisitFundCommunityPool(*FundCommunityPoolMsg) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (m DistributionMsg) Visit(v DistributionMsgVisitor) error {
+	if err := checkVariants("DistributionMsg", "", m.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case m.SetWithdrawAddress != nil:
+		return v.VisitSetWithdrawAddress(m.SetWithdrawAddress)
+	case m.WithdrawDelegatorReward != nil:
+		return v.VisitWithdrawDelegatorReward(m.WithdrawDelegatorReward)
+	case m.FundCommunityPool != nil:
+		return v.VisitFundCommunityPool(m.FundCommunityPool)
+	default:
+		panic("unreachable")
+	}
+}
+
+// WasmMsgVisitor is implemented by handlers of all WasmMsg variants.
+type WasmMsgVisitor interface {
+	VisitExecute(*ExecuteMsg) error
+	VisitInstantiate(*InstantiateMsg) error
+	VisitInstantiate2(*Instantiate2Msg) error
+	VisitMigrate(*MigrateMsg) error
+	VisitUpdateAdmin(*UpdateAdminMsg) error
+	VisitClearAdmin(*ClearAdminMsg) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (m WasmMsg) Visit(v WasmMsgVisitor) error {
+	if err := checkVariants("WasmMsg", "", m.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case m.Execute != nil:
+		return v.VisitExecute(m.Execute)
+	case m.Instantiate != nil:
+		return v.VisitInstantiate(m.Instantiate)
+	case m.Instantiate2 != nil:
+		return v.VisitInstantiate2(m.Instantiate2)
+	case m.Migrate != nil:
+		return v.VisitMigrate(m.Migrate)
+	case m.UpdateAdmin != nil:
+		return v.VisitUpdateAdmin(m.UpdateAdmin)
+	case m.ClearAdmin != nil:
+		return v.VisitClearAdmin(m.ClearAdmin)
+	default:
+		panic("unreachable")
+	}
+}
+
+// QueryRequestVisitor is implemented by handlers of all QueryRequest variants.
+type QueryRequestVisitor interface {
+	VisitBank(*BankQuery) error
+	VisitCustom(json.RawMessage) error
+	VisitIBC(*IBCQuery) error
+	VisitStaking(*StakingQuery) error
+	VisitDistribution(*DistributionQuery) error
+	VisitStargate(*StargateQuery) error
+	VisitGrpc(*GrpcQuery) error
+	VisitWasm(*WasmQuery) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (q QueryRequest) Visit(v QueryRequestVisitor) error {
+	if err := checkVariants("QueryRequest", "", q.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case q.Bank != nil:
+		return v.VisitBank(q.Bank)
+	case len(q.Custom) != 0:
+		return v.VisitCustom(q.Custom)
+	case q.IBC != nil:
+		return v.VisitIBC(q.IBC)
+	case q.Staking != nil:
+		return v.VisitStaking(q.Staking)
+	case q.Distribution != nil:
+		return v.VisitDistribution(q.Distribution)
+	case q.Stargate != nil:
+		return v.VisitStargate(q.Stargate)
+	case q.Grpc != nil:
+		return v.VisitGrpc(q.Grpc)
+	case q.Wasm != nil:
+		return v.VisitWasm(q.Wasm)
+	default:
+		panic("unreachable")
+	}
+}
+
+// BankQueryVisitor is implemented by handlers of all BankQuery variants.
+type BankQueryVisitor interface {
+	VisitSupply(*SupplyQuery) error
+	VisitBalance(*BalanceQuery) error
+	VisitAllBalances(*AllBalancesQuery) error
+	VisitDenomMetadata(*DenomMetadataQuery) error
+	VisitAllDenomMetadata(*AllDenomMetadataQuery) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (q BankQuery) Visit(v BankQueryVisitor) error {
+	if err := checkVariants("BankQuery", "", q.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case q.Supply != nil:
+		return v.VisitSupply(q.Supply)
+	case q.Balance != nil:
+		return v.VisitBalance(q.Balance)
+	case q.AllBalances != nil:
+		return v.VisitAllBalances(q.AllBalances)
+	case q.DenomMetadata != nil:
+		return v.VisitDenomMetadata(q.DenomMetadata)
+	case q.AllDenomMetadata != nil:
+		return v.VisitAllDenomMetadata(q.AllDenomMetadata)
+	default:
+		panic("unreachable")
+	}
+}
+
+// IBCQueryVisitor is implemented by handlers of all IBCQuery variants.
+type IBCQueryVisitor interface {
+	VisitPortID(*PortIDQuery) error
+	VisitListChannels(*ListChannelsQuery) error
+	VisitChannel(*ChannelQuery) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (q IBCQuery) Visit(v IBCQueryVisitor) error {
+	if err := checkVariants("IBCQuery", "", q.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case q.PortID != nil:
+		return v.VisitPortID(q.PortID)
+	case q.ListChannels != nil:
+		return v.VisitListChannels(q.ListChannels)
+	case q.Channel != nil:
+		return v.VisitChannel(q.Channel)
+	default:
+		panic("unreachable")
+	}
+}
+
+// StakingQueryVisitor is implemented by handlers of all StakingQuery variants.
+type StakingQueryVisitor interface {
+	VisitAllValidators(*AllValidatorsQuery) error
+	VisitValidator(*ValidatorQuery) error
+	VisitAllDelegations(*AllDelegationsQuery) error
+	VisitDelegation(*DelegationQuery) error
+	VisitBondedDenom() error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (q StakingQuery) Visit(v StakingQueryVisitor) error {
+	if err := checkVariants("StakingQuery", "", q.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case q.AllValidators != nil:
+		return v.VisitAllValidators(q.AllValidators)
+	case q.Validator != nil:
+		return v.VisitValidator(q.Validator)
+	case q.AllDelegations != nil:
+		return v.VisitAllDelegations(q.AllDelegations)
+	case q.Delegation != nil:
+		return v.VisitDelegation(q.Delegation)
+	case q.BondedDenom != nil:
+		return v.VisitBondedDenom()
+	default:
+		panic("unreachable")
+	}
+}
+
+// DistributionQueryVisitor is implemented by handlers of all DistributionQuery variants.
+type DistributionQueryVisitor interface {
+	VisitDelegatorWithdrawAddress(*DelegatorWithdrawAddressQuery) error
+	VisitDelegationRewards(*DelegationRewardsQuery) error
+	VisitDelegationTotalRewards(*DelegationTotalRewardsQuery) error
+	VisitDelegatorValidators(*DelegatorValidatorsQuery) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (q DistributionQuery) Visit(v DistributionQueryVisitor) error {
+	if err := checkVariants("DistributionQuery", "", q.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case q.DelegatorWithdrawAddress != nil:
+		return v.VisitDelegatorWithdrawAddress(q.DelegatorWithdrawAddress)
+	case q.DelegationRewards != nil:
+		return v.VisitDelegationRewards(q.DelegationRewards)
+	case q.DelegationTotalRewards != nil:
+		return v.VisitDelegationTotalRewards(q.DelegationTotalRewards)
+	case q.DelegatorValidators != nil:
+		return v.VisitDelegatorValidators(q.DelegatorValidators)
+	default:
+		panic("unreachable")
+	}
+}
+
+// WasmQueryVisitor is implemented by handlers of all WasmQuery variants.
+type WasmQueryVisitor interface {
+	VisitSmart(*SmartQuery) error
+	VisitRaw(*RawQuery) error
+	VisitContractInfo(*ContractInfoQuery) error
+	VisitCodeInfo(*CodeInfoQuery) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (q WasmQuery) Visit(v WasmQueryVisitor) error {
+	if err := checkVariants("WasmQuery", "", q.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case q.Smart != nil:
+		return v.VisitSmart(q.Smart)
+	case q.Raw != nil:
+		return v.VisitRaw(q.Raw)
+	case q.ContractInfo != nil:
+		return v.VisitContractInfo(q.ContractInfo)
+	case q.CodeInfo != nil:
+		return v.VisitCodeInfo(q.CodeInfo)
+	default:
+		panic("unreachable")
+	}
+}
+
+// IBCChannelOpenMsgVisitor is implemented by handlers of all IBCChannelOpenMsg variants.
+type IBCChannelOpenMsgVisitor interface {
+	VisitOpenInit(*IBCOpenInit) error
+	VisitOpenTry(*IBCOpenTry) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (msg IBCChannelOpenMsg) Visit(v IBCChannelOpenMsgVisitor) error {
+	if err := checkVariants("IBCChannelOpenMsg", "", msg.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case msg.OpenInit != nil:
+		return v.VisitOpenInit(msg.OpenInit)
+	case msg.OpenTry != nil:
+		return v.VisitOpenTry(msg.OpenTry)
+	default:
+		panic("unreachable")
+	}
+}
+
+// IBCChannelConnectMsgVisitor is implemented by handlers of all IBCChannelConnectMsg variants.
+type IBCChannelConnectMsgVisitor interface {
+	VisitOpenAck(*IBCOpenAck) error
+	VisitOpenConfirm(*IBCOpenConfirm) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (msg IBCChannelConnectMsg) Visit(v IBCChannelConnectMsgVisitor) error {
+	if err := checkVariants("IBCChannelConnectMsg", "", msg.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case msg.OpenAck != nil:
+		return v.VisitOpenAck(msg.OpenAck)
+	case msg.OpenConfirm != nil:
+		return v.VisitOpenConfirm(msg.OpenConfirm)
+	default:
+		panic("unreachable")
+	}
+}
+
+// IBCChannelCloseMsgVisitor is implemented by handlers of all IBCChannelCloseMsg variants.
+type IBCChannelCloseMsgVisitor interface {
+	VisitCloseInit(*IBCCloseInit) error
+	VisitCloseConfirm(*IBCCloseConfirm) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (msg IBCChannelCloseMsg) Visit(v IBCChannelCloseMsgVisitor) error {
+	if err := checkVariants("IBCChannelCloseMsg", "", msg.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case msg.CloseInit != nil:
+		return v.VisitCloseInit(msg.CloseInit)
+	case msg.CloseConfirm != nil:
+		return v.VisitCloseConfirm(msg.CloseConfirm)
+	default:
+		panic("unreachable")
+	}
+}
+
+// IBCSourceCallbackMsgVisitor is implemented by handlers of all IBCSourceCallbackMsg variants.
+type IBCSourceCallbackMsgVisitor interface {
+	VisitAcknowledgement(*IBCAckCallbackMsg) error
+	VisitTimeout(*IBCTimeoutCallbackMsg) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (msg IBCSourceCallbackMsg) Visit(v IBCSourceCallbackMsgVisitor) error {
+	if err := checkVariants("IBCSourceCallbackMsg", "", msg.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case msg.Acknowledgement != nil:
+		return v.VisitAcknowledgement(msg.Acknowledgement)
+	case msg.Timeout != nil:
+		return v.VisitTimeout(msg.Timeout)
+	default:
+		panic("unreachable")
+	}
+}
+
+// SystemErrorVisitor is implemented by handlers of all SystemError variants.
+type SystemErrorVisitor interface {
+	VisitInvalidRequest(*InvalidRequest) error
+	VisitInvalidResponse(*InvalidResponse) error
+	VisitNoSuchContract(*NoSuchContract) error
+	VisitNoSuchCode(*NoSuchCode) error
+	VisitUnknown(*Unknown) error
+	VisitUnsupportedRequest(*UnsupportedRequest) error
+}
+
+// Visit calls the method of v that corresponds to the variant that is set.
+func (a SystemError) Visit(v SystemErrorVisitor) error {
+	if err := checkVariants("SystemError", "", a.variants()...); err != nil {
+		return err
+	}
+	switch {
+	case a.InvalidRequest != nil:
+		return v.VisitInvalidRequest(a.InvalidRequest)
+	case a.InvalidResponse != nil:
+		return v.VisitInvalidResponse(a.InvalidResponse)
+	case a.NoSuchContract != nil:
+		return v.VisitNoSuchContract(a.NoSuchContract)
+	case a.NoSuchCode != nil:
+		return v.VisitNoSuchCode(a.NoSuchCode)
+	case a.Unknown != nil:
+		return v.VisitUnknown(a.Unknown)
+	case a.UnsupportedRequest != nil:
+		return v.VisitUnsupportedRequest(a.UnsupportedRequest)
+	default:
+		panic("unreachable")
+	}
+}
@@ -0,0 +1,104 @@
+package types
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// msgRecorder records the visited variants of CosmosMsg and its BankMsg and WasmMsg variants
+type msgRecorder struct {
+	visited []string
+}
+
+var (
+	_ CosmosMsgVisitor = (*msgRecorder)(nil)
+	_ BankMsgVisitor   = (*msgRecorder)(nil)
+)
+
+func (r *msgRecorder) record(name string) error {
+	r.visited = append(r.visited, name)
+	return nil
+}
+
+func (r *msgRecorder) VisitBank(m *BankMsg) error {
+	if err := r.record("bank"); err != nil {
+		return err
+	}
+	return m.Visit(r)
+}
+func (r *msgRecorder) VisitCustom(json.RawMessage) error        { return r.record("custom") }
+func (r *msgRecorder) VisitDistribution(*DistributionMsg) error { return r.record("distribution") }
+func (r *msgRecorder) VisitGov(*GovMsg) error                   { return r.record("gov") }
+func (r *msgRecorder) VisitIBC(*IBCMsg) error                   { return r.record("ibc") }
+func (r *msgRecorder) VisitStaking(*StakingMsg) error           { return r.record("staking") }
+func (r *msgRecorder) VisitAny(*AnyMsg) error                   { return r.record("any") }
+func (r *msgRecorder) VisitWasm(*WasmMsg) error                 { return r.record("wasm") }
+func (r *msgRecorder) VisitSend(*SendMsg) error                 { return r.record("send") }
+func (r *msgRecorder) VisitBurn(*BurnMsg) error                 { return r.record("burn") }
+
+func TestCosmosMsgVisit(t *testing.T) {
+	var msg CosmosMsg
+	err := json.Unmarshal([]byte(`{"bank":{"burn":{"amount":[]}}}`), &msg)
+	require.NoError(t, err)
+
+	r := &msgRecorder{}
+	require.NoError(t, msg.Visit(r))
+	require.Equal(t, []string{"bank", "burn"}, r.visited)
+
+	r = &msgRecorder{}
+	require.NoError(t, CosmosMsg{Custom: json.RawMessage(`{}`)}.Visit(r))
+	require.Equal(t, []string{"custom"}, r.visited)
+
+	// invalid enums are not visited
+	r = &msgRecorder{}
+	err = CosmosMsg{}.Visit(r)
+	require.EqualError(t, err, "invalid CosmosMsg: no variant set")
+	err = CosmosMsg{Bank: &BankMsg{}, Wasm: &WasmMsg{}}.Visit(r)
+	require.EqualError(t, err, "invalid CosmosMsg: multiple variants set: bank, wasm")
+	err = CosmosMsg{Bank: &BankMsg{}}.Visit(r)
+	require.EqualError(t, err, "invalid BankMsg: no variant set")
+	require.Equal(t, []string{"bank"}, r.visited)
+}
+
+type stakingQueryRecorder struct {
+	visited string
+}
+
+var _ StakingQueryVisitor = (*stakingQueryRecorder)(nil)
+
+func (r *stakingQueryRecorder) VisitAllValidators(*AllValidatorsQuery) error {
+	r.visited = "all_validators"
+	return nil
+}
+
+func (r *stakingQueryRecorder) VisitValidator(*ValidatorQuery) error {
+	r.visited = "validator"
+	return nil
+}
+
+func (r *stakingQueryRecorder) VisitAllDelegations(*AllDelegationsQuery) error {
+	r.visited = "all_delegations"
+	return nil
+}
+
+func (r *stakingQueryRecorder) VisitDelegation(*DelegationQuery) error {
+	r.visited = "delegation"
+	return nil
+}
+
+func (r *stakingQueryRecorder) VisitBondedDenom() error {
+	r.visited = "bonded_denom"
+	return nil
+}
+
+func TestStakingQueryVisit(t *testing.T) {
+	var req QueryRequest
+	err := json.Unmarshal([]byte(`{"staking":{"bonded_denom":{}}}`), &req)
+	require.NoError(t, err)
+
+	r := &stakingQueryRecorder{}
+	require.NoError(t, req.Staking.Visit(r))
+	require.Equal(t, "bonded_denom", r.visited)
+}
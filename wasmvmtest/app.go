@@ -0,0 +1,567 @@
+//go:build cgo && !nolink_libwasmvm
+
+// Package wasmvmtest provides an in-memory multi-contract application for testing
+// contracts that interact with each other, similar to cw-multi-test in Rust.
+//
+// The App holds any number of contract instances and a mock bank on a single
+// MemDB-backed store. Messages returned by contracts are dispatched recursively.
+// IBC contracts in one or two Apps can be connected using a Relayer.
+// Sub-messages are executed by a dispatch.Dispatcher, so SubMsg.ReplyOn, SubMsg.GasLimit
+// and SubMsg.Payload are honoured and the state changes of failed sub-messages are rolled back.
+package wasmvmtest
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	cosmwasm "github.com/CosmWasm/wasmvm/v2"
+	"github.com/CosmWasm/wasmvm/v2/dispatch"
+	"github.com/CosmWasm/wasmvm/v2/store"
+	wasmvmtesting "github.com/CosmWasm/wasmvm/v2/testing"
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// DefaultGasLimit is the CosmWasm gas limit of a top level call
+const DefaultGasLimit uint64 = 500_000_000_000
+
+const (
+	contractsPrefix = "contracts/"
+	// sequencesPrefix is the prefix of the counters used to generate contract addresses and channel IDs.
+	// They are kept in the store so that they are rolled back with all other state changes.
+	sequencesPrefix = "sequences/"
+)
+
+// AppResponse is the result of executing a message in the App
+type AppResponse struct {
+	// Events are all events emitted during the execution, including sub-messages and replies
+	Events []types.Event
+	// Data is the data returned by the contract, possibly overridden by replies
+	Data []byte
+	// GasUsed is the CosmWasm gas used by all contract calls
+	GasUsed uint64
+}
+
+// ContractInfo is the metadata of a contract instance
+type ContractInfo struct {
+	CodeID  uint64 `json:"code_id"`
+	Creator string `json:"creator"`
+	Admin   string `json:"admin,omitempty"`
+	Label   string `json:"label"`
+}
+
+// App is an in-memory chain that executes contracts on a VM.
+// It is not safe for concurrent use.
+type App struct {
+	vm  *cosmwasm.VM
+	db  types.KVStore
+	api types.GoAPI
+	// codes contains the checksums of all stored codes. The code ID is the index + 1.
+	codes      []cosmwasm.Checksum
+	queryGas   uint64
+	dispatcher *dispatch.Dispatcher
+
+	// Block is the block info passed to all contracts
+	Block types.BlockInfo
+	// GasLimit is the CosmWasm gas limit of every top level call
+	GasLimit uint64
+	// DeserCost is the gas cost of deserializing one byte of a contract response
+	DeserCost types.UFraction
+}
+
+var (
+	_ types.Querier           = (*App)(nil)
+	_ dispatch.MessageHandler = (*App)(nil)
+)
+
+// NewApp creates an App executing contracts on the given VM.
+func NewApp(vm *cosmwasm.VM) *App {
+	app := &App{
+		vm:  vm,
+		db:  dbStore{db: wasmvmtesting.NewMemDB()},
+		api: *wasmvmtesting.NewMockAPI(),
+		Block: types.BlockInfo{
+			Height:  12345,
+			Time:    1578939743_987654321,
+			ChainID: "testing",
+		},
+		GasLimit:  DefaultGasLimit,
+		DeserCost: types.UFraction{Numerator: 1, Denominator: 1},
+	}
+	app.dispatcher = dispatch.NewDispatcher(app, vm.GasConfig().GasMultiplier)
+	return app
+}
+
+// StoreCode stores the given Wasm code and returns its code ID.
+func (a *App) StoreCode(code []byte) (uint64, error) {
+	checksum, _, err := a.vm.StoreCode(code, a.GasLimit)
+	if err != nil {
+		return 0, err
+	}
+	a.codes = append(a.codes, checksum)
+	return uint64(len(a.codes)), nil
+}
+
+// Instantiate creates a new contract instance and returns its address.
+// All state changes are reverted if the instantiation or any of the resulting messages fail.
+func (a *App) Instantiate(sender string, codeID uint64, msg []byte, funds []types.Coin, label, admin string) (string, *AppResponse, error) {
+	var addr string
+	var res *AppResponse
+	err := a.transaction(func() (err error) {
+		addr, res, err = a.instantiate(sender, codeID, msg, funds, label, admin, nil, a.GasLimit)
+		return err
+	})
+	return addr, res, err
+}
+
+// Execute calls the execute entry point of a contract.
+// All state changes are reverted if the execution or any of the resulting messages fail.
+func (a *App) Execute(sender, contract string, msg []byte, funds []types.Coin) (*AppResponse, error) {
+	var res *AppResponse
+	err := a.transaction(func() (err error) {
+		res, err = a.execute(sender, contract, msg, funds, a.GasLimit)
+		return err
+	})
+	return res, err
+}
+
+// Migrate migrates a contract to a new code. Only the admin of the contract can do this.
+// All state changes are reverted if the migration or any of the resulting messages fail.
+func (a *App) Migrate(sender, contract string, newCodeID uint64, msg []byte) (*AppResponse, error) {
+	var res *AppResponse
+	err := a.transaction(func() (err error) {
+		res, err = a.migrate(sender, contract, newCodeID, msg, a.GasLimit)
+		return err
+	})
+	return res, err
+}
+
+// Dispatch executes a message as if it was sent by sender.
+// All state changes are reverted if the message or any of the resulting messages fail.
+func (a *App) Dispatch(sender string, msg types.CosmosMsg) (*AppResponse, error) {
+	var res *AppResponse
+	err := a.transaction(func() (err error) {
+		res, err = a.dispatch(sender, msg, a.GasLimit)
+		return err
+	})
+	return res, err
+}
+
+// QuerySmart performs a smart query on a contract.
+func (a *App) QuerySmart(contract string, msg []byte) ([]byte, error) {
+	return a.querySmart(contract, msg, a.GasLimit)
+}
+
+// ContractInfo returns the metadata of a contract instance.
+func (a *App) ContractInfo(addr string) (ContractInfo, error) {
+	var info ContractInfo
+	bz := a.db.Get([]byte(contractsPrefix + addr))
+	if bz == nil {
+		return info, types.NoSuchContract{Addr: addr}
+	}
+	err := json.Unmarshal(bz, &info)
+	return info, err
+}
+
+func (a *App) setContractInfo(addr string, info ContractInfo) {
+	bz, err := json.Marshal(info)
+	if err != nil {
+		panic(err)
+	}
+	a.db.Set([]byte(contractsPrefix+addr), bz)
+}
+
+// nextSequence returns the current value of the named counter, starting at 0, and increments it
+func (a *App) nextSequence(name string) uint64 {
+	key := []byte(sequencesPrefix + name)
+	var seq uint64
+	if bz := a.db.Get(key); bz != nil {
+		seq = binary.BigEndian.Uint64(bz)
+	}
+	a.db.Set(key, binary.BigEndian.AppendUint64(nil, seq+1))
+	return seq
+}
+
+func (a *App) checksum(codeID uint64) (cosmwasm.Checksum, error) {
+	if codeID == 0 || codeID > uint64(len(a.codes)) {
+		return nil, types.NoSuchCode{CodeID: codeID}
+	}
+	return a.codes[codeID-1], nil
+}
+
+// contractStore returns the storage of a contract
+func (a *App) contractStore(addr string) types.KVStore {
+	return store.NewPrefixStore(a.db, []byte("contract/"+addr+"/"))
+}
+
+func (a *App) env(contract string) types.Env {
+	return types.Env{
+		Block:       a.Block,
+		Transaction: &types.TransactionInfo{Index: 0},
+		Contract:    types.ContractInfo{Address: contract},
+	}
+}
+
+// transaction runs fn and reverts all state changes if it returns an error
+func (a *App) transaction(fn func() error) error {
+	parent := a.db
+	cache := store.NewCacheKVStore(parent)
+	a.db = cache
+	err := fn()
+	a.db = parent
+	if err == nil {
+		cache.Write()
+	}
+	return err
+}
+
+func (a *App) instantiate(sender string, codeID uint64, msg []byte, funds []types.Coin, label, admin string, salt []byte, gasLimit uint64) (string, *AppResponse, error) {
+	checksum, err := a.checksum(codeID)
+	if err != nil {
+		return "", &AppResponse{}, err
+	}
+	var addr string
+	if salt == nil {
+		addr = "contract" + strconv.FormatUint(a.nextSequence("contracts")+1, 10)
+	} else {
+		addr = instantiate2Address(checksum, sender, salt)
+	}
+	if _, err := a.ContractInfo(addr); err == nil {
+		return "", &AppResponse{}, fmt.Errorf("contract %s already exists", addr)
+	}
+	a.setContractInfo(addr, ContractInfo{CodeID: codeID, Creator: sender, Admin: admin, Label: label})
+	if err := a.sendCoins(sender, addr, funds); err != nil {
+		return "", &AppResponse{}, err
+	}
+
+	info := types.MessageInfo{Sender: sender, Funds: funds}
+	res, gasUsed, err := a.vm.Instantiate(checksum, a.env(addr), info, msg, a.contractStore(addr), a.api, a, a.gasMeter(), gasLimit, a.DeserCost)
+	if err != nil {
+		return "", &AppResponse{GasUsed: gasUsed}, err
+	}
+	if res.Err != "" {
+		return "", &AppResponse{GasUsed: gasUsed}, errors.New(res.Err)
+	}
+	events := []types.Event{{
+		Type: "instantiate",
+		Attributes: types.Array[types.EventAttribute]{
+			{Key: "_contract_address", Value: addr},
+			{Key: "code_id", Value: strconv.FormatUint(codeID, 10)},
+		},
+	}}
+	appRes, err := a.handleResponse(addr, res.Ok, events, gasUsed, gasLimit)
+	return addr, appRes, err
+}
+
+// instantiate2Address derives a deterministic address from the checksum, creator and salt.
+// The mock API only supports addresses with at most 32 bytes.
+func instantiate2Address(checksum cosmwasm.Checksum, creator string, salt []byte) string {
+	h := sha256.New()
+	h.Write(checksum)
+	h.Write([]byte(creator))
+	h.Write(salt)
+	return "contract" + hex.EncodeToString(h.Sum(nil))[:24]
+}
+
+func (a *App) execute(sender, contract string, msg []byte, funds []types.Coin, gasLimit uint64) (*AppResponse, error) {
+	contractInfo, err := a.ContractInfo(contract)
+	if err != nil {
+		return &AppResponse{}, err
+	}
+	checksum, err := a.checksum(contractInfo.CodeID)
+	if err != nil {
+		return &AppResponse{}, err
+	}
+	if err := a.sendCoins(sender, contract, funds); err != nil {
+		return &AppResponse{}, err
+	}
+
+	info := types.MessageInfo{Sender: sender, Funds: funds}
+	res, gasUsed, err := a.vm.Execute(checksum, a.env(contract), info, msg, a.contractStore(contract), a.api, a, a.gasMeter(), gasLimit, a.DeserCost)
+	if err != nil {
+		return &AppResponse{GasUsed: gasUsed}, err
+	}
+	if res.Err != "" {
+		return &AppResponse{GasUsed: gasUsed}, errors.New(res.Err)
+	}
+	events := []types.Event{{
+		Type:       "execute",
+		Attributes: types.Array[types.EventAttribute]{{Key: "_contract_address", Value: contract}},
+	}}
+	return a.handleResponse(contract, res.Ok, events, gasUsed, gasLimit)
+}
+
+func (a *App) migrate(sender, contract string, newCodeID uint64, msg []byte, gasLimit uint64) (*AppResponse, error) {
+	contractInfo, err := a.ContractInfo(contract)
+	if err != nil {
+		return &AppResponse{}, err
+	}
+	if contractInfo.Admin == "" || contractInfo.Admin != sender {
+		return &AppResponse{}, fmt.Errorf("unauthorized: %s is not the admin of %s", sender, contract)
+	}
+	checksum, err := a.checksum(newCodeID)
+	if err != nil {
+		return &AppResponse{}, err
+	}
+	contractInfo.CodeID = newCodeID
+	a.setContractInfo(contract, contractInfo)
+
+	res, gasUsed, err := a.vm.Migrate(checksum, a.env(contract), msg, a.contractStore(contract), a.api, a, a.gasMeter(), gasLimit, a.DeserCost)
+	if err != nil {
+		return &AppResponse{GasUsed: gasUsed}, err
+	}
+	if res.Err != "" {
+		return &AppResponse{GasUsed: gasUsed}, errors.New(res.Err)
+	}
+	events := []types.Event{{
+		Type: "migrate",
+		Attributes: types.Array[types.EventAttribute]{
+			{Key: "_contract_address", Value: contract},
+			{Key: "code_id", Value: strconv.FormatUint(newCodeID, 10)},
+		},
+	}}
+	return a.handleResponse(contract, res.Ok, events, gasUsed, gasLimit)
+}
+
+func (a *App) updateAdmin(sender, contract, newAdmin string) error {
+	contractInfo, err := a.ContractInfo(contract)
+	if err != nil {
+		return err
+	}
+	if contractInfo.Admin == "" || contractInfo.Admin != sender {
+		return fmt.Errorf("unauthorized: %s is not the admin of %s", sender, contract)
+	}
+	contractInfo.Admin = newAdmin
+	a.setContractInfo(contract, contractInfo)
+	return nil
+}
+
+// handleResponse collects the events of a contract response and dispatches its messages
+func (a *App) handleResponse(contract string, resp *types.Response, events []types.Event, gasUsed, gasLimit uint64) (*AppResponse, error) {
+	events = append(events, contractEvents(contract, resp.Attributes, resp.Events)...)
+	if gasUsed > gasLimit {
+		return &AppResponse{GasUsed: gasUsed}, types.OutOfGasError{}
+	}
+	res, err := a.dispatcher.DispatchSubMessages(state{KVStore: a.db}, contract, resp.Messages, gasLimit-gasUsed)
+	gasUsed += res.GasUsed
+	if err != nil {
+		return &AppResponse{GasUsed: gasUsed}, err
+	}
+	data := resp.Data
+	if res.Data != nil {
+		data = res.Data
+	}
+	return &AppResponse{Events: append(events, res.Events...), Data: data, GasUsed: gasUsed}, nil
+}
+
+// contractEvents converts the attributes and events of a contract response into events
+// the same way wasmd does.
+func contractEvents(contract string, attributes []types.EventAttribute, events []types.Event) []types.Event {
+	var res []types.Event
+	if len(attributes) > 0 {
+		attrs := types.Array[types.EventAttribute]{{Key: "_contract_address", Value: contract}}
+		res = append(res, types.Event{Type: "wasm", Attributes: append(attrs, attributes...)})
+	}
+	for _, e := range events {
+		attrs := types.Array[types.EventAttribute]{{Key: "_contract_address", Value: contract}}
+		res = append(res, types.Event{Type: "wasm-" + e.Type, Attributes: append(attrs, e.Attributes...)})
+	}
+	return res
+}
+
+// use makes the App operate on the given store and returns a function restoring the previous one
+func (a *App) use(store dispatch.Store) func() {
+	prev := a.db
+	a.db = store.(types.KVStore)
+	return func() { a.db = prev }
+}
+
+// Handle implements dispatch.MessageHandler.
+func (a *App) Handle(store dispatch.Store, sender string, msg types.CosmosMsg, gasLimit uint64) (*dispatch.Result, error) {
+	defer a.use(store)()
+	res, err := a.dispatch(sender, msg, gasLimit)
+	return &dispatch.Result{Events: res.Events, Data: res.Data, GasUsed: res.GasUsed}, err
+}
+
+// Reply implements dispatch.MessageHandler.
+func (a *App) Reply(store dispatch.Store, contract string, reply types.Reply, gasLimit uint64) (*dispatch.Result, error) {
+	defer a.use(store)()
+	res, err := a.reply(contract, reply, gasLimit)
+	return &dispatch.Result{Events: res.Events, Data: res.Data, GasUsed: res.GasUsed}, err
+}
+
+// reply calls the reply entry point of a contract and dispatches the resulting messages.
+func (a *App) reply(contract string, reply types.Reply, gasLimit uint64) (*AppResponse, error) {
+	contractInfo, err := a.ContractInfo(contract)
+	if err != nil {
+		return &AppResponse{}, err
+	}
+	checksum, err := a.checksum(contractInfo.CodeID)
+	if err != nil {
+		return &AppResponse{}, err
+	}
+
+	res, gasUsed, err := a.vm.Reply(checksum, a.env(contract), reply, a.contractStore(contract), a.api, a, a.gasMeter(), gasLimit, a.DeserCost)
+	if err != nil {
+		return &AppResponse{GasUsed: gasUsed}, err
+	}
+	if res.Err != "" {
+		return &AppResponse{GasUsed: gasUsed}, errors.New(res.Err)
+	}
+	mode := "handle_success"
+	if reply.Result.Ok == nil {
+		mode = "handle_failure"
+	}
+	events := []types.Event{{
+		Type: "reply",
+		Attributes: types.Array[types.EventAttribute]{
+			{Key: "_contract_address", Value: contract},
+			{Key: "mode", Value: mode},
+		},
+	}}
+	return a.handleResponse(contract, res.Ok, events, gasUsed, gasLimit)
+}
+
+// dispatch executes a message sent by sender
+func (a *App) dispatch(sender string, msg types.CosmosMsg, gasLimit uint64) (*AppResponse, error) {
+	switch {
+	case msg.Bank != nil && msg.Bank.Send != nil:
+		send := msg.Bank.Send
+		if err := a.sendCoins(sender, send.ToAddress, send.Amount); err != nil {
+			return &AppResponse{}, err
+		}
+		return &AppResponse{Events: []types.Event{transferEvent(sender, send.ToAddress, send.Amount)}}, nil
+	case msg.Bank != nil && msg.Bank.Burn != nil:
+		if err := a.burnCoins(sender, msg.Bank.Burn.Amount); err != nil {
+			return &AppResponse{}, err
+		}
+		return &AppResponse{}, nil
+	case msg.IBC != nil:
+		return a.dispatchIBC(sender, msg.IBC)
+	case msg.Wasm != nil && msg.Wasm.Execute != nil:
+		m := msg.Wasm.Execute
+		return a.execute(sender, m.ContractAddr, m.Msg, m.Funds, gasLimit)
+	case msg.Wasm != nil && msg.Wasm.Instantiate != nil:
+		m := msg.Wasm.Instantiate
+		_, res, err := a.instantiate(sender, m.CodeID, m.Msg, m.Funds, m.Label, m.Admin, nil, gasLimit)
+		return res, err
+	case msg.Wasm != nil && msg.Wasm.Instantiate2 != nil:
+		m := msg.Wasm.Instantiate2
+		_, res, err := a.instantiate(sender, m.CodeID, m.Msg, m.Funds, m.Label, m.Admin, m.Salt, gasLimit)
+		return res, err
+	case msg.Wasm != nil && msg.Wasm.Migrate != nil:
+		m := msg.Wasm.Migrate
+		return a.migrate(sender, m.ContractAddr, m.NewCodeID, m.Msg, gasLimit)
+	case msg.Wasm != nil && msg.Wasm.UpdateAdmin != nil:
+		m := msg.Wasm.UpdateAdmin
+		return &AppResponse{}, a.updateAdmin(sender, m.ContractAddr, m.Admin)
+	case msg.Wasm != nil && msg.Wasm.ClearAdmin != nil:
+		return &AppResponse{}, a.updateAdmin(sender, msg.Wasm.ClearAdmin.ContractAddr, "")
+	default:
+		bz, _ := json.Marshal(msg)
+		return &AppResponse{}, fmt.Errorf("unsupported message: %s", bz)
+	}
+}
+
+func transferEvent(from, to string, amount []types.Coin) types.Event {
+	coins := ""
+	for i, c := range amount {
+		if i > 0 {
+			coins += ","
+		}
+		coins += c.Amount + c.Denom
+	}
+	return types.Event{
+		Type: "transfer",
+		Attributes: types.Array[types.EventAttribute]{
+			{Key: "recipient", Value: to},
+			{Key: "sender", Value: from},
+			{Key: "amount", Value: coins},
+		},
+	}
+}
+
+// Query implements types.Querier. It supports bank, wasm and IBC channel queries.
+func (a *App) Query(request types.QueryRequest, gasLimit uint64) ([]byte, error) {
+	switch {
+	case request.Bank != nil && request.Bank.Balance != nil:
+		q := request.Bank.Balance
+		return json.Marshal(types.BalanceResponse{Amount: a.Balance(q.Address, q.Denom)})
+	case request.Bank != nil && request.Bank.AllBalances != nil:
+		return json.Marshal(types.AllBalancesResponse{Amount: a.Balances(request.Bank.AllBalances.Address)})
+	case request.Wasm != nil && request.Wasm.Smart != nil:
+		q := request.Wasm.Smart
+		return a.querySmart(q.ContractAddr, q.Msg, gasLimit)
+	case request.Wasm != nil && request.Wasm.Raw != nil:
+		q := request.Wasm.Raw
+		if _, err := a.ContractInfo(q.ContractAddr); err != nil {
+			return nil, err
+		}
+		return a.contractStore(q.ContractAddr).Get(q.Key), nil
+	case request.Wasm != nil && request.Wasm.ContractInfo != nil:
+		info, err := a.ContractInfo(request.Wasm.ContractInfo.ContractAddr)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(types.ContractInfoResponse{CodeID: info.CodeID, Creator: info.Creator, Admin: info.Admin})
+	case request.Wasm != nil && request.Wasm.CodeInfo != nil:
+		codeID := request.Wasm.CodeInfo.CodeID
+		checksum, err := a.checksum(codeID)
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(types.CodeInfoResponse{CodeID: codeID, Checksum: checksum})
+	case request.IBC != nil && request.IBC.ListChannels != nil:
+		return json.Marshal(types.ListChannelsResponse{Channels: a.channels(request.IBC.ListChannels.PortID)})
+	case request.IBC != nil && request.IBC.Channel != nil:
+		q := request.IBC.Channel
+		res := types.ChannelResponse{}
+		if ch, err := a.channel(q.ChannelID); err == nil && ch.State == channelOpen && ch.Channel.Endpoint.PortID == q.PortID {
+			res.Channel = &ch.Channel
+		}
+		return json.Marshal(res)
+	default:
+		return nil, types.UnsupportedRequest{Kind: "only bank balance, wasm and ibc channel queries are supported"}
+	}
+}
+
+// GasConsumed implements types.Querier.
+func (a *App) GasConsumed() uint64 {
+	return a.queryGas
+}
+
+func (a *App) querySmart(contract string, msg []byte, gasLimit uint64) ([]byte, error) {
+	contractInfo, err := a.ContractInfo(contract)
+	if err != nil {
+		return nil, err
+	}
+	checksum, err := a.checksum(contractInfo.CodeID)
+	if err != nil {
+		return nil, err
+	}
+	res, gasUsed, err := a.vm.Query(checksum, a.env(contract), msg, a.contractStore(contract), a.api, a, a.gasMeter(), gasLimit, a.DeserCost)
+	a.queryGas += gasUsed
+	if err != nil {
+		return nil, err
+	}
+	if res.Err != "" {
+		return nil, errors.New(res.Err)
+	}
+	return res.Ok, nil
+}
+
+// gasMeter returns the gas meter passed to the VM. Storage access is not charged
+// in the App, so this never reports any gas consumption.
+func (a *App) gasMeter() types.GasMeter {
+	return noopGasMeter{}
+}
+
+type noopGasMeter struct{}
+
+func (noopGasMeter) GasConsumed() types.Gas {
+	return 0
+}
@@ -0,0 +1,183 @@
+//go:build cgo && !nolink_libwasmvm
+
+package wasmvmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	cosmwasm "github.com/CosmWasm/wasmvm/v2"
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+const (
+	HACKATOM_TEST_CONTRACT = "../testdata/hackatom.wasm"
+	REFLECT_TEST_CONTRACT  = "../testdata/reflect.wasm"
+)
+
+func withApp(t *testing.T) *App {
+	t.Helper()
+	tmpdir := t.TempDir()
+	vm, err := cosmwasm.NewVM(tmpdir, []string{"staking", "stargate", "iterator", "cosmwasm_1_1", "cosmwasm_1_2", "cosmwasm_1_3"}, 32, false, 100)
+	require.NoError(t, err)
+	t.Cleanup(vm.Cleanup)
+	return NewApp(vm)
+}
+
+func storeCode(t *testing.T, app *App, path string) uint64 {
+	t.Helper()
+	wasm, err := os.ReadFile(path)
+	require.NoError(t, err)
+	codeID, err := app.StoreCode(wasm)
+	require.NoError(t, err)
+	return codeID
+}
+
+func TestBankMessagesAreDispatched(t *testing.T) {
+	app := withApp(t)
+	codeID := storeCode(t, app, HACKATOM_TEST_CONTRACT)
+	app.SetBalances("creator", types.Array[types.Coin]{types.NewCoin(1000, "ATOM")})
+
+	msg := []byte(`{"verifier": "fred", "beneficiary": "bob"}`)
+	contract, _, err := app.Instantiate("creator", codeID, msg, []types.Coin{types.NewCoin(250, "ATOM")}, "hackatom", "")
+	require.NoError(t, err)
+	assert.Equal(t, types.NewCoin(750, "ATOM"), app.Balance("creator", "ATOM"))
+	assert.Equal(t, types.NewCoin(250, "ATOM"), app.Balance(contract, "ATOM"))
+
+	res, err := app.Execute("fred", contract, []byte(`{"release":{}}`), nil)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0xF0, 0x0B, 0xAA}, res.Data)
+	assert.Equal(t, types.NewCoin(250, "ATOM"), app.Balance("bob", "ATOM"))
+	assert.Equal(t, types.NewCoin(0, "ATOM"), app.Balance(contract, "ATOM"))
+
+	// only the verifier can release
+	_, err = app.Execute("bob", contract, []byte(`{"release":{}}`), nil)
+	require.Error(t, err)
+}
+
+func reflectSubMsg(t *testing.T, msgs ...types.SubMsg) []byte {
+	t.Helper()
+	bz, err := json.Marshal(msgs)
+	require.NoError(t, err)
+	return []byte(fmt.Sprintf(`{"reflect_sub_msg":{"msgs":%s}}`, bz))
+}
+
+func queryReply(t *testing.T, app *App, contract string, id uint64) types.Reply {
+	t.Helper()
+	bz, err := app.QuerySmart(contract, []byte(fmt.Sprintf(`{"sub_msg_result":{"id":%d}}`, id)))
+	require.NoError(t, err)
+	var reply types.Reply
+	require.NoError(t, json.Unmarshal(bz, &reply))
+	return reply
+}
+
+func TestSubMessageReplies(t *testing.T) {
+	app := withApp(t)
+	codeID := storeCode(t, app, REFLECT_TEST_CONTRACT)
+	app.SetBalances("creator", types.Array[types.Coin]{types.NewCoin(1000, "ATOM")})
+
+	contract, _, err := app.Instantiate("creator", codeID, []byte(`{}`), []types.Coin{types.NewCoin(10, "ATOM")}, "reflect", "")
+	require.NoError(t, err)
+
+	send := func(amount uint64) types.CosmosMsg {
+		return types.CosmosMsg{Bank: &types.BankMsg{Send: &types.SendMsg{
+			ToAddress: "friend",
+			Amount:    types.Array[types.Coin]{types.NewCoin(amount, "ATOM")},
+		}}}
+	}
+
+	// successful sub-message with reply
+	gasLimit := uint64(1_000_000)
+	_, err = app.Execute("creator", contract, reflectSubMsg(t, types.SubMsg{
+		ID:       1,
+		Msg:      send(3),
+		ReplyOn:  types.ReplySuccess,
+		GasLimit: &gasLimit,
+		Payload:  []byte("hello"),
+	}), nil)
+	require.NoError(t, err)
+	assert.Equal(t, types.NewCoin(3, "ATOM"), app.Balance("friend", "ATOM"))
+	reply := queryReply(t, app, contract, 1)
+	require.NotNil(t, reply.Result.Ok)
+
+	// failing sub-message with reply on error is rolled back, but the execution succeeds
+	_, err = app.Execute("creator", contract, reflectSubMsg(t,
+		types.SubMsg{ID: 2, Msg: send(1), ReplyOn: types.ReplyNever},
+		types.SubMsg{ID: 3, Msg: send(1000), ReplyOn: types.ReplyError},
+	), nil)
+	require.NoError(t, err)
+	assert.Equal(t, types.NewCoin(4, "ATOM"), app.Balance("friend", "ATOM"))
+	reply = queryReply(t, app, contract, 3)
+	assert.Nil(t, reply.Result.Ok)
+	// the error is redacted as in wasmd
+	assert.Equal(t, "codespace: undefined, code: 1", reply.Result.Err)
+
+	// failing sub-message without reply rolls back the entire execution
+	_, err = app.Execute("creator", contract, reflectSubMsg(t,
+		types.SubMsg{ID: 4, Msg: send(1), ReplyOn: types.ReplyNever},
+		types.SubMsg{ID: 5, Msg: send(1000), ReplyOn: types.ReplySuccess},
+	), nil)
+	require.ErrorContains(t, err, "insufficient funds")
+	assert.Equal(t, types.NewCoin(4, "ATOM"), app.Balance("friend", "ATOM"))
+	assert.Equal(t, types.NewCoin(6, "ATOM"), app.Balance(contract, "ATOM"))
+}
+
+func TestContractToContractCalls(t *testing.T) {
+	app := withApp(t)
+	reflectID := storeCode(t, app, REFLECT_TEST_CONTRACT)
+	hackatomID := storeCode(t, app, HACKATOM_TEST_CONTRACT)
+	app.SetBalances("creator", types.Array[types.Coin]{types.NewCoin(1000, "ATOM")})
+
+	reflect, _, err := app.Instantiate("creator", reflectID, []byte(`{}`), nil, "reflect", "")
+	require.NoError(t, err)
+	hackatom, _, err := app.Instantiate("creator", hackatomID, []byte(fmt.Sprintf(`{"verifier": %q, "beneficiary": "bob"}`, reflect)), []types.Coin{types.NewCoin(100, "ATOM")}, "hackatom", "")
+	require.NoError(t, err)
+
+	// reflect executes hackatom which sends its funds to bob
+	res, err := app.Execute("creator", reflect, reflectSubMsg(t, types.SubMsg{
+		ID:      1,
+		Msg:     types.CosmosMsg{Wasm: &types.WasmMsg{Execute: &types.ExecuteMsg{ContractAddr: hackatom, Msg: []byte(`{"release":{}}`)}}},
+		ReplyOn: types.ReplyAlways,
+	}), nil)
+	require.NoError(t, err)
+	assert.Equal(t, types.NewCoin(100, "ATOM"), app.Balance("bob", "ATOM"))
+	assert.Greater(t, res.GasUsed, uint64(0))
+
+	reply := queryReply(t, app, reflect, 1)
+	require.NotNil(t, reply.Result.Ok)
+	assert.Equal(t, []byte{0xF0, 0x0B, 0xAA}, reply.Result.Ok.Data)
+	assert.Greater(t, reply.GasUsed, uint64(0))
+}
+
+func TestContractAddressesAreRolledBack(t *testing.T) {
+	app := withApp(t)
+	reflectID := storeCode(t, app, REFLECT_TEST_CONTRACT)
+	hackatomID := storeCode(t, app, HACKATOM_TEST_CONTRACT)
+
+	reflect, _, err := app.Instantiate("creator", reflectID, []byte(`{}`), nil, "reflect", "")
+	require.NoError(t, err)
+	assert.Equal(t, "contract1", reflect)
+
+	// a failing instantiation does not use up an address
+	_, _, err = app.Instantiate("creator", hackatomID, []byte(`{}`), nil, "hackatom", "")
+	require.Error(t, err)
+
+	// neither does a failing instantiation in a sub-message whose error is handled in reply
+	_, err = app.Execute("creator", reflect, reflectSubMsg(t, types.SubMsg{
+		ID:      1,
+		Msg:     types.CosmosMsg{Wasm: &types.WasmMsg{Instantiate: &types.InstantiateMsg{CodeID: hackatomID, Msg: []byte(`{}`), Label: "hackatom"}}},
+		ReplyOn: types.ReplyError,
+	}), nil)
+	require.NoError(t, err)
+	reply := queryReply(t, app, reflect, 1)
+	require.NotEmpty(t, reply.Result.Err)
+
+	hackatom, _, err := app.Instantiate("creator", hackatomID, []byte(`{"verifier": "fred", "beneficiary": "bob"}`), nil, "hackatom", "")
+	require.NoError(t, err)
+	assert.Equal(t, "contract2", hackatom)
+}
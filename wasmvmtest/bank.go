@@ -0,0 +1,126 @@
+//go:build cgo && !nolink_libwasmvm
+
+package wasmvmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+const bankPrefix = "bank/"
+
+func bankKey(addr string) []byte {
+	return []byte(bankPrefix + addr)
+}
+
+// Balances returns all balances of the given address sorted by denom.
+func (a *App) Balances(addr string) types.Array[types.Coin] {
+	bz := a.db.Get(bankKey(addr))
+	if bz == nil {
+		return types.Array[types.Coin]{}
+	}
+	var coins types.Array[types.Coin]
+	if err := json.Unmarshal(bz, &coins); err != nil {
+		panic(err)
+	}
+	return coins
+}
+
+// Balance returns the balance of the given address in the given denom.
+func (a *App) Balance(addr, denom string) types.Coin {
+	for _, c := range a.Balances(addr) {
+		if c.Denom == denom {
+			return c
+		}
+	}
+	return types.NewCoin(0, denom)
+}
+
+// SetBalances overrides all balances of the given address.
+func (a *App) SetBalances(addr string, coins types.Array[types.Coin]) {
+	amounts, err := toAmounts(coins)
+	if err != nil {
+		panic(err)
+	}
+	a.setAmounts(addr, amounts)
+}
+
+func (a *App) amounts(addr string) map[string]*big.Int {
+	amounts, err := toAmounts(a.Balances(addr))
+	if err != nil {
+		panic(err)
+	}
+	return amounts
+}
+
+func (a *App) setAmounts(addr string, amounts map[string]*big.Int) {
+	coins := make(types.Array[types.Coin], 0, len(amounts))
+	for denom, amount := range amounts {
+		if amount.Sign() != 0 {
+			coins = append(coins, types.Coin{Denom: denom, Amount: amount.String()})
+		}
+	}
+	sort.Slice(coins, func(i, j int) bool { return coins[i].Denom < coins[j].Denom })
+	bz, err := json.Marshal(coins)
+	if err != nil {
+		panic(err)
+	}
+	a.db.Set(bankKey(addr), bz)
+}
+
+// sendCoins moves coins from one account to another
+func (a *App) sendCoins(from, to string, coins []types.Coin) error {
+	if err := a.burnCoins(from, coins); err != nil {
+		return err
+	}
+	add, err := toAmounts(coins)
+	if err != nil {
+		return err
+	}
+	balance := a.amounts(to)
+	for denom, amount := range add {
+		if balance[denom] == nil {
+			balance[denom] = new(big.Int)
+		}
+		balance[denom].Add(balance[denom], amount)
+	}
+	a.setAmounts(to, balance)
+	return nil
+}
+
+// burnCoins removes coins from an account
+func (a *App) burnCoins(from string, coins []types.Coin) error {
+	sub, err := toAmounts(coins)
+	if err != nil {
+		return err
+	}
+	balance := a.amounts(from)
+	for denom, amount := range sub {
+		have := balance[denom]
+		if have == nil || have.Cmp(amount) < 0 {
+			return fmt.Errorf("insufficient funds: %s has less than %s%s", from, amount, denom)
+		}
+		have.Sub(have, amount)
+	}
+	a.setAmounts(from, balance)
+	return nil
+}
+
+func toAmounts(coins []types.Coin) (map[string]*big.Int, error) {
+	res := make(map[string]*big.Int, len(coins))
+	for _, c := range coins {
+		amount, ok := new(big.Int).SetString(c.Amount, 10)
+		if !ok || amount.Sign() < 0 {
+			return nil, fmt.Errorf("invalid amount %q for denom %q", c.Amount, c.Denom)
+		}
+		if res[c.Denom] == nil {
+			res[c.Denom] = new(big.Int)
+		}
+		res[c.Denom].Add(res[c.Denom], amount)
+	}
+	return res, nil
+}
@@ -0,0 +1,614 @@
+//go:build cgo && !nolink_libwasmvm
+
+package wasmvmtest
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+
+	cosmwasm "github.com/CosmWasm/wasmvm/v2"
+	"github.com/CosmWasm/wasmvm/v2/store"
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+const (
+	channelsPrefix    = "ibc/channels/"
+	commitmentsPrefix = "ibc/commitments/"
+	receiptsPrefix    = "ibc/receipts/"
+	acksPrefix        = "ibc/acks/"
+)
+
+// Channel states as in ibc-go
+const (
+	channelInit    = "INIT"
+	channelTryOpen = "TRYOPEN"
+	channelOpen    = "OPEN"
+	channelClosed  = "CLOSED"
+)
+
+// PortID returns the IBC port of a contract, which is "wasm." followed by the address as in wasmd.
+func PortID(contract string) string {
+	return "wasm." + contract
+}
+
+// channelEnd is the state of one end of a channel
+type channelEnd struct {
+	Channel      types.IBCChannel `json:"channel"`
+	Contract     string           `json:"contract"`
+	State        string           `json:"state"`
+	NextSequence uint64           `json:"next_sequence"`
+}
+
+func (a *App) newChannelID() string {
+	return "channel-" + strconv.FormatUint(a.nextSequence("channels"), 10)
+}
+
+func (a *App) channel(channelID string) (channelEnd, error) {
+	var ch channelEnd
+	bz := a.db.Get([]byte(channelsPrefix + channelID))
+	if bz == nil {
+		return ch, fmt.Errorf("channel %s not found", channelID)
+	}
+	err := json.Unmarshal(bz, &ch)
+	return ch, err
+}
+
+func (a *App) setChannel(channelID string, ch channelEnd) {
+	a.setJSON(channelsPrefix+channelID, ch)
+}
+
+// channels returns all channels bound to the given port
+func (a *App) channels(portID string) []types.IBCChannel {
+	res := []types.IBCChannel{}
+	a.iterate(channelsPrefix, func(_, value []byte) {
+		var ch channelEnd
+		if err := json.Unmarshal(value, &ch); err != nil {
+			panic(err)
+		}
+		if ch.Channel.Endpoint.PortID == portID && ch.State == channelOpen {
+			res = append(res, ch.Channel)
+		}
+	})
+	return res
+}
+
+// contractChannel returns the channel with the given ID if it is open and bound to contract
+func (a *App) contractChannel(contract, channelID string) (channelEnd, error) {
+	ch, err := a.channel(channelID)
+	if err != nil {
+		return ch, err
+	}
+	if ch.Contract != contract {
+		return ch, fmt.Errorf("channel %s is not bound to %s", channelID, contract)
+	}
+	if ch.State != channelOpen {
+		return ch, fmt.Errorf("channel %s is not open", channelID)
+	}
+	return ch, nil
+}
+
+// sequenceKey returns a key for a packet in the given store whose entries are ordered by sequence
+func sequenceKey(prefix, channelID string, sequence uint64) []byte {
+	return []byte(fmt.Sprintf("%s%s/%020d", prefix, channelID, sequence))
+}
+
+func (a *App) setJSON(key string, v any) {
+	bz, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	a.db.Set([]byte(key), bz)
+}
+
+// iterate calls fn for all entries with the given prefix in ascending order.
+func (a *App) iterate(prefix string, fn func(key, value []byte)) {
+	it := store.NewPrefixStore(a.db, []byte(prefix)).Iterator(nil, nil)
+	defer it.Close()
+	for ; it.Valid(); it.Next() {
+		fn(it.Key(), it.Value())
+	}
+}
+
+// commitments returns all packets sent on the channel that were neither acknowledged nor timed out
+func (a *App) commitments(channelID string) []types.IBCPacket {
+	var res []types.IBCPacket
+	a.iterate(commitmentsPrefix+channelID+"/", func(_, value []byte) {
+		var packet types.IBCPacket
+		if err := json.Unmarshal(value, &packet); err != nil {
+			panic(err)
+		}
+		res = append(res, packet)
+	})
+	return res
+}
+
+func (a *App) commitment(channelID string, sequence uint64) (types.IBCPacket, error) {
+	var packet types.IBCPacket
+	bz := a.db.Get(sequenceKey(commitmentsPrefix, channelID, sequence))
+	if bz == nil {
+		return packet, fmt.Errorf("no commitment for packet %d on %s", sequence, channelID)
+	}
+	err := json.Unmarshal(bz, &packet)
+	return packet, err
+}
+
+func (a *App) hasReceipt(channelID string, sequence uint64) bool {
+	return a.db.Get(sequenceKey(receiptsPrefix, channelID, sequence)) != nil
+}
+
+// writtenAck is an acknowledgement that was written but not yet relayed
+type writtenAck struct {
+	Sequence uint64 `json:"sequence"`
+	Data     []byte `json:"data"`
+}
+
+func (a *App) writeAck(channelID string, sequence uint64, ack []byte) error {
+	if !a.hasReceipt(channelID, sequence) {
+		return fmt.Errorf("packet %d on %s was not received", sequence, channelID)
+	}
+	key := sequenceKey(acksPrefix, channelID, sequence)
+	if a.db.Get(key) != nil {
+		return fmt.Errorf("acknowledgement for packet %d on %s already written", sequence, channelID)
+	}
+	a.setJSON(string(key), writtenAck{Sequence: sequence, Data: ack})
+	return nil
+}
+
+func (a *App) acks(channelID string) []writtenAck {
+	var res []writtenAck
+	a.iterate(acksPrefix+channelID+"/", func(_, value []byte) {
+		var ack writtenAck
+		if err := json.Unmarshal(value, &ack); err != nil {
+			panic(err)
+		}
+		res = append(res, ack)
+	})
+	return res
+}
+
+// timedOut returns true if the timeout has passed at the current block of the App
+func (a *App) timedOut(timeout types.IBCTimeout) bool {
+	if timeout.Block != nil && !timeout.Block.IsZero() && a.Block.Height >= timeout.Block.Height {
+		return true
+	}
+	return timeout.Timestamp != 0 && uint64(a.Block.Time) >= timeout.Timestamp
+}
+
+// SendPacket sends a packet on behalf of contract as if it had returned an IBCMsg.SendPacket.
+// This is useful to test the receiving side with contracts that do not send packets themselves.
+func (a *App) SendPacket(contract, channelID string, data []byte, timeout types.IBCTimeout) (types.IBCPacket, error) {
+	var packet types.IBCPacket
+	err := a.transaction(func() (err error) {
+		packet, err = a.sendPacket(contract, channelID, data, timeout)
+		return err
+	})
+	return packet, err
+}
+
+func (a *App) sendPacket(contract, channelID string, data []byte, timeout types.IBCTimeout) (types.IBCPacket, error) {
+	ch, err := a.contractChannel(contract, channelID)
+	if err != nil {
+		return types.IBCPacket{}, err
+	}
+	if (timeout.Block == nil || timeout.Block.IsZero()) && timeout.Timestamp == 0 {
+		return types.IBCPacket{}, errors.New("packet timeout must be set")
+	}
+	packet := types.IBCPacket{
+		Data:     data,
+		Src:      ch.Channel.Endpoint,
+		Dest:     ch.Channel.CounterpartyEndpoint,
+		Sequence: ch.NextSequence,
+		Timeout:  timeout,
+	}
+	ch.NextSequence++
+	a.setChannel(channelID, ch)
+	a.setJSON(string(sequenceKey(commitmentsPrefix, channelID, packet.Sequence)), packet)
+	return packet, nil
+}
+
+// dispatchIBC executes an IBCMsg sent by contract
+func (a *App) dispatchIBC(contract string, msg *types.IBCMsg) (*AppResponse, error) {
+	switch {
+	case msg.SendPacket != nil:
+		m := msg.SendPacket
+		packet, err := a.sendPacket(contract, m.ChannelID, m.Data, m.Timeout)
+		if err != nil {
+			return &AppResponse{}, err
+		}
+		return &AppResponse{Events: []types.Event{{
+			Type: "send_packet",
+			Attributes: types.Array[types.EventAttribute]{
+				{Key: "packet_src_port", Value: packet.Src.PortID},
+				{Key: "packet_src_channel", Value: packet.Src.ChannelID},
+				{Key: "packet_sequence", Value: strconv.FormatUint(packet.Sequence, 10)},
+			},
+		}}}, nil
+	case msg.WriteAcknowledgement != nil:
+		m := msg.WriteAcknowledgement
+		if _, err := a.contractChannel(contract, m.ChannelID); err != nil {
+			return &AppResponse{}, err
+		}
+		return &AppResponse{}, a.writeAck(m.ChannelID, m.PacketSequence, m.Ack.Data)
+	case msg.CloseChannel != nil:
+		ch, err := a.contractChannel(contract, msg.CloseChannel.ChannelID)
+		if err != nil {
+			return &AppResponse{}, err
+		}
+		return a.closeChannel(msg.CloseChannel.ChannelID, ch, (&types.IBCCloseInit{Channel: ch.Channel}).ToMsg())
+	default:
+		bz, _ := json.Marshal(msg)
+		return &AppResponse{}, fmt.Errorf("unsupported IBC message: %s", bz)
+	}
+}
+
+func (a *App) contractChecksum(contract string) (cosmwasm.Checksum, error) {
+	contractInfo, err := a.ContractInfo(contract)
+	if err != nil {
+		return nil, err
+	}
+	return a.checksum(contractInfo.CodeID)
+}
+
+// ibcBasicCall calls an IBC entry point of contract returning an IBCBasicResult
+// and dispatches the resulting messages
+func (a *App) ibcBasicCall(contract string, call func(checksum cosmwasm.Checksum, env types.Env, store types.KVStore) (*types.IBCBasicResult, uint64, error)) (*AppResponse, error) {
+	checksum, err := a.contractChecksum(contract)
+	if err != nil {
+		return &AppResponse{}, err
+	}
+	res, gasUsed, err := call(checksum, a.env(contract), a.contractStore(contract))
+	if err != nil {
+		return &AppResponse{GasUsed: gasUsed}, err
+	}
+	if res.Err != "" {
+		return &AppResponse{GasUsed: gasUsed}, errors.New(res.Err)
+	}
+	resp := &types.Response{Messages: res.Ok.Messages, Attributes: res.Ok.Attributes, Events: res.Ok.Events}
+	return a.handleResponse(contract, resp, nil, gasUsed, a.GasLimit)
+}
+
+// openChannel calls the ibc_channel_open entry point and returns the version of the channel
+func (a *App) openChannel(contract string, msg types.IBCChannelOpenMsg) (string, error) {
+	checksum, err := a.contractChecksum(contract)
+	if err != nil {
+		return "", err
+	}
+	res, _, err := a.vm.IBCChannelOpen(checksum, a.env(contract), msg, a.contractStore(contract), a.api, a, a.gasMeter(), a.GasLimit, a.DeserCost)
+	if err != nil {
+		return "", err
+	}
+	if res.Err != "" {
+		return "", errors.New(res.Err)
+	}
+	if res.Ok != nil && res.Ok.Version != "" {
+		return res.Ok.Version, nil
+	}
+	return msg.GetChannel().Version, nil
+}
+
+func (a *App) connectChannel(contract string, msg types.IBCChannelConnectMsg) (*AppResponse, error) {
+	return a.ibcBasicCall(contract, func(checksum cosmwasm.Checksum, env types.Env, store types.KVStore) (*types.IBCBasicResult, uint64, error) {
+		return a.vm.IBCChannelConnect(checksum, env, msg, store, a.api, a, a.gasMeter(), a.GasLimit, a.DeserCost)
+	})
+}
+
+// closeChannel marks the channel as closed and calls the ibc_channel_close entry point
+func (a *App) closeChannel(channelID string, ch channelEnd, msg types.IBCChannelCloseMsg) (*AppResponse, error) {
+	ch.State = channelClosed
+	a.setChannel(channelID, ch)
+	return a.ibcBasicCall(ch.Contract, func(checksum cosmwasm.Checksum, env types.Env, store types.KVStore) (*types.IBCBasicResult, uint64, error) {
+		return a.vm.IBCChannelClose(checksum, env, msg, store, a.api, a, a.gasMeter(), a.GasLimit, a.DeserCost)
+	})
+}
+
+// receivePacket calls the ibc_packet_receive entry point and stores the acknowledgement if there is one.
+// As in wasmd, an error of the contract is turned into an error acknowledgement
+// and all state changes of the contract are reverted.
+func (a *App) receivePacket(channelID string, packet types.IBCPacket, relayer string) ([]byte, error) {
+	ch, err := a.channel(channelID)
+	if err != nil {
+		return nil, err
+	}
+	if ch.State != channelOpen {
+		return nil, fmt.Errorf("channel %s is not open", channelID)
+	}
+	a.db.Set(sequenceKey(receiptsPrefix, channelID, packet.Sequence), []byte{1})
+
+	var ack []byte
+	err = a.transaction(func() error {
+		checksum, err := a.contractChecksum(ch.Contract)
+		if err != nil {
+			return err
+		}
+		msg := types.IBCPacketReceiveMsg{Packet: packet, Relayer: relayer}
+		res, gasUsed, err := a.vm.IBCPacketReceive(checksum, a.env(ch.Contract), msg, a.contractStore(ch.Contract), a.api, a, a.gasMeter(), a.GasLimit, a.DeserCost)
+		if err != nil {
+			return err
+		}
+		if res.Err != "" {
+			return errors.New(res.Err)
+		}
+		ack = res.Ok.Acknowledgement
+		resp := &types.Response{Messages: res.Ok.Messages, Attributes: res.Ok.Attributes, Events: res.Ok.Events}
+		_, err = a.handleResponse(ch.Contract, resp, nil, gasUsed, a.GasLimit)
+		return err
+	})
+	if err != nil {
+		ack = errorAck(err)
+	}
+	if ack == nil {
+		// asynchronous acknowledgement written later using IBCMsg.WriteAcknowledgement
+		return nil, nil
+	}
+	return ack, a.writeAck(channelID, packet.Sequence, ack)
+}
+
+// errorAck creates an error acknowledgement in the JSON format used by ibc-go
+func errorAck(err error) []byte {
+	bz, _ := json.Marshal(struct {
+		Error string `json:"error"`
+	}{err.Error()})
+	return bz
+}
+
+// acknowledgePacket removes the commitment and calls the ibc_packet_ack entry point
+func (a *App) acknowledgePacket(channelID string, sequence uint64, ack []byte, relayer string) (types.IBCPacket, error) {
+	packet, err := a.commitment(channelID, sequence)
+	if err != nil {
+		return packet, err
+	}
+	ch, err := a.channel(channelID)
+	if err != nil {
+		return packet, err
+	}
+	a.db.Delete(sequenceKey(commitmentsPrefix, channelID, sequence))
+	msg := types.IBCPacketAckMsg{
+		Acknowledgement: types.IBCAcknowledgement{Data: ack},
+		OriginalPacket:  packet,
+		Relayer:         relayer,
+	}
+	_, err = a.ibcBasicCall(ch.Contract, func(checksum cosmwasm.Checksum, env types.Env, store types.KVStore) (*types.IBCBasicResult, uint64, error) {
+		return a.vm.IBCPacketAck(checksum, env, msg, store, a.api, a, a.gasMeter(), a.GasLimit, a.DeserCost)
+	})
+	return packet, err
+}
+
+// timeoutPacket removes the commitment and calls the ibc_packet_timeout entry point.
+// As in ibc-go, a timeout closes ordered channels.
+func (a *App) timeoutPacket(channelID string, packet types.IBCPacket, relayer string) error {
+	ch, err := a.channel(channelID)
+	if err != nil {
+		return err
+	}
+	a.db.Delete(sequenceKey(commitmentsPrefix, channelID, packet.Sequence))
+	if ch.Channel.Order == types.Ordered {
+		ch.State = channelClosed
+		a.setChannel(channelID, ch)
+	}
+	msg := types.IBCPacketTimeoutMsg{Packet: packet, Relayer: relayer}
+	_, err = a.ibcBasicCall(ch.Contract, func(checksum cosmwasm.Checksum, env types.Env, store types.KVStore) (*types.IBCBasicResult, uint64, error) {
+		return a.vm.IBCPacketTimeout(checksum, env, msg, store, a.api, a, a.gasMeter(), a.GasLimit, a.DeserCost)
+	})
+	return err
+}
+
+// Endpoint is one end of a channel between two contracts.
+type Endpoint struct {
+	App      *App
+	Contract string
+	// ChannelID is assigned during the handshake
+	ChannelID string
+}
+
+func (e Endpoint) ibcEndpoint() types.IBCEndpoint {
+	return types.IBCEndpoint{PortID: PortID(e.Contract), ChannelID: e.ChannelID}
+}
+
+// Channel is an open channel between two contracts
+type Channel struct {
+	// A is the end that initiated the handshake
+	A Endpoint
+	// B is the counterparty
+	B       Endpoint
+	Order   types.IBCOrder
+	Version string
+}
+
+// PacketAck is an acknowledgement relayed back to the sender of a packet
+type PacketAck struct {
+	Packet types.IBCPacket
+	Ack    []byte
+}
+
+// RelayResult lists everything that was relayed by Relayer.Relay.
+type RelayResult struct {
+	// Received are the packets received by the destination contract
+	Received []types.IBCPacket
+	// Acks are the acknowledgements passed to the sending contract
+	Acks []PacketAck
+	// Timeouts are the packets that timed out
+	Timeouts []types.IBCPacket
+}
+
+// Relayer simulates an IBC relayer between contracts in one or two Apps.
+// Light clients and proofs are not simulated, so both ends trust each other.
+type Relayer struct {
+	// Address is the relayer address passed to the contracts
+	Address string
+}
+
+// NewRelayer creates a relayer with the given address.
+func NewRelayer(address string) *Relayer {
+	return &Relayer{Address: address}
+}
+
+// OpenChannel performs a channel handshake between the contracts a and b, which
+// may run in different Apps. a initiates the handshake with the given order and version.
+// The version can be changed by the contracts in ibc_channel_open.
+func (r *Relayer) OpenChannel(a, b Endpoint, order types.IBCOrder, version string) (*Channel, error) {
+	const connectionID = "connection-0"
+	var chA types.IBCChannel
+	err := a.App.transaction(func() (err error) {
+		a.ChannelID = a.App.newChannelID()
+		chA = types.IBCChannel{
+			Endpoint:             a.ibcEndpoint(),
+			CounterpartyEndpoint: types.IBCEndpoint{PortID: PortID(b.Contract)},
+			Order:                order,
+			Version:              version,
+			ConnectionID:         connectionID,
+		}
+		chA.Version, err = a.App.openChannel(a.Contract, (&types.IBCOpenInit{Channel: chA}).ToMsg())
+		a.App.setChannel(a.ChannelID, channelEnd{Channel: chA, Contract: a.Contract, State: channelInit, NextSequence: 1})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open init: %w", err)
+	}
+
+	var chB types.IBCChannel
+	err = b.App.transaction(func() (err error) {
+		b.ChannelID = b.App.newChannelID()
+		chB = types.IBCChannel{
+			Endpoint:             b.ibcEndpoint(),
+			CounterpartyEndpoint: a.ibcEndpoint(),
+			Order:                order,
+			Version:              chA.Version,
+			ConnectionID:         connectionID,
+		}
+		chB.Version, err = b.App.openChannel(b.Contract, (&types.IBCOpenTry{Channel: chB, CounterpartyVersion: chA.Version}).ToMsg())
+		b.App.setChannel(b.ChannelID, channelEnd{Channel: chB, Contract: b.Contract, State: channelTryOpen, NextSequence: 1})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open try: %w", err)
+	}
+
+	chA.CounterpartyEndpoint.ChannelID = b.ChannelID
+	chA.Version = chB.Version
+	err = a.App.transaction(func() error {
+		a.App.setChannel(a.ChannelID, channelEnd{Channel: chA, Contract: a.Contract, State: channelOpen, NextSequence: 1})
+		_, err := a.App.connectChannel(a.Contract, (&types.IBCOpenAck{Channel: chA, CounterpartyVersion: chB.Version}).ToMsg())
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open ack: %w", err)
+	}
+
+	err = b.App.transaction(func() error {
+		b.App.setChannel(b.ChannelID, channelEnd{Channel: chB, Contract: b.Contract, State: channelOpen, NextSequence: 1})
+		_, err := b.App.connectChannel(b.Contract, (&types.IBCOpenConfirm{Channel: chB}).ToMsg())
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("open confirm: %w", err)
+	}
+	return &Channel{A: a, B: b, Order: order, Version: chB.Version}, nil
+}
+
+// Relay relays all pending packets and acknowledgements in both directions until there is nothing
+// left to relay. Packets whose timeout has passed on the destination App are timed out instead
+// of being received. Closing a channel on one end is relayed to the other end.
+func (r *Relayer) Relay(ch *Channel) (*RelayResult, error) {
+	res := &RelayResult{}
+	for {
+		progress := false
+		for _, ends := range [][2]Endpoint{{ch.A, ch.B}, {ch.B, ch.A}} {
+			n, err := r.relay(ends[0], ends[1], res)
+			if err != nil {
+				return res, err
+			}
+			progress = progress || n > 0
+		}
+		if !progress {
+			return res, nil
+		}
+	}
+}
+
+// relay relays from src to dst and returns the number of relayed items
+func (r *Relayer) relay(src, dst Endpoint, res *RelayResult) (int, error) {
+	n := 0
+	dstCh, err := dst.App.channel(dst.ChannelID)
+	if err != nil {
+		return n, err
+	}
+	for _, packet := range src.App.commitments(src.ChannelID) {
+		if dst.App.hasReceipt(dst.ChannelID, packet.Sequence) {
+			continue
+		}
+		n++
+		// packets to a closed channel are timed out as well
+		if dst.App.timedOut(packet.Timeout) || dstCh.State == channelClosed {
+			err := src.App.transaction(func() error {
+				return src.App.timeoutPacket(src.ChannelID, packet, r.Address)
+			})
+			if err != nil {
+				return n, fmt.Errorf("timeout packet %d: %w", packet.Sequence, err)
+			}
+			res.Timeouts = append(res.Timeouts, packet)
+			continue
+		}
+		err := dst.App.transaction(func() error {
+			_, err := dst.App.receivePacket(dst.ChannelID, packet, r.Address)
+			return err
+		})
+		if err != nil {
+			return n, fmt.Errorf("receive packet %d: %w", packet.Sequence, err)
+		}
+		res.Received = append(res.Received, packet)
+	}
+
+	for _, ack := range dst.App.acks(dst.ChannelID) {
+		n++
+		var packet types.IBCPacket
+		err := src.App.transaction(func() (err error) {
+			packet, err = src.App.acknowledgePacket(src.ChannelID, ack.Sequence, ack.Data, r.Address)
+			return err
+		})
+		if err != nil {
+			return n, fmt.Errorf("acknowledge packet %d: %w", ack.Sequence, err)
+		}
+		// the acknowledgement stays on chain in ibc-go, but is removed here to mark it as relayed
+		dst.App.db.Delete(sequenceKey(acksPrefix, dst.ChannelID, ack.Sequence))
+		res.Acks = append(res.Acks, PacketAck{Packet: packet, Ack: ack.Data})
+	}
+
+	srcCh, err := src.App.channel(src.ChannelID)
+	if err != nil {
+		return n, err
+	}
+	dstCh, err = dst.App.channel(dst.ChannelID)
+	if err != nil {
+		return n, err
+	}
+	if srcCh.State == channelClosed && dstCh.State == channelOpen {
+		n++
+		err := dst.App.transaction(func() error {
+			_, err := dst.App.closeChannel(dst.ChannelID, dstCh, (&types.IBCCloseConfirm{Channel: dstCh.Channel}).ToMsg())
+			return err
+		})
+		if err != nil {
+			return n, fmt.Errorf("close confirm: %w", err)
+		}
+	}
+	return n, nil
+}
+
+// CloseChannel closes the channel on the A end and relays the closing to the B end.
+func (r *Relayer) CloseChannel(ch *Channel) error {
+	err := ch.A.App.transaction(func() error {
+		end, err := ch.A.App.channel(ch.A.ChannelID)
+		if err != nil {
+			return err
+		}
+		_, err = ch.A.App.closeChannel(ch.A.ChannelID, end, (&types.IBCCloseInit{Channel: end.Channel}).ToMsg())
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = r.Relay(ch)
+	return err
+}
@@ -0,0 +1,111 @@
+//go:build cgo && !nolink_libwasmvm
+
+package wasmvmtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+const IBC_REFLECT_TEST_CONTRACT = "../testdata/ibc_reflect.wasm"
+
+type accountInfo struct {
+	Account   string `json:"account"`
+	ChannelID string `json:"channel_id"`
+}
+
+// withIBCReflect stores the reflect and ibc_reflect codes and instantiates ibc_reflect
+func withIBCReflect(t *testing.T, app *App) string {
+	t.Helper()
+	reflectID := storeCode(t, app, REFLECT_TEST_CONTRACT)
+	codeID := storeCode(t, app, IBC_REFLECT_TEST_CONTRACT)
+	msg := []byte(fmt.Sprintf(`{"reflect_code_id":%d}`, reflectID))
+	contract, _, err := app.Instantiate("creator", codeID, msg, nil, "ibc-reflect", "")
+	require.NoError(t, err)
+	return contract
+}
+
+func listAccounts(t *testing.T, app *App, contract string) []accountInfo {
+	t.Helper()
+	bz, err := app.QuerySmart(contract, []byte(`{"list_accounts":{}}`))
+	require.NoError(t, err)
+	var res struct {
+		Accounts []accountInfo `json:"accounts"`
+	}
+	require.NoError(t, json.Unmarshal(bz, &res))
+	return res.Accounts
+}
+
+func TestIBCRelayer(t *testing.T) {
+	appA, appB := withApp(t), withApp(t)
+	contractA := withIBCReflect(t, appA)
+	contractB := withIBCReflect(t, appB)
+	relayer := NewRelayer("relayer")
+
+	ch, err := relayer.OpenChannel(Endpoint{App: appA, Contract: contractA}, Endpoint{App: appB, Contract: contractB}, types.Ordered, "ibc-reflect-v1")
+	require.NoError(t, err)
+	assert.Equal(t, "channel-0", ch.A.ChannelID)
+	assert.Equal(t, "channel-0", ch.B.ChannelID)
+	assert.Equal(t, "ibc-reflect-v1", ch.Version)
+
+	// the connect callback created a reflect account for the channel
+	accounts := listAccounts(t, appB, contractB)
+	require.Len(t, accounts, 1)
+	assert.Equal(t, ch.B.ChannelID, accounts[0].ChannelID)
+	account := accounts[0].Account
+	appB.SetBalances(account, types.Array[types.Coin]{types.NewCoin(1000, "uatom")})
+
+	// ibc_reflect only supports ordered channels
+	_, err = relayer.OpenChannel(Endpoint{App: appA, Contract: contractA}, Endpoint{App: appB, Contract: contractB}, types.Unordered, "ibc-reflect-v1")
+	require.ErrorContains(t, err, "open init")
+
+	// send a packet dispatching a bank message from the reflect account
+	data := []byte(`{"dispatch":{"msgs":[{"bank":{"send":{"to_address":"friend","amount":[{"denom":"uatom","amount":"123"}]}}}]}}`)
+	timeout := types.IBCTimeout{Timestamp: uint64(appB.Block.Time) + 1_000_000_000}
+	packet, err := appA.SendPacket(contractA, ch.A.ChannelID, data, timeout)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(1), packet.Sequence)
+	assert.Equal(t, types.IBCEndpoint{PortID: PortID(contractB), ChannelID: ch.B.ChannelID}, packet.Dest)
+
+	res, err := relayer.Relay(ch)
+	require.NoError(t, err)
+	assert.Equal(t, []types.IBCPacket{packet}, res.Received)
+	assert.Empty(t, res.Timeouts)
+	require.Len(t, res.Acks, 1)
+	assert.Equal(t, packet, res.Acks[0].Packet)
+	var ack struct {
+		Err string `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(res.Acks[0].Ack, &ack))
+	assert.Empty(t, ack.Err)
+	assert.Equal(t, types.NewCoin(123, "uatom"), appB.Balance("friend", "uatom"))
+	assert.Equal(t, types.NewCoin(877, "uatom"), appB.Balance(account, "uatom"))
+
+	// nothing left to relay
+	res, err = relayer.Relay(ch)
+	require.NoError(t, err)
+	assert.Equal(t, &RelayResult{}, res)
+
+	// a packet times out once the block time of the destination passes the timeout
+	packet, err = appA.SendPacket(contractA, ch.A.ChannelID, data, timeout)
+	require.NoError(t, err)
+	appB.Block.Time += 1_000_000_000
+	res, err = relayer.Relay(ch)
+	require.NoError(t, err)
+	assert.Empty(t, res.Received)
+	assert.Equal(t, []types.IBCPacket{packet}, res.Timeouts)
+	assert.Equal(t, types.NewCoin(123, "uatom"), appB.Balance("friend", "uatom"))
+
+	// the timeout closed the ordered channel on both ends
+	_, err = appA.SendPacket(contractA, ch.A.ChannelID, data, timeout)
+	require.ErrorContains(t, err, "not open")
+	bz, err := appB.Query(types.QueryRequest{IBC: &types.IBCQuery{ListChannels: &types.ListChannelsQuery{PortID: PortID(contractB)}}}, appB.GasLimit)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"channels":[]}`, string(bz))
+}
@@ -0,0 +1,76 @@
+//go:build cgo && !nolink_libwasmvm
+
+package wasmvmtest
+
+import (
+	"github.com/CosmWasm/wasmvm/v2/dispatch"
+	"github.com/CosmWasm/wasmvm/v2/store"
+	wasmvmtesting "github.com/CosmWasm/wasmvm/v2/testing"
+	"github.com/CosmWasm/wasmvm/v2/types"
+)
+
+// dbStore is a types.KVStore backed by a MemDB without gas metering
+type dbStore struct {
+	db *wasmvmtesting.MemDB
+}
+
+var _ types.KVStore = dbStore{}
+
+func (s dbStore) Get(key []byte) []byte {
+	v, err := s.db.Get(key)
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func (s dbStore) Set(key, value []byte) {
+	if err := s.db.Set(key, value); err != nil {
+		panic(err)
+	}
+}
+
+func (s dbStore) Delete(key []byte) {
+	if err := s.db.Delete(key); err != nil {
+		panic(err)
+	}
+}
+
+func (s dbStore) Iterator(start, end []byte) types.Iterator {
+	it, err := s.db.Iterator(start, end)
+	if err != nil {
+		panic(err)
+	}
+	return it
+}
+
+func (s dbStore) ReverseIterator(start, end []byte) types.Iterator {
+	it, err := s.db.ReverseIterator(start, end)
+	if err != nil {
+		panic(err)
+	}
+	return it
+}
+
+// state is the dispatch.Store of the App. Branches are cache stores that are written
+// to the parent store on success.
+type state struct {
+	types.KVStore
+}
+
+var _ dispatch.Store = state{}
+
+func (s state) Branch() dispatch.BranchedStore {
+	return branch{store.NewCacheKVStore(s.KVStore)}
+}
+
+// branch is a branched state
+type branch struct {
+	*store.CacheKVStore
+}
+
+var _ dispatch.BranchedStore = branch{}
+
+func (b branch) Branch() dispatch.BranchedStore {
+	return branch{b.CacheKVStore.Branch()}
+}